@@ -0,0 +1,29 @@
+package config
+
+import (
+	"Bitground-go/exchange"
+	"Bitground-go/util"
+)
+
+// 외부 API 기본 주소
+const (
+	DefaultCoinGeckoBaseURL = "https://api.coingecko.com"
+	DefaultBitgroundBaseURL = "https://api.bitground.kr"
+)
+
+// APIConfig 외부 API 주소 구성 구조체
+type APIConfig struct {
+	UpbitBaseURL     string
+	CoinGeckoBaseURL string
+	BitgroundBaseURL string
+}
+
+// NewAPIConfig APIConfig 생성 함수, 값이 없으면 실제 서비스 주소를 사용합니다.
+// 테스트에서는 *_BASE_URL 값을 가짜 서버 주소로 지정합니다.
+func NewAPIConfig(obj map[string]interface{}) APIConfig {
+	return APIConfig{
+		UpbitBaseURL:     util.GetString(obj, "UPBIT_BASE_URL", exchange.DefaultUpbitBaseURL),
+		CoinGeckoBaseURL: util.GetString(obj, "COINGECKO_BASE_URL", DefaultCoinGeckoBaseURL),
+		BitgroundBaseURL: util.GetString(obj, "BITGROUND_BASE_URL", DefaultBitgroundBaseURL),
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"Bitground-go/util"
+	"strings"
+	"time"
+)
+
+// 관심 코인 기본값 (INSIGHT_WATCHLIST가 없을 때)
+var DefaultInsightWatchlist = []string{"KRW-ETH", "KRW-SOL", "KRW-XRP"}
+
+// InsightConfig 인사이트 생성 구성 구조체
+type InsightConfig struct {
+	MaxAttempts   int      // 응답 검증 실패 시 최대 요청 횟수 (0 이하면 기본값)
+	Watchlist     []string // 개별 분석을 요청할 관심 코인 심볼
+	PromptDir     string   // 프롬프트 템플릿 디렉터리 (비어 있으면 DB, 기본 템플릿 순으로 사용)
+	PromptVersion string   // 사용할 템플릿 버전 (비어 있으면 활성/최신 버전)
+	Locales       []string // 저장할 인사이트 언어 (ko는 항상 생성하고, 나머지는 번역)
+
+	Concurrency    int           // 동시에 보내는 심볼별 요청 수
+	RequestTimeout time.Duration // LLM 요청 한 번의 시간 제한
+	Budget         time.Duration // 생성과 번역 요청 전체의 시간 제한 (저장 시간은 제외)
+}
+
+// 심볼별 인사이트 요청 기본값
+const (
+	DefaultInsightConcurrency    = 4
+	DefaultInsightRequestTimeout = 60 * time.Second
+	DefaultInsightBudget         = 120 * time.Second // Main 전체 제한 시간(4분) 안에서 저장할 시간을 남기도록
+)
+
+// NewInsightConfig InsightConfig 생성 함수
+// INSIGHT_WATCHLIST에 쉼표로 구분한 심볼 목록(예: KRW-ETH,KRW-SOL)을,
+// INSIGHT_LOCALES에 쉼표로 구분한 언어 코드(예: ko,en)를 지정합니다.
+// INSIGHT_REQUEST_TIMEOUT과 INSIGHT_BUDGET은 초 단위이며 0이면 따로 제한하지 않습니다. (Main 제한 시간만 적용)
+func NewInsightConfig(obj map[string]interface{}) (InsightConfig, error) {
+	maxAttempts, err := util.GetInt(obj, "INSIGHT_MAX_ATTEMPTS", 0)
+	if err != nil {
+		return InsightConfig{}, err
+	}
+
+	concurrency, err := util.GetInt(obj, "INSIGHT_CONCURRENCY", DefaultInsightConcurrency)
+	if err != nil {
+		return InsightConfig{}, err
+	}
+	timeoutSeconds, err := util.GetInt(obj, "INSIGHT_REQUEST_TIMEOUT", int(DefaultInsightRequestTimeout/time.Second))
+	if err != nil {
+		return InsightConfig{}, err
+	}
+	budgetSeconds, err := util.GetInt(obj, "INSIGHT_BUDGET", int(DefaultInsightBudget/time.Second))
+	if err != nil {
+		return InsightConfig{}, err
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultInsightConcurrency
+	}
+
+	cfg := InsightConfig{
+		MaxAttempts:    maxAttempts,
+		Concurrency:    concurrency,
+		RequestTimeout: time.Duration(timeoutSeconds) * time.Second,
+		Budget:         time.Duration(budgetSeconds) * time.Second,
+		Watchlist:      DefaultInsightWatchlist,
+		PromptDir:      util.GetString(obj, "INSIGHT_PROMPT_DIR", ""),
+		PromptVersion:  util.GetString(obj, "INSIGHT_PROMPT_VERSION", ""),
+	}
+
+	if raw := util.GetString(obj, "INSIGHT_WATCHLIST", ""); raw != "" {
+		cfg.Watchlist = splitList(raw, strings.ToUpper)
+	}
+	cfg.Locales = splitList(util.GetString(obj, "INSIGHT_LOCALES", "ko"), strings.ToLower)
+
+	return cfg, nil
+}
+
+// 쉼표로 구분한 목록을 공백 제거 후 normalize를 적용해 나눕니다. (빈 값은 제외)
+func splitList(raw string, normalize func(string) string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = normalize(strings.TrimSpace(value)); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
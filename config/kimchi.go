@@ -0,0 +1,35 @@
+package config
+
+import (
+	"Bitground-go/util"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 김치 프리미엄 환율 기본 출처 (업비트 USDT 원화 시세를 원/달러 환율로 사용)
+const DefaultKimchiFXSource = "KRW-USDT"
+
+// KimchiConfig 김치 프리미엄 계산 구성 구조체
+// FXRate가 0보다 크면 고정 환율을, 아니면 업비트 FXMarket 시세를 원/달러 환율로 사용합니다.
+type KimchiConfig struct {
+	FXMarket string
+	FXRate   float64
+}
+
+// NewKimchiConfig KimchiConfig 생성 함수
+// KIMCHI_FX_SOURCE에 업비트 마켓 코드(예: KRW-USDT) 또는 고정 환율(예: 1380)을 지정합니다.
+func NewKimchiConfig(obj map[string]interface{}) (KimchiConfig, error) {
+	source := strings.TrimSpace(util.GetString(obj, "KIMCHI_FX_SOURCE", DefaultKimchiFXSource))
+	if source == "" {
+		source = DefaultKimchiFXSource
+	}
+
+	if rate, err := strconv.ParseFloat(source, 64); err == nil {
+		if rate <= 0 {
+			return KimchiConfig{}, fmt.Errorf("KIMCHI_FX_SOURCE 환율은 0보다 커야 합니다: %s", source)
+		}
+		return KimchiConfig{FXRate: rate}, nil
+	}
+	return KimchiConfig{FXMarket: strings.ToUpper(source)}, nil
+}
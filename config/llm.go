@@ -0,0 +1,42 @@
+package config
+
+import (
+	"Bitground-go/llm"
+	"Bitground-go/util"
+	"strings"
+)
+
+// LLMConfig 인사이트 생성에 사용할 LLM 제공자 구성 구조체
+type LLMConfig struct {
+	Providers []llm.Options // 호출 순서대로, 앞선 제공자가 실패하면 다음 제공자 사용
+}
+
+// NewLLMConfig LLMConfig 생성 함수
+// LLM_PROVIDERS에 쉼표로 구분한 제공자 목록(gemini, openai, ollama)을 지정하며, 없으면 gemini만 사용합니다.
+// 제공자별 주소, 키, 모델은 GEMINI_*, OPENAI_*, OLLAMA_* 값으로 지정합니다. (Gemini 키는 GOOGLE_API_KEY)
+func NewLLMConfig(obj map[string]interface{}) LLMConfig {
+	var cfg LLMConfig
+	for _, name := range strings.Split(util.GetString(obj, "LLM_PROVIDERS", llm.ProviderGemini), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		opts := llm.Options{Name: name}
+		switch name {
+		case llm.ProviderGemini:
+			opts.BaseURL = util.GetString(obj, "GEMINI_BASE_URL", llm.DefaultGeminiBaseURL)
+			opts.APIKey = util.GetString(obj, "GOOGLE_API_KEY", "")
+			opts.Model = util.GetString(obj, "GEMINI_MODEL", llm.DefaultGeminiModel)
+		case llm.ProviderOpenAI:
+			opts.BaseURL = util.GetString(obj, "OPENAI_BASE_URL", llm.DefaultOpenAIBaseURL)
+			opts.APIKey = util.GetString(obj, "OPENAI_API_KEY", "")
+			opts.Model = util.GetString(obj, "OPENAI_MODEL", llm.DefaultOpenAIModel)
+		case llm.ProviderOllama:
+			opts.BaseURL = util.GetString(obj, "OLLAMA_BASE_URL", llm.DefaultOllamaBaseURL)
+			opts.Model = util.GetString(obj, "OLLAMA_MODEL", llm.DefaultOllamaModel)
+		}
+		cfg.Providers = append(cfg.Providers, opts)
+	}
+	return cfg
+}
@@ -0,0 +1,18 @@
+package exchange
+
+import (
+	"Bitground-go/model"
+	"context"
+	"time"
+)
+
+// MarketDataSource 거래소 시세 데이터 조회 인터페이스
+// 서비스 함수들은 이 인터페이스만 사용하므로 테스트용 가짜 구현으로 교체할 수 있습니다.
+type MarketDataSource interface {
+	// ListMarkets 거래소의 전체 마켓 목록을 유의/경고 정보와 함께 조회합니다.
+	ListMarkets(ctx context.Context) ([]model.UpbitCoinList, error)
+	// FetchTickers KRW 마켓 전체의 현재 시세를 조회합니다.
+	FetchTickers(ctx context.Context) ([]model.UpbitCoinPrice, error)
+	// FetchCandles 한 마켓의 분 단위(unit) 캔들을 to 시각 이전부터 최대 count개 조회합니다.
+	FetchCandles(ctx context.Context, market string, unit int, to time.Time, count int) ([]model.UpbitCandle, error)
+}
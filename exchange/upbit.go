@@ -0,0 +1,105 @@
+package exchange
+
+import (
+	"Bitground-go/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultUpbitBaseURL Upbit API 기본 주소
+const DefaultUpbitBaseURL = "https://api.upbit.com"
+
+// Upbit Upbit 공개 API를 사용하는 MarketDataSource 구현체
+type Upbit struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewUpbit Upbit 생성 함수, baseURL이 비어 있으면 기본 주소를 사용합니다.
+func NewUpbit(baseURL string) *Upbit {
+	if baseURL == "" {
+		baseURL = DefaultUpbitBaseURL
+	}
+	return &Upbit{
+		baseURL: baseURL,
+		client:  &http.Client{},
+	}
+}
+
+// ListMarkets 전체 마켓 목록을 조회합니다.
+func (u *Upbit) ListMarkets(ctx context.Context) ([]model.UpbitCoinList, error) {
+	var markets []model.UpbitCoinList
+	if err := u.getJSON(ctx, "/v1/market/all?is_details=true", &markets); err != nil {
+		return nil, err
+	}
+	return markets, nil
+}
+
+// FetchTickers KRW 마켓 전체의 현재 시세를 조회합니다.
+func (u *Upbit) FetchTickers(ctx context.Context) ([]model.UpbitCoinPrice, error) {
+	var tickers []model.UpbitCoinPrice
+	if err := u.getJSON(ctx, "/v1/ticker/all?quote_currencies=KRW", &tickers); err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
+
+// FetchCandles 한 마켓의 분 단위 캔들을 조회합니다. 결과는 최신 캔들부터 정렬되어 있습니다.
+func (u *Upbit) FetchCandles(ctx context.Context, market string, unit int, to time.Time, count int) ([]model.UpbitCandle, error) {
+	params := url.Values{}
+	params.Set("market", market)
+	params.Set("count", strconv.Itoa(count))
+	if !to.IsZero() {
+		params.Set("to", to.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+
+	var candles []model.UpbitCandle
+	path := fmt.Sprintf("/v1/candles/minutes/%d?%s", unit, params.Encode())
+	if err := u.getJSON(ctx, path, &candles); err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
+// getJSON GET 요청을 보내 JSON 응답을 v로 디코딩합니다.
+func (u *Upbit) getJSON(ctx context.Context, path string, v interface{}) error {
+	// Context를 활용한 HTTP 요청 (타임아웃: 10초)
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, "GET", u.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("HTTP 요청 생성 에러: %w", err)
+	}
+
+	// API 요청 보내기
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API 요청 에러: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		// 응답 본문을 닫아 리소스 누수 방지
+		if err := Body.Close(); err != nil {
+			log.Printf("응답 본문 닫기 에러: %v\n", err)
+		}
+	}(resp.Body)
+
+	// 응답 확인
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("서버 응답 에러: 상태 코드 %d", resp.StatusCode)
+	}
+
+	// JSON 응답 디코딩
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("JSON 디코딩 에러: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,390 @@
+// Package fakeapi Upbit, CoinGecko, LLM(Gemini, OpenAI 호환, Ollama 호환), Bitground API를 흉내내는 테스트용 HTTP 서버입니다.
+// 네트워크 없이 Main을 끝까지 실행할 수 있도록 하나의 httptest 서버에서 모든 서비스를 응답합니다.
+package fakeapi
+
+import (
+	"Bitground-go/model"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SeasonUpdate 스프링 서버로 전달된 시즌/스플릿 업데이트 요청 기록
+type SeasonUpdate struct {
+	SecretKey  string
+	SeasonFlag string
+}
+
+// Server 가짜 외부 API 서버
+// 각 필드는 Set* 함수로 교체하는 응답 픽스처입니다.
+type Server struct {
+	mu sync.Mutex
+	ts *httptest.Server
+
+	markets    []model.UpbitCoinList
+	tickers    []model.UpbitCoinPrice
+	candles    map[string][]model.UpbitCandle
+	marketCaps []model.GeckoCoin
+	capHistory map[string][][2]float64
+
+	llmReplies []string
+	prompts    []string
+
+	seasonUpdateStatus int
+	seasonUpdates      []SeasonUpdate
+}
+
+// New 가짜 API 서버 생성 및 시작 함수, 사용 후 Close를 호출해야 합니다.
+func New() *Server {
+	s := &Server{
+		candles:            make(map[string][]model.UpbitCandle),
+		capHistory:         make(map[string][][2]float64),
+		seasonUpdateStatus: http.StatusOK,
+	}
+
+	mux := http.NewServeMux()
+	// Upbit
+	mux.HandleFunc("/v1/market/all", s.handleMarkets)
+	mux.HandleFunc("/v1/ticker/all", s.handleTickers)
+	mux.HandleFunc("/v1/candles/minutes/", s.handleCandles)
+	// CoinGecko
+	mux.HandleFunc("/api/v3/coins/markets", s.handleMarketCaps)
+	mux.HandleFunc("/api/v3/coins/list", s.handleCoinList)
+	mux.HandleFunc("/api/v3/coins/", s.handleMarketCapHistory)
+	// LLM
+	mux.HandleFunc("/v1beta/models/", s.handleGenerate)
+	mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	mux.HandleFunc("/api/generate", s.handleOllamaGenerate)
+	// Bitground
+	mux.HandleFunc("/seasons/update", s.handleSeasonUpdate)
+
+	s.ts = httptest.NewServer(mux)
+	return s
+}
+
+// URL 서버 주소
+func (s *Server) URL() string {
+	return s.ts.URL
+}
+
+// Close 서버 종료
+func (s *Server) Close() {
+	s.ts.Close()
+}
+
+// Config Main에 넘기는 obj에 합칠 외부 API 주소 설정값을 반환합니다.
+func (s *Server) Config() map[string]interface{} {
+	return map[string]interface{}{
+		"UPBIT_BASE_URL":     s.ts.URL,
+		"COINGECKO_BASE_URL": s.ts.URL,
+		"GEMINI_BASE_URL":    s.ts.URL,
+		"OPENAI_BASE_URL":    s.ts.URL,
+		"OLLAMA_BASE_URL":    s.ts.URL,
+		"BITGROUND_BASE_URL": s.ts.URL,
+	}
+}
+
+// SetMarkets Upbit 마켓 목록 응답 설정
+func (s *Server) SetMarkets(markets []model.UpbitCoinList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markets = markets
+}
+
+// SetTickers Upbit 현재 시세 응답 설정
+func (s *Server) SetTickers(tickers []model.UpbitCoinPrice) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickers = tickers
+}
+
+// SetCandles 마켓별 Upbit 분 캔들 응답 설정 (candle_date_time_utc 기준으로 요청의 to, count를 적용)
+func (s *Server) SetCandles(market string, candles []model.UpbitCandle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.candles[market] = candles
+}
+
+// SetMarketCaps CoinGecko 시가총액 응답 설정 (coins/list 응답에도 사용)
+func (s *Server) SetMarketCaps(coins []model.GeckoCoin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marketCaps = coins
+}
+
+// SetMarketCapHistory CoinGecko 코인 id별 과거 시가총액 응답 설정 ([밀리초 타임스탬프, 시가총액] 목록)
+func (s *Server) SetMarketCapHistory(id string, points [][2]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capHistory[id] = points
+}
+
+// SetLLMReplies LLM 응답 텍스트 설정, 요청마다 순서대로 반환하고 마지막 응답은 반복합니다.
+func (s *Server) SetLLMReplies(replies ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.llmReplies = replies
+}
+
+// SetSeasonUpdateStatus 시즌 업데이트 요청에 돌려줄 상태 코드 설정
+func (s *Server) SetSeasonUpdateStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seasonUpdateStatus = status
+}
+
+// Prompts 지금까지 LLM에 전달된 프롬프트 목록
+func (s *Server) Prompts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.prompts...)
+}
+
+// SeasonUpdates 지금까지 받은 시즌/스플릿 업데이트 요청 목록
+func (s *Server) SeasonUpdates() []SeasonUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SeasonUpdate(nil), s.seasonUpdates...)
+}
+
+func (s *Server) handleMarkets(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.markets)
+}
+
+func (s *Server) handleTickers(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.tickers)
+}
+
+// Upbit처럼 to(UTC, 제외) 이전에 시작한 캔들을 최신순으로 최대 count개 돌려줍니다. (to가 없으면 전체, count가 없으면 200개)
+func (s *Server) handleCandles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var to time.Time
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		to = parsed
+	}
+	count := 200
+	if raw := query.Get("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid count"})
+			return
+		}
+		count = parsed
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	candles := make([]model.UpbitCandle, 0, count)
+	for _, candle := range s.candles[query.Get("market")] {
+		start, err := time.Parse("2006-01-02T15:04:05", candle.CandleDateTimeUTC)
+		if err == nil && (to.IsZero() || start.Before(to)) {
+			candles = append(candles, candle)
+		}
+	}
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].CandleDateTimeUTC > candles[j].CandleDateTimeUTC
+	})
+	if len(candles) > count {
+		candles = candles[:count]
+	}
+	writeJSON(w, http.StatusOK, candles)
+}
+
+// CoinGecko처럼 시가총액 내림차순으로 per_page개(기본 100개)를 돌려줍니다. ids가 있으면 해당 id의 코인만 돌려줍니다.
+func (s *Server) handleMarketCaps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	perPage := 100
+	if raw := query.Get("per_page"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid per_page"})
+			return
+		}
+		perPage = parsed
+	}
+	ids := make(map[string]bool)
+	if raw := query.Get("ids"); raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			ids[id] = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	coins := make([]model.GeckoCoin, 0, len(s.marketCaps))
+	for _, coin := range s.marketCaps {
+		if len(ids) == 0 || ids[coin.ID] {
+			coins = append(coins, coin)
+		}
+	}
+	sort.SliceStable(coins, func(i, j int) bool {
+		return coins[i].MarketCap > coins[j].MarketCap
+	})
+	if len(coins) > perPage {
+		coins = coins[:perPage]
+	}
+	writeJSON(w, http.StatusOK, coins)
+}
+
+// 설정된 시가총액 응답의 전체 코인 id와 심볼을 돌려줍니다.
+func (s *Server) handleCoinList(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]map[string]string, 0, len(s.marketCaps))
+	for _, coin := range s.marketCaps {
+		list = append(list, map[string]string{"id": coin.ID, "symbol": coin.Symbol})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// /api/v3/coins/{id}/market_chart/range 요청에 설정된 과거 시가총액을 돌려줍니다. (기간 필터 없음)
+func (s *Server) handleMarketCapHistory(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v3/coins/"), "/market_chart/range")
+	if id == "" || strings.Contains(id, "/") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	points := s.capHistory[id]
+	if points == nil {
+		points = [][2]float64{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"market_caps": points})
+}
+
+// Gemini generateContent 형식으로 설정된 응답 텍스트를 돌려줍니다.
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Contents []struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var prompt []string
+	for _, content := range body.Contents {
+		for _, part := range content.Parts {
+			prompt = append(prompt, part.Text)
+		}
+	}
+	reply := s.nextLLMReply(strings.Join(prompt, "\n"))
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"candidates": []interface{}{
+			map[string]interface{}{
+				"content": map[string]interface{}{
+					"parts": []interface{}{
+						map[string]string{"text": reply},
+					},
+				},
+			},
+		},
+	})
+}
+
+// OpenAI chat completions 형식으로 설정된 응답 텍스트를 돌려줍니다.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var prompt []string
+	for _, message := range body.Messages {
+		prompt = append(prompt, message.Content)
+	}
+	reply := s.nextLLMReply(strings.Join(prompt, "\n"))
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"model": body.Model,
+		"choices": []interface{}{
+			map[string]interface{}{
+				"message": map[string]string{"role": "assistant", "content": reply},
+			},
+		},
+	})
+}
+
+// Ollama generate 형식으로 설정된 응답 텍스트를 돌려줍니다.
+func (s *Server) handleOllamaGenerate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"response": s.nextLLMReply(body.Prompt),
+		"done":     true,
+	})
+}
+
+// 프롬프트를 기록하고 다음 LLM 응답을 꺼냅니다.
+func (s *Server) nextLLMReply(prompt string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prompts = append(s.prompts, prompt)
+
+	reply := ""
+	if len(s.llmReplies) > 0 {
+		reply = s.llmReplies[0]
+		if len(s.llmReplies) > 1 {
+			s.llmReplies = s.llmReplies[1:]
+		}
+	}
+	return reply
+}
+
+func (s *Server) handleSeasonUpdate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seasonUpdates = append(s.seasonUpdates, SeasonUpdate{
+		SecretKey:  r.PostForm.Get("secretKey"),
+		SeasonFlag: r.PostForm.Get("seasonFlag"),
+	})
+	w.WriteHeader(s.seasonUpdateStatus)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("가짜 API 응답 인코딩 에러: %v\n", err)
+	}
+}
@@ -0,0 +1,56 @@
+package fakeapi
+
+import (
+	"Bitground-go/exchange"
+	"Bitground-go/model"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCandlesToAndCount(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	base := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	var candles []model.UpbitCandle
+	for h := 0; h < 10; h++ {
+		candles = append(candles, model.UpbitCandle{
+			Market:            "KRW-BTC",
+			CandleDateTimeUTC: base.Add(time.Duration(h) * time.Hour).Format("2006-01-02T15:04:05"),
+		})
+	}
+	s.SetCandles("KRW-BTC", candles)
+
+	upbit := exchange.NewUpbit(s.URL())
+	ctx := context.Background()
+
+	// to는 제외, 최신순
+	got, err := upbit.FetchCandles(ctx, "KRW-BTC", 60, base.Add(5*time.Hour), 3)
+	if err != nil {
+		t.Fatalf("FetchCandles 에러: %v", err)
+	}
+	want := []string{"2024-03-15T04:00:00", "2024-03-15T03:00:00", "2024-03-15T02:00:00"}
+	if len(got) != len(want) {
+		t.Fatalf("캔들 %d개, want %d개", len(got), len(want))
+	}
+	for i, c := range got {
+		if c.CandleDateTimeUTC != want[i] {
+			t.Errorf("candles[%d] = %s, want %s", i, c.CandleDateTimeUTC, want[i])
+		}
+	}
+
+	// to가 없으면 최신 캔들부터
+	got, err = upbit.FetchCandles(ctx, "KRW-BTC", 60, time.Time{}, 200)
+	if err != nil {
+		t.Fatalf("FetchCandles 에러: %v", err)
+	}
+	if len(got) != 10 || got[0].CandleDateTimeUTC != "2024-03-15T09:00:00" {
+		t.Errorf("전체 캔들 %d개, 첫 캔들 %+v", len(got), got)
+	}
+
+	// 설정하지 않은 마켓은 빈 목록
+	if got, err := upbit.FetchCandles(ctx, "KRW-ETH", 60, time.Time{}, 1); err != nil || len(got) != 0 {
+		t.Errorf("KRW-ETH = %+v, %v", got, err)
+	}
+}
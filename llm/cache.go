@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+)
+
+// Cache 모델 호출을 기록하고, 같은 요청에 대해 확인된 응답을 재사용하는 저장소
+type Cache interface {
+	// Lookup key로 확인된(Confirm) 응답을 찾습니다. 없으면 found는 false입니다.
+	Lookup(ctx context.Context, key string) (resp Response, found bool, err error)
+	// Save 호출 결과를 기록하고 호출 id를 반환합니다. 실패한 호출도 디버깅을 위해 기록합니다.
+	Save(ctx context.Context, key string, req Request, resp Response, callErr error, elapsed time.Duration) (int64, error)
+	// Confirm 검증을 통과한 호출을 재사용 대상으로 표시합니다.
+	Confirm(ctx context.Context, callID int64) error
+}
+
+// RequestKey 요청의 캐시 키 (제공자 경로, 프롬프트, 스키마의 SHA-256)
+// 제공자나 모델 설정을 바꾸면 키가 달라져 이전 모델의 응답을 재사용하지 않습니다.
+func RequestKey(route string, req Request) string {
+	hash := sha256.New()
+	hash.Write([]byte(route))
+	hash.Write([]byte{0})
+	hash.Write([]byte(req.Prompt))
+	hash.Write([]byte{0})
+	hash.Write(req.Schema)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// CachedProvider 호출을 Cache에 기록하고, 확인된 응답이 있으면 제공자를 호출하지 않고 재사용하는 Provider
+type CachedProvider struct {
+	next  Provider
+	cache Cache
+	route string // 캐시 키에 넣는 제공자 경로 (Route)
+}
+
+// WithCache provider 호출을 cache에 기록하는 Provider를 반환합니다.
+// route는 provider를 만든 제공자 설정(Route)으로, 같은 route의 응답만 재사용합니다.
+func WithCache(provider Provider, cache Cache, route string) *CachedProvider {
+	return &CachedProvider{next: provider, cache: cache, route: route}
+}
+
+// Generate 확인된 응답이 있으면 그대로 반환하고, 없으면 제공자를 호출하여 결과를 기록합니다.
+// 기록 저장에 실패해도 모델 응답은 그대로 반환합니다.
+func (c *CachedProvider) Generate(ctx context.Context, req Request) (Response, error) {
+	key := RequestKey(c.route, req)
+
+	resp, found, err := c.cache.Lookup(ctx, key)
+	if err != nil {
+		log.Printf("LLM 응답 캐시 조회 실패: %v\n", err)
+	} else if found {
+		resp.Text = ExtractJSON(resp.Raw)
+		resp.Cached = true
+		return resp, nil
+	}
+
+	started := time.Now()
+	resp, callErr := c.next.Generate(ctx, req)
+	callID, err := c.cache.Save(ctx, key, req, resp, callErr, time.Since(started))
+	if err != nil {
+		log.Printf("LLM 호출 기록 실패: %v\n", err)
+	}
+	resp.CallID = callID
+
+	return resp, callErr
+}
+
+// Confirm 응답이 검증을 통과했음을 기록합니다. 이후 같은 요청은 이 응답을 재사용합니다.
+func (c *CachedProvider) Confirm(ctx context.Context, resp Response) error {
+	if resp.CallID == 0 || resp.Cached {
+		return nil
+	}
+	return c.cache.Confirm(ctx, resp.CallID)
+}
+
+// Confirm provider가 캐시를 사용하면 resp를 재사용 대상으로 표시합니다. 캐시를 사용하지 않으면 아무것도 하지 않습니다.
+func Confirm(ctx context.Context, provider Provider, resp Response) error {
+	if cached, ok := provider.(*CachedProvider); ok {
+		return cached.Confirm(ctx, resp)
+	}
+	return nil
+}
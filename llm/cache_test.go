@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryCache 메모리에 호출 기록을 저장하는 테스트용 Cache
+type memoryCache struct {
+	calls     []memoryCall
+	lookupErr error
+	saveErr   error
+}
+
+type memoryCall struct {
+	Key       string
+	Req       Request
+	Resp      Response
+	Err       error
+	Confirmed bool
+}
+
+func (c *memoryCache) Lookup(ctx context.Context, key string) (Response, bool, error) {
+	if c.lookupErr != nil {
+		return Response{}, false, c.lookupErr
+	}
+	for i := len(c.calls) - 1; i >= 0; i-- {
+		if call := c.calls[i]; call.Key == key && call.Confirmed {
+			return Response{CallID: int64(i + 1), Provider: call.Resp.Provider, Model: call.Resp.Model, Raw: call.Resp.Raw}, true, nil
+		}
+	}
+	return Response{}, false, nil
+}
+
+func (c *memoryCache) Save(ctx context.Context, key string, req Request, resp Response, callErr error, elapsed time.Duration) (int64, error) {
+	if c.saveErr != nil {
+		return 0, c.saveErr
+	}
+	c.calls = append(c.calls, memoryCall{Key: key, Req: req, Resp: resp, Err: callErr})
+	return int64(len(c.calls)), nil
+}
+
+func (c *memoryCache) Confirm(ctx context.Context, callID int64) error {
+	c.calls[callID-1].Confirmed = true
+	return nil
+}
+
+func TestCachedProviderMissAndHit(t *testing.T) {
+	ctx := context.Background()
+	next := &stubProvider{resp: Response{Text: "[1]", Raw: "```json\n[1]\n```", Provider: "gemini", Model: "g"}}
+	cache := &memoryCache{}
+	provider := WithCache(next, cache, "gemini/g")
+	req := Request{Prompt: "p", Schema: testSchema, Purpose: "insight:ALL"}
+
+	// 1. 기록이 없으면 제공자를 호출하고 기록
+	resp, err := provider.Generate(ctx, req)
+	if err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+	if next.calls != 1 || resp.Cached || resp.CallID != 1 {
+		t.Errorf("첫 호출: 제공자 %d회, Cached %v, CallID %d", next.calls, resp.Cached, resp.CallID)
+	}
+	if len(cache.calls) != 1 || cache.calls[0].Key != RequestKey("gemini/g", req) || cache.calls[0].Req.Purpose != "insight:ALL" {
+		t.Errorf("호출 기록 = %+v", cache.calls)
+	}
+
+	// 2. 확인하기 전에는 재사용하지 않음
+	if _, err := provider.Generate(ctx, req); err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+	if next.calls != 2 {
+		t.Errorf("확인되지 않은 응답은 재사용하지 않아야 함: 제공자 %d회", next.calls)
+	}
+
+	// 3. 확인 후에는 제공자를 호출하지 않고 재사용 (원문에서 JSON 추출)
+	if err := Confirm(ctx, provider, resp); err != nil {
+		t.Fatalf("Confirm 에러: %v", err)
+	}
+	cached, err := provider.Generate(ctx, req)
+	if err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+	if next.calls != 2 || !cached.Cached || cached.Text != "[1]" || cached.Provider != "gemini" || cached.CallID != 1 {
+		t.Errorf("재사용 응답 = %+v (제공자 %d회)", cached, next.calls)
+	}
+
+	// 재사용한 응답을 다시 확인해도 기록을 바꾸지 않음
+	if err := Confirm(ctx, provider, cached); err != nil {
+		t.Errorf("Confirm 에러: %v", err)
+	}
+}
+
+func TestCachedProviderKey(t *testing.T) {
+	req := Request{Prompt: "p", Schema: testSchema, Purpose: "insight:KRW-BTC"}
+	base := RequestKey("gemini/g", req)
+
+	tests := []struct {
+		name  string
+		route string
+		req   Request
+		same  bool
+	}{
+		{"용도만 다름", "gemini/g", Request{Prompt: "p", Schema: testSchema, Purpose: "insight:KRW-ETH"}, true},
+		{"모델이 다름", "gemini/g2", req, false},
+		{"제공자가 다름", "openai/g", req, false},
+		{"프롬프트가 다름", "gemini/g", Request{Prompt: "p2", Schema: testSchema}, false},
+		{"스키마가 다름", "gemini/g", Request{Prompt: "p"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequestKey(tt.route, tt.req) == base; got != tt.same {
+				t.Errorf("같은 키 = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	// 모델 설정을 바꾸면 확인된 응답이 있어도 다시 호출
+	ctx := context.Background()
+	cache := &memoryCache{}
+	old := WithCache(&stubProvider{resp: Response{Raw: "[1]"}}, cache, "gemini/g")
+	resp, _ := old.Generate(ctx, req)
+	_ = Confirm(ctx, old, resp)
+
+	next := &stubProvider{resp: Response{Raw: "[2]"}}
+	if _, err := WithCache(next, cache, "gemini/g2").Generate(ctx, req); err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+	if next.calls != 1 {
+		t.Error("다른 모델의 응답을 재사용하면 안 됨")
+	}
+}
+
+func TestCachedProviderErrors(t *testing.T) {
+	ctx := context.Background()
+
+	// 제공자 호출 실패도 기록하고, 에러를 그대로 반환
+	cache := &memoryCache{}
+	callErr := errors.New("quota")
+	_, err := WithCache(&stubProvider{err: callErr}, cache, "r").Generate(ctx, Request{Prompt: "p"})
+	if !errors.Is(err, callErr) {
+		t.Errorf("에러 = %v, want %v", err, callErr)
+	}
+	if len(cache.calls) != 1 || !errors.Is(cache.calls[0].Err, callErr) {
+		t.Errorf("실패한 호출도 기록해야 함: %+v", cache.calls)
+	}
+
+	// 캐시 조회/저장에 실패해도 모델 응답은 반환
+	broken := &memoryCache{lookupErr: errors.New("db down"), saveErr: errors.New("db down")}
+	next := &stubProvider{resp: Response{Text: "[]"}}
+	resp, err := WithCache(next, broken, "r").Generate(ctx, Request{Prompt: "p"})
+	if err != nil || resp.Text != "[]" || resp.CallID != 0 || next.calls != 1 {
+		t.Errorf("캐시 장애 시 응답 = %+v, %v", resp, err)
+	}
+
+	// 캐시를 쓰지 않는 제공자는 Confirm이 아무것도 하지 않음
+	if err := Confirm(ctx, next, Response{CallID: 1}); err != nil {
+		t.Errorf("Confirm 에러: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Gemini 기본 설정
+// 기본 모델은 기존에 쓰던 미리보기 모델(gemini-2.5-flash-preview-05-20) 대신 정식 모델을 사용합니다.
+// 이전 모델이 필요하면 GEMINI_MODEL로 지정합니다.
+const (
+	DefaultGeminiBaseURL = "https://generativelanguage.googleapis.com"
+	DefaultGeminiModel   = "gemini-2.5-flash"
+)
+
+// geminiRequest Gemini generateContent 요청 본문
+type geminiRequest struct {
+	Contents         []geminiContent        `json:"contents"`
+	GenerationConfig geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiContent struct {
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiGenerationConfig struct {
+	ResponseMimeType   string          `json:"responseMimeType"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+// geminiResponse Gemini generateContent 응답 본문
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+}
+
+// Gemini Google Gemini API를 사용하는 Provider 구현체
+type Gemini struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewGemini Gemini 생성 함수, 비어 있는 값은 기본값을 사용합니다.
+func NewGemini(baseURL, apiKey, model string) *Gemini {
+	if baseURL == "" {
+		baseURL = DefaultGeminiBaseURL
+	}
+	if model == "" {
+		model = DefaultGeminiModel
+	}
+	return &Gemini{baseURL: baseURL, apiKey: apiKey, model: model, client: &http.Client{}}
+}
+
+// Generate generateContent API로 JSON 응답을 생성합니다.
+// 스키마가 있으면 responseJsonSchema로 응답 형식을 제한합니다.
+func (g *Gemini) Generate(ctx context.Context, req Request) (Response, error) {
+	// Context를 활용한 HTTP 요청 (호출자 기한, 없으면 기본 3분)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+
+	body := geminiRequest{
+		Contents: []geminiContent{{Parts: []geminiPart{{Text: req.Prompt}}}},
+		GenerationConfig: geminiGenerationConfig{
+			ResponseMimeType:   "application/json",
+			ResponseJSONSchema: req.Schema,
+		},
+	}
+
+	var resp geminiResponse
+	url := fmt.Sprintf("%s/v1beta/models/%s:generateContent", g.baseURL, g.model)
+	if err := postJSON(reqCtx, g.client, url, map[string]string{"x-goog-api-key": g.apiKey}, body, &resp); err != nil {
+		return Response{}, fmt.Errorf("gemini(%s): %w", g.model, err)
+	}
+
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return Response{}, fmt.Errorf("gemini(%s): 모델 응답에 유효한 데이터가 없습니다", g.model)
+	}
+
+	return Response{
+		Text:     ExtractJSON(resp.Candidates[0].Content.Parts[0].Text),
+		Raw:      resp.Candidates[0].Content.Parts[0].Text,
+		Provider: ProviderGemini,
+		Model:    g.model,
+	}, nil
+}
@@ -0,0 +1,200 @@
+// Package llm 인사이트 생성에 사용하는 LLM API 공통 인터페이스와 구현체(Gemini, OpenAI 호환, Ollama 호환)입니다.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 지원하는 제공자 이름
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+)
+
+// Provider 프롬프트로 JSON 응답을 생성하는 LLM 인터페이스
+// 서비스 함수들은 이 인터페이스만 사용하므로 설정만으로 모델/제공자를 교체할 수 있습니다.
+type Provider interface {
+	// Generate 프롬프트를 보내 모델 응답에서 추출한 JSON 텍스트를 반환합니다.
+	Generate(ctx context.Context, req Request) (Response, error)
+}
+
+// Request 모델 요청
+type Request struct {
+	Prompt string
+	// Schema 응답 JSON 스키마 (JSON Schema 형식), 제공자가 지원하면 응답 형식 제약으로 함께 전달합니다.
+	// 지원하지 않는 제공자에서는 무시되므로 호출자가 응답을 직접 검증해야 합니다.
+	Schema json.RawMessage
+	// Purpose 요청 용도 (예: insight:KRW-ETH), 호출 기록과 재생에만 사용하며 캐시 키에는 포함하지 않습니다.
+	Purpose string
+}
+
+// Response 모델 응답
+type Response struct {
+	Text     string // 코드 블록 등을 제거한 JSON 텍스트
+	Raw      string // 모델 출력 원문
+	Provider string // 응답을 생성한 제공자 이름
+	Model    string // 응답을 생성한 모델 이름
+
+	CallID int64 // 호출 기록 id (캐시를 사용할 때만)
+	Cached bool  // 저장된 응답을 재사용했는지 여부
+}
+
+// 호출자 컨텍스트에 기한이 없을 때 제공자 요청 한 번의 시간 제한
+const defaultRequestTimeout = 3 * time.Minute
+
+// requestContext 제공자 요청 컨텍스트를 만듭니다.
+// 호출자 컨텍스트에 기한이 있으면 그 기한을 따르고, 없으면 기본 시간 제한을 둡니다.
+func requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultRequestTimeout)
+}
+
+// Options 제공자 생성 설정
+type Options struct {
+	Name    string // gemini, openai, ollama
+	BaseURL string
+	APIKey  string
+	Model   string
+}
+
+// Route 제공자 설정 순서를 "gemini/gemini-2.5-flash,openai/gpt-4o-mini" 형태로 나타냅니다. (응답 캐시 키에 사용)
+func Route(opts ...Options) string {
+	parts := make([]string, len(opts))
+	for i, opt := range opts {
+		parts[i] = opt.Name + "/" + opt.Model
+	}
+	return strings.Join(parts, ",")
+}
+
+// New 설정에 맞는 제공자 생성 함수
+func New(opts Options) (Provider, error) {
+	switch opts.Name {
+	case ProviderGemini:
+		return NewGemini(opts.BaseURL, opts.APIKey, opts.Model), nil
+	case ProviderOpenAI:
+		return NewOpenAI(opts.BaseURL, opts.APIKey, opts.Model), nil
+	case ProviderOllama:
+		return NewOllama(opts.BaseURL, opts.Model), nil
+	default:
+		return nil, fmt.Errorf("지원하지 않는 LLM 제공자: %q", opts.Name)
+	}
+}
+
+// NewChain 설정 순서대로 제공자를 생성하여, 앞선 제공자가 실패하면 다음 제공자를 사용하는 Provider를 반환합니다.
+func NewChain(opts ...Options) (Provider, error) {
+	if len(opts) == 0 {
+		return nil, fmt.Errorf("LLM 제공자 설정이 없음")
+	}
+
+	providers := make([]Provider, 0, len(opts))
+	for _, opt := range opts {
+		provider, err := New(opt)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return Fallback(providers), nil
+}
+
+// Fallback 순서대로 시도하여 처음 성공한 응답을 반환하는 Provider
+type Fallback []Provider
+
+// Generate 각 제공자를 순서대로 호출합니다. 모두 실패하면 마지막 에러를 반환합니다.
+// ctx에 기한이 있으면 남은 시간을 남은 제공자 수로 나눠 각 제공자에 주므로, 앞선 제공자가 느려도 다음 제공자가 시도할 시간이 남습니다.
+func (f Fallback) Generate(ctx context.Context, req Request) (Response, error) {
+	var lastErr error
+	for i, provider := range f {
+		resp, err := f.generate(ctx, provider, req, len(f)-i)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return Response{}, err
+		}
+		log.Printf("LLM 제공자 호출 실패, 다음 제공자 시도: %v\n", err)
+		lastErr = err
+	}
+	return Response{}, fmt.Errorf("모든 LLM 제공자 호출 실패: %w", lastErr)
+}
+
+// 남은 제공자 remaining개 중 하나로서 남은 시간의 몫만큼 호출합니다.
+func (f Fallback) generate(ctx context.Context, provider Provider, req Request, remaining int) (Response, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Until(deadline)/time.Duration(remaining))
+		defer cancel()
+	}
+	return provider.Generate(ctx, req)
+}
+
+var jsonBlockPattern = regexp.MustCompile("(?s)```(?:json)?\\s*(.*?)\\s*```") // `(?s)`는 `.`이 줄바꿈 문자도 포함하도록 함
+
+// ExtractJSON 모델 출력에서 JSON 텍스트만 추출합니다. ```json ... ``` 코드 블록이 있으면 그 안의 내용을 사용합니다.
+func ExtractJSON(text string) string {
+	if matches := jsonBlockPattern.FindStringSubmatch(text); len(matches) > 1 {
+		return strings.TrimSpace(matches[1])
+	}
+	return strings.TrimSpace(text)
+}
+
+// postJSON body를 JSON으로 POST하고 응답을 v로 디코딩합니다.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, v interface{}) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("JSON 인코딩 오류: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("HTTP 요청 생성 에러: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API 요청 에러: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		// 응답 본문 닫기
+		if err := Body.Close(); err != nil {
+			log.Printf("응답 본문 닫기 에러: %v\n", err)
+		}
+	}(resp.Body)
+
+	// 응답 본문 읽기
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("응답 본문 읽기 에러: %w", err)
+	}
+
+	// HTTP 응답 상태 코드 확인
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API 요청 실패: 상태 코드 %d, 응답: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if err := json.Unmarshal(bodyBytes, v); err != nil {
+		return fmt.Errorf("JSON 디코딩 에러: %w", err)
+	}
+
+	return nil
+}
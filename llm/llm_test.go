@@ -0,0 +1,321 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedRequest 테스트 서버가 받은 요청
+type recordedRequest struct {
+	Path   string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+// newTestServer 받은 요청을 기록하고 status와 reply로 응답하는 테스트 서버
+func newTestServer(t *testing.T, status int, reply string) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("요청 본문 읽기 에러: %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("요청 본문이 JSON이 아님: %v", err)
+		}
+		requests = append(requests, recordedRequest{Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+var testSchema = json.RawMessage(`{"type":"array"}`)
+
+func TestGeminiGenerate(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK,
+		`{"candidates":[{"content":{"parts":[{"text":"`+"```json\\n[{\\\"symbol\\\":\\\"KRW-BTC\\\"}]\\n```"+`"}]}}]}`)
+
+	resp, err := NewGemini(server.URL, "test-key", "gemini-test").Generate(context.Background(),
+		Request{Prompt: "분석해 주세요", Schema: testSchema, Purpose: "insight:KRW-BTC"})
+	if err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+
+	if resp.Text != `[{"symbol":"KRW-BTC"}]` || resp.Provider != ProviderGemini || resp.Model != "gemini-test" {
+		t.Errorf("응답 = %+v", resp)
+	}
+	if !strings.HasPrefix(resp.Raw, "```json") {
+		t.Errorf("원문은 코드 블록을 유지해야 함: %q", resp.Raw)
+	}
+
+	req := (*requests)[0]
+	if req.Path != "/v1beta/models/gemini-test:generateContent" {
+		t.Errorf("경로 = %s", req.Path)
+	}
+	if key := req.Header.Get("x-goog-api-key"); key != "test-key" {
+		t.Errorf("API 키 헤더 = %q", key)
+	}
+	contents := req.Body["contents"].([]interface{})
+	part := contents[0].(map[string]interface{})["parts"].([]interface{})[0].(map[string]interface{})
+	if part["text"] != "분석해 주세요" {
+		t.Errorf("프롬프트 = %v", part["text"])
+	}
+	config := req.Body["generationConfig"].(map[string]interface{})
+	if config["responseMimeType"] != "application/json" || config["responseJsonSchema"] == nil {
+		t.Errorf("generationConfig = %v", config)
+	}
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK,
+		`{"model":"gpt-test-2024","choices":[{"message":{"role":"assistant","content":" [{\"symbol\":\"KRW-ETH\"}] "}}]}`)
+
+	resp, err := NewOpenAI(server.URL, "sk-test", "gpt-test").Generate(context.Background(),
+		Request{Prompt: "분석해 주세요", Schema: testSchema})
+	if err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+
+	if resp.Text != `[{"symbol":"KRW-ETH"}]` || resp.Provider != ProviderOpenAI || resp.Model != "gpt-test" {
+		t.Errorf("응답 = %+v", resp)
+	}
+
+	req := (*requests)[0]
+	if req.Path != "/v1/chat/completions" {
+		t.Errorf("경로 = %s", req.Path)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "Bearer sk-test" {
+		t.Errorf("Authorization 헤더 = %q", auth)
+	}
+	if req.Body["model"] != "gpt-test" {
+		t.Errorf("모델 = %v", req.Body["model"])
+	}
+	if _, ok := req.Body["response_format"]; ok {
+		t.Error("최상위 배열 응답을 위해 response_format을 지정하지 않아야 함")
+	}
+	message := req.Body["messages"].([]interface{})[0].(map[string]interface{})
+	if message["role"] != "user" || message["content"] != "분석해 주세요" {
+		t.Errorf("메시지 = %v", message)
+	}
+}
+
+func TestOpenAIWithoutKey(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK, `{"choices":[{"message":{"content":"[]"}}]}`)
+
+	if _, err := NewOpenAI(server.URL, "", "local").Generate(context.Background(), Request{Prompt: "p"}); err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+	if auth := (*requests)[0].Header.Get("Authorization"); auth != "" {
+		t.Errorf("키가 없으면 Authorization 헤더를 보내지 않아야 함: %q", auth)
+	}
+}
+
+func TestOllamaGenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema json.RawMessage
+		format interface{}
+	}{
+		{"스키마 사용", testSchema, map[string]interface{}{"type": "array"}},
+		{"스키마 없음", nil, "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newTestServer(t, http.StatusOK, `{"response":"[{\"symbol\":\"KRW-XRP\"}]","done":true}`)
+
+			resp, err := NewOllama(server.URL, "llama-test").Generate(context.Background(),
+				Request{Prompt: "분석해 주세요", Schema: tt.schema})
+			if err != nil {
+				t.Fatalf("Generate 에러: %v", err)
+			}
+			if resp.Text != `[{"symbol":"KRW-XRP"}]` || resp.Provider != ProviderOllama || resp.Model != "llama-test" {
+				t.Errorf("응답 = %+v", resp)
+			}
+
+			req := (*requests)[0]
+			if req.Path != "/api/generate" || req.Body["model"] != "llama-test" || req.Body["prompt"] != "분석해 주세요" {
+				t.Errorf("요청 = %s %v", req.Path, req.Body)
+			}
+			if req.Body["stream"] != false {
+				t.Errorf("stream = %v, want false", req.Body["stream"])
+			}
+			if got, _ := json.Marshal(req.Body["format"]); string(got) != mustJSON(t, tt.format) {
+				t.Errorf("format = %s, want %s", got, mustJSON(t, tt.format))
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("JSON 인코딩 에러: %v", err)
+	}
+	return string(b)
+}
+
+func TestProviderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		reply   string
+		newFunc func(url string) Provider
+		wantErr string
+	}{
+		{"gemini 상태 코드", http.StatusTooManyRequests, `{"error":"quota"}`,
+			func(url string) Provider { return NewGemini(url, "k", "g") }, "상태 코드 429"},
+		{"gemini 후보 없음", http.StatusOK, `{"candidates":[]}`,
+			func(url string) Provider { return NewGemini(url, "k", "g") }, "유효한 데이터가 없습니다"},
+		{"openai 선택지 없음", http.StatusOK, `{"choices":[]}`,
+			func(url string) Provider { return NewOpenAI(url, "k", "o") }, "유효한 데이터가 없습니다"},
+		{"openai JSON 아님", http.StatusOK, `not json`,
+			func(url string) Provider { return NewOpenAI(url, "k", "o") }, "JSON 디코딩 에러"},
+		{"ollama 빈 응답", http.StatusOK, `{"response":""}`,
+			func(url string) Provider { return NewOllama(url, "l") }, "유효한 데이터가 없습니다"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestServer(t, tt.status, tt.reply)
+			_, err := tt.newFunc(server.URL).Generate(context.Background(), Request{Prompt: "p"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("에러 = %v, want %q 포함", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// stubProvider 정해진 응답이나 에러를 돌려주고, 호출 시 남은 기한을 기록하는 테스트용 Provider
+type stubProvider struct {
+	resp      Response
+	err       error
+	wait      bool // true면 ctx가 끝날 때까지 기다린 뒤 ctx 에러를 반환
+	calls     int
+	remaining []time.Duration // 호출 시점의 남은 기한 (기한이 없으면 -1)
+}
+
+func (p *stubProvider) Generate(ctx context.Context, req Request) (Response, error) {
+	p.calls++
+	if deadline, ok := ctx.Deadline(); ok {
+		p.remaining = append(p.remaining, time.Until(deadline))
+	} else {
+		p.remaining = append(p.remaining, -1)
+	}
+	if p.wait {
+		<-ctx.Done()
+		return Response{}, ctx.Err()
+	}
+	return p.resp, p.err
+}
+
+func TestFallbackOrder(t *testing.T) {
+	first := &stubProvider{err: errors.New("quota")}
+	second := &stubProvider{resp: Response{Text: "[]", Provider: "second"}}
+	third := &stubProvider{resp: Response{Text: "[]", Provider: "third"}}
+
+	resp, err := Fallback{first, second, third}.Generate(context.Background(), Request{Prompt: "p"})
+	if err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+	if resp.Provider != "second" {
+		t.Errorf("응답 제공자 = %s, want second", resp.Provider)
+	}
+	if first.calls != 1 || second.calls != 1 || third.calls != 0 {
+		t.Errorf("호출 횟수 = %d, %d, %d, want 1, 1, 0", first.calls, second.calls, third.calls)
+	}
+	if first.remaining[0] != -1 {
+		t.Errorf("기한이 없으면 제공자에도 기한을 두지 않아야 함: %s", first.remaining[0])
+	}
+
+	_, err = Fallback{first, &stubProvider{err: errors.New("down")}}.Generate(context.Background(), Request{Prompt: "p"})
+	if err == nil || !strings.Contains(err.Error(), "모든 LLM 제공자 호출 실패") || !strings.Contains(err.Error(), "down") {
+		t.Errorf("모두 실패하면 마지막 에러를 반환해야 함: %v", err)
+	}
+}
+
+func TestFallbackDeadlineSplit(t *testing.T) {
+	// 첫 제공자가 자기 몫(1/3)을 다 써도 다음 제공자가 남은 시간의 절반을 받음
+	slow := &stubProvider{wait: true}
+	second := &stubProvider{err: errors.New("quota")}
+	third := &stubProvider{resp: Response{Text: "[]", Provider: "third"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	resp, err := Fallback{slow, second, third}.Generate(ctx, Request{Prompt: "p"})
+	if err != nil {
+		t.Fatalf("Generate 에러: %v", err)
+	}
+	if resp.Provider != "third" {
+		t.Errorf("응답 제공자 = %s, want third", resp.Provider)
+	}
+
+	if got := slow.remaining[0]; got > 100*time.Millisecond || got < 50*time.Millisecond {
+		t.Errorf("첫 제공자 기한 = %s, want 약 100ms (300ms / 3)", got)
+	}
+	if got := second.remaining[0]; got > 100*time.Millisecond || got < 50*time.Millisecond {
+		t.Errorf("두 번째 제공자 기한 = %s, want 약 100ms (남은 200ms / 2)", got)
+	}
+	if got := third.remaining[0]; got > 200*time.Millisecond || got <= 100*time.Millisecond {
+		t.Errorf("마지막 제공자 기한 = %s, want 약 200ms (남은 시간 전체)", got)
+	}
+}
+
+func TestFallbackStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	next := &stubProvider{resp: Response{Text: "[]"}}
+
+	_, err := Fallback{&stubProvider{wait: true}, next}.Generate(ctx, Request{Prompt: "p"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("에러 = %v, want context.Canceled", err)
+	}
+	if next.calls != 0 {
+		t.Error("호출자 컨텍스트가 끝나면 다음 제공자를 시도하지 않아야 함")
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"JSON만", `[{"a":1}]`, `[{"a":1}]`},
+		{"앞뒤 공백", "\n  [1, 2]  \n", `[1, 2]`},
+		{"json 코드 블록", "```json\n[{\"a\":1}]\n```", `[{"a":1}]`},
+		{"언어 없는 코드 블록", "```\n{\"a\":1}\n```", `{"a":1}`},
+		{"설명이 붙은 코드 블록", "분석 결과입니다.\n```json\n[1]\n```\n참고하세요.", `[1]`},
+		{"여러 줄 코드 블록", "```json\n[\n  {\"a\": 1},\n  {\"a\": 2}\n]\n```", "[\n  {\"a\": 1},\n  {\"a\": 2}\n]"},
+		{"코드 블록 없음", `설명만 있음`, `설명만 있음`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractJSON(tt.text); got != tt.want {
+				t.Errorf("ExtractJSON = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	got := Route(Options{Name: ProviderGemini, Model: "gemini-2.5-flash"}, Options{Name: ProviderOpenAI, Model: "gpt-4o-mini"})
+	if want := "gemini/gemini-2.5-flash,openai/gpt-4o-mini"; got != want {
+		t.Errorf("Route = %q, want %q", got, want)
+	}
+}
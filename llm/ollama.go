@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Ollama 호환 API 기본 설정
+const (
+	DefaultOllamaBaseURL = "http://localhost:11434"
+	DefaultOllamaModel   = "llama3.1"
+)
+
+// ollamaRequest generate 요청 본문
+// format은 "json" 또는 JSON 스키마 객체를 받습니다.
+type ollamaRequest struct {
+	Model  string      `json:"model"`
+	Prompt string      `json:"prompt"`
+	Format interface{} `json:"format"`
+	Stream bool        `json:"stream"`
+}
+
+// ollamaResponse generate 응답 본문 (stream: false)
+type ollamaResponse struct {
+	Response string `json:"response"`
+}
+
+// Ollama 로컬 Ollama 호환 API를 사용하는 Provider 구현체
+type Ollama struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewOllama Ollama 생성 함수, 비어 있는 값은 기본값을 사용합니다.
+func NewOllama(baseURL, model string) *Ollama {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	return &Ollama{baseURL: baseURL, model: model, client: &http.Client{}}
+}
+
+// Generate generate API로 JSON 응답을 생성합니다.
+// 스키마가 있으면 format으로 응답 형식을 제한합니다.
+func (o *Ollama) Generate(ctx context.Context, req Request) (Response, error) {
+	// Context를 활용한 HTTP 요청 (호출자 기한, 없으면 기본 3분)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+
+	body := ollamaRequest{Model: o.model, Prompt: req.Prompt, Format: "json", Stream: false}
+	if len(req.Schema) > 0 {
+		body.Format = req.Schema
+	}
+
+	var resp ollamaResponse
+	if err := postJSON(reqCtx, o.client, o.baseURL+"/api/generate", nil, body, &resp); err != nil {
+		return Response{}, fmt.Errorf("ollama(%s): %w", o.model, err)
+	}
+
+	if resp.Response == "" {
+		return Response{}, fmt.Errorf("ollama(%s): 모델 응답에 유효한 데이터가 없습니다", o.model)
+	}
+
+	return Response{
+		Text:     ExtractJSON(resp.Response),
+		Raw:      resp.Response,
+		Provider: ProviderOllama,
+		Model:    o.model,
+	}, nil
+}
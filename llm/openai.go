@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// OpenAI 호환 API 기본 설정
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com"
+	DefaultOpenAIModel   = "gpt-4o-mini"
+)
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIRequest chat completions 요청 본문
+// response_format(json_object, json_schema)은 최상위 배열을 허용하지 않으므로 지정하지 않고 응답에서 JSON을 추출합니다.
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+}
+
+// openAIResponse chat completions 응답 본문
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// OpenAI OpenAI chat completions 호환 API를 사용하는 Provider 구현체
+// baseURL을 바꾸면 같은 형식을 지원하는 다른 서비스에도 사용할 수 있습니다.
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAI OpenAI 생성 함수, 비어 있는 값은 기본값을 사용합니다.
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	return &OpenAI{baseURL: baseURL, apiKey: apiKey, model: model, client: &http.Client{}}
+}
+
+// Generate chat completions API로 JSON 응답을 생성합니다.
+// 요청 스키마는 사용하지 않습니다. (openAIRequest 참고)
+func (o *OpenAI) Generate(ctx context.Context, req Request) (Response, error) {
+	// Context를 활용한 HTTP 요청 (호출자 기한, 없으면 기본 3분)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+
+	body := openAIRequest{
+		Model:    o.model,
+		Messages: []openAIMessage{{Role: "user", Content: req.Prompt}},
+	}
+	headers := map[string]string{}
+	if o.apiKey != "" {
+		headers["Authorization"] = "Bearer " + o.apiKey
+	}
+
+	var resp openAIResponse
+	if err := postJSON(reqCtx, o.client, o.baseURL+"/v1/chat/completions", headers, body, &resp); err != nil {
+		return Response{}, fmt.Errorf("openai(%s): %w", o.model, err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return Response{}, fmt.Errorf("openai(%s): 모델 응답에 유효한 데이터가 없습니다", o.model)
+	}
+
+	return Response{
+		Text:     ExtractJSON(resp.Choices[0].Message.Content),
+		Raw:      resp.Choices[0].Message.Content,
+		Provider: ProviderOpenAI,
+		Model:    o.model,
+	}, nil
+}
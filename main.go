@@ -2,10 +2,14 @@ package main
 
 import (
 	"Bitground-go/config"
+	"Bitground-go/exchange"
+	"Bitground-go/llm"
+	"Bitground-go/model"
 	"Bitground-go/service"
 	"Bitground-go/util"
 	"context"
 	"database/sql"
+	"fmt"
 	"golang.org/x/sync/errgroup"
 	"log"
 	"time"
@@ -29,18 +33,52 @@ import (
 //	obj["GOOGLE_API_KEY"] = os.Getenv("GOOGLE_API_KEY")
 //	obj["SEASON_NAME"] = os.Getenv("SEASON_NAME")
 //	obj["SEASON_UPDATE_KEY"] = os.Getenv("SEASON_UPDATE_KEY")
+//	// 실행 일정 (cron 표현식: 분 시 일 월 요일, 비어 있으면 TYPE에 맞는 기본 일정 사용)
+//	obj["SEASON_SCHEDULE"] = os.Getenv("SEASON_SCHEDULE")
+//	obj["SPLIT_SCHEDULE"] = os.Getenv("SPLIT_SCHEDULE")
+//	obj["COIN_SCHEDULE"] = os.Getenv("COIN_SCHEDULE")
+//	obj["INSIGHT_SCHEDULE"] = os.Getenv("INSIGHT_SCHEDULE")
+//	obj["SEASON_DRY_RUN"] = os.Getenv("SEASON_DRY_RUN") // true면 시즌 종료 보고서만 생성
+//	// 외부 API 주소 (비어 있으면 실제 서비스 주소 사용, 테스트 시 fakeapi 서버 주소 지정)
+//	obj["UPBIT_BASE_URL"] = os.Getenv("UPBIT_BASE_URL")
+//	obj["COINGECKO_BASE_URL"] = os.Getenv("COINGECKO_BASE_URL")
+//	obj["GEMINI_BASE_URL"] = os.Getenv("GEMINI_BASE_URL")
+//	obj["KIMCHI_FX_SOURCE"] = os.Getenv("KIMCHI_FX_SOURCE") // 김치 프리미엄 원/달러 환율: 업비트 마켓 코드 또는 고정 환율 (기본 KRW-USDT)
+//	// 인사이트 LLM 제공자 (쉼표로 구분, 앞에서부터 시도: gemini, openai, ollama) 및 제공자별 설정
+//	obj["LLM_PROVIDERS"] = os.Getenv("LLM_PROVIDERS")
+//	obj["GEMINI_MODEL"] = os.Getenv("GEMINI_MODEL") // 기본 gemini-2.5-flash (이전 기본값: gemini-2.5-flash-preview-05-20)
+//	obj["OPENAI_BASE_URL"] = os.Getenv("OPENAI_BASE_URL")
+//	obj["OPENAI_API_KEY"] = os.Getenv("OPENAI_API_KEY")
+//	obj["OPENAI_MODEL"] = os.Getenv("OPENAI_MODEL")
+//	obj["OLLAMA_BASE_URL"] = os.Getenv("OLLAMA_BASE_URL")
+//	obj["OLLAMA_MODEL"] = os.Getenv("OLLAMA_MODEL")
+//	obj["INSIGHT_MAX_ATTEMPTS"] = os.Getenv("INSIGHT_MAX_ATTEMPTS") // 응답 검증 실패 시 최대 요청 횟수 (기본 3)
+//	obj["INSIGHT_WATCHLIST"] = os.Getenv("INSIGHT_WATCHLIST")           // 쉼표로 구분한 관심 코인 (기본 KRW-ETH,KRW-SOL,KRW-XRP)
+//	obj["INSIGHT_PROMPT_DIR"] = os.Getenv("INSIGHT_PROMPT_DIR")         // 프롬프트 템플릿 디렉터리 (없으면 prompt_templates 테이블, 기본 템플릿)
+//	obj["INSIGHT_PROMPT_VERSION"] = os.Getenv("INSIGHT_PROMPT_VERSION") // 템플릿 버전 (없으면 활성/최신 버전)
+//	obj["INSIGHT_LOCALES"] = os.Getenv("INSIGHT_LOCALES")               // 쉼표로 구분한 인사이트 언어 (기본 ko, ko 외에는 번역, 스프링 서버의 ai_insights locale 마이그레이션 필요)
+//	obj["INSIGHT_CONCURRENCY"] = os.Getenv("INSIGHT_CONCURRENCY")       // 동시에 보내는 심볼별 요청 수 (기본 4)
+//	obj["INSIGHT_REQUEST_TIMEOUT"] = os.Getenv("INSIGHT_REQUEST_TIMEOUT") // LLM 요청 한 번의 시간 제한(초, 기본 60)
+//	obj["INSIGHT_BUDGET"] = os.Getenv("INSIGHT_BUDGET")                   // 인사이트 생성/번역 요청 전체의 시간 제한(초, 기본 120)
+//	obj["LLM_CACHE"] = os.Getenv("LLM_CACHE")                           // false면 llm_calls 기록 및 응답 재사용 안 함
+//	// 관리 명령 (지정하면 정기 작업 대신 실행, runCommand 참고)
+//	obj["COMMAND"] = os.Getenv("COMMAND")             // insight-replay, backfill
+//	obj["LLM_CALL_ID"] = os.Getenv("LLM_CALL_ID")     // insight-replay: 재생할 llm_calls id
+//	obj["REPLAY_DATE"] = os.Getenv("REPLAY_DATE")     // insight-replay: 재생할 날짜 (기본 오늘)
+//	obj["BACKFILL_FROM"] = os.Getenv("BACKFILL_FROM") // backfill: 시작일 YYYY-MM-DD (기본 종료일 6일 전)
+//	obj["BACKFILL_TO"] = os.Getenv("BACKFILL_TO")     // backfill: 종료일 YYYY-MM-DD (기본 오늘)
+//	obj["BITGROUND_BASE_URL"] = os.Getenv("BITGROUND_BASE_URL")
 //
 //	Main(obj)
 //}
 
 func Main(obj map[string]interface{}) map[string]interface{} {
-	isSuccess := true
-
 	// 전체 함수에 대한 타임아웃 설정 (서버리스 함수 제한시간보다 짧게)
 	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Minute)
 	defer cancel()
 
 	cfg := config.NewDBConfig(obj)
+	apiCfg := config.NewAPIConfig(obj)
 
 	// 1. 데이터베이스 연결
 	db, err := config.ConnectDB(ctx, cfg)
@@ -56,14 +94,29 @@ func Main(obj map[string]interface{}) map[string]interface{} {
 		}
 	}(db)
 
+	// COMMAND가 지정되면 정기 작업 대신 해당 명령만 실행
+	if command := util.GetString(obj, "COMMAND", ""); command != "" {
+		return runCommand(ctx, db, command, obj)
+	}
+
+	// 단계별 실행 결과 기록 (job_runs 테이블 및 반환값)
+	jobs := service.NewJobRecorder()
+	if _, err := service.StartJobRun(ctx, db, jobs); err != nil {
+		log.Println("실행 기록 시작 실패:", err)
+	}
+
 	// 2. 마켓 인덱스 업데이트 (비동기)
-	g, gCtx := errgroup.WithContext(ctx)
+	// 인덱스 계산에 쓴 CoinGecko 시세는 김치 프리미엄 계산에서 재사용 (indexGroup.Wait 이후에 읽음)
+	indexGroup, indexCtx := errgroup.WithContext(ctx)
+	var globalCoins []model.GeckoCoin
 
-	g.Go(func() error {
+	indexGroup.Go(func() error {
 		log.Println("마켓 인덱스 업데이트 시작")
-		err := service.UpdateMarketIndex(gCtx, db)
+		err := jobs.Run(indexCtx, service.StepMarketIndex, func(ctx context.Context) (err error) {
+			globalCoins, err = service.UpdateMarketIndex(ctx, db, apiCfg.CoinGeckoBaseURL)
+			return err
+		})
 		if err != nil {
-			isSuccess = false
 			log.Println("마켓 인덱스 업데이트 실패:", err)
 		}
 		log.Println("마켓 인덱스 업데이트 완료")
@@ -71,128 +124,378 @@ func Main(obj map[string]interface{}) map[string]interface{} {
 	})
 
 	// 3-1. db에서 코인 심볼 조회하여 심볼 맵 생성
-	symbolMap, err := service.GetActiveCoinsSymbols(ctx, db)
+	var symbolMap map[string]int
+	err = jobs.Run(ctx, service.StepActiveCoins, func(ctx context.Context) (err error) {
+		symbolMap, err = service.GetActiveCoinsSymbols(ctx, db)
+		return err
+	})
 	if err != nil {
-		isSuccess = false
 		log.Println("활성화된 코인 심볼 조회 실패:", err)
 	}
 
 	//3-2. 현 시즌 id 조회
-	seasonID, err := service.GetCurrentSeasonID(ctx, db)
+	var seasonID int
+	err = jobs.Run(ctx, service.StepCurrentSeason, func(ctx context.Context) (err error) {
+		seasonID, err = service.GetCurrentSeasonID(ctx, db)
+		return err
+	})
 	if err != nil {
-		isSuccess = false
 		log.Println("현재 시즌 ID 조회 실패:", err)
 	}
 
 	// 4. 시간과 환경변수 통해 업데이트 플래그 확인
-	flags, err := util.TimeCheck(obj)
+	var flags model.UpdateFlags
+	err = jobs.Run(ctx, service.StepFlags, func(context.Context) (err error) {
+		flags, err = util.TimeCheck(obj)
+		return err
+	})
 	if err != nil {
-		isSuccess = false
 		log.Println("업데이트 플래그 확인 실패:", err)
 	}
+	jobs.SetFlags(flags)
 
 	// 5. 플래그에 따라 업데이트 수행
 	log.Printf("업데이트 플래그: %+v\n", flags)
 
-	// 5-1. 인사이트 업데이트 (비동기)
-	g.Go(func() error {
-		if flags.Insight {
-			geminiKey := obj["GOOGLE_API_KEY"].(string)
-			log.Println("인사이트 업데이트 시작")
-			err := service.UpdateInsight(gCtx, db, geminiKey, symbolMap)
-			if err != nil {
-				isSuccess = false
-				log.Println("인사이트 업데이트 실패:", err)
-			} else {
-				log.Println("인사이트 업데이트 완료")
+	// 5-2. 거래소 시세 조회 (코인, 예약 주문, 랭킹, 시즌, 가격 히스토리 업데이트에서 공유)
+	market := exchange.NewUpbit(apiCfg.UpbitBaseURL)
+	var tickers []model.UpbitCoinPrice
+	tickerErr := jobs.Run(ctx, service.StepTickers, func(ctx context.Context) (err error) {
+		tickers, err = market.FetchTickers(ctx)
+		return err
+	})
+	if tickerErr != nil {
+		log.Println("코인 시세 조회 실패:", tickerErr)
+	}
+	coinPrices, coinPriceHistory := service.MapTickersBySymbolID(tickers, symbolMap)
+
+	// 중단된 시즌 전환이 있으면 다른 작업보다 먼저 완료 (반쯤 종료된 시즌으로 체결/랭킹이 계산되지 않도록)
+	// 재개에 실패하면 이번 실행의 체결, 랭킹, 시즌 업데이트는 생략
+	seasonReady := tickerErr == nil
+	if seasonReady {
+		err := jobs.Run(ctx, service.StepSeasonResume, func(ctx context.Context) error {
+			resumed, err := service.ResumeSeasonRollover(ctx, db, coinPrices)
+			if err != nil || !resumed {
+				return err
 			}
-		} else {
-			log.Println("인사이트 업데이트 생략")
+			log.Println("중단된 시즌 전환 재개 완료")
+			if seasonID, err = service.GetCurrentSeasonID(ctx, db); err != nil {
+				return fmt.Errorf("현재 시즌 ID 조회 실패: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			seasonReady = false
+			log.Println("중단된 시즌 전환 재개 실패:", err)
 		}
-		return nil
-	})
+	} else {
+		jobs.Skip(service.StepSeasonResume)
+	}
 
-	// 5-2. 코인 업데이트 수행
-	if flags.Coin {
+	// 5-3. 코인 업데이트 수행
+	if flags.Coin && tickerErr == nil {
 		log.Println("코인 업데이트 시작")
-		err = service.UpdateCoins(ctx, db)
+		err = jobs.Run(ctx, service.StepCoins, func(ctx context.Context) error {
+			return service.UpdateCoins(ctx, db, market, tickers)
+		})
 		if err != nil {
-			isSuccess = false
 			log.Println("코인 업데이트 실패:", err)
 		} else {
 			log.Println("코인 업데이트 완료")
 		}
 	} else {
+		jobs.Skip(service.StepCoins)
 		log.Println("코인 업데이트 생략")
 	}
 
-	// 5-3. 유저 자산 업데이트 수행
-	if flags.Split {
+	// 5-4. 유저 자산 업데이트 수행 (중단된 시즌 전환을 마치지 못했으면 반쯤 종료된 시즌에 지급하지 않도록 생략)
+	if flags.Split && seasonReady {
 		log.Println("유저 자산 업데이트 시작")
-		err = service.UpdateSplit(ctx, db, obj)
+		err = jobs.Run(ctx, service.StepSplit, func(ctx context.Context) error {
+			return service.UpdateSplit(ctx, db, seasonID, obj)
+		})
 		if err != nil {
-			isSuccess = false
 			log.Println("유저 자산 업데이트 실패:", err)
 		} else {
 			log.Println("유저 자산 업데이트 완료")
 		}
 	} else {
+		jobs.Skip(service.StepSplit)
 		log.Println("유저 자산 업데이트 생략")
 	}
 
-	// 5-4. 랭킹 업데이트 수행
-	log.Println("랭킹 업데이트 시작")
-	if flags.Insight {
-		log.Println("유저 자산 스냅샷 업데이트 시작")
+	// 5-5. 예약 주문 체결 (랭킹 계산 전에 체결 결과를 반영)
+	if seasonReady {
+		log.Println("예약 주문 체결 시작")
+		err = jobs.Run(ctx, service.StepOrderMatch, func(ctx context.Context) error {
+			_, err := service.MatchPendingOrders(ctx, db, market, seasonID, coinPriceHistory, obj)
+			return err
+		})
+		if err != nil {
+			log.Println("예약 주문 체결 실패:", err)
+		}
 	} else {
-		log.Println("유저 자산 스냅샷 업데이트 생략")
+		jobs.Skip(service.StepOrderMatch)
+		log.Println("시세 정보가 없어 예약 주문 체결 생략")
 	}
-	err, coinPriceHistory, coinPrices := service.UpdateRank(ctx, db, symbolMap, seasonID, flags.Coin)
-	if err != nil {
-		isSuccess = false
-		log.Println("랭킹(& 유저 자산 스냅샷) 업데이트 실패:", err)
-	} else {
-		log.Println("랭킹 업데이트 완료")
+
+	// 5-6. 랭킹 업데이트 수행
+	if seasonReady {
+		log.Println("랭킹 업데이트 시작")
 		if flags.Insight {
-			log.Println("유저 자산 스냅샷 업데이트 완료")
+			log.Println("유저 자산 스냅샷 업데이트 시작")
+		} else {
+			log.Println("유저 자산 스냅샷 업데이트 생략")
+		}
+		err = jobs.Run(ctx, service.StepRank, func(ctx context.Context) error {
+			return service.UpdateRank(ctx, db, coinPrices, seasonID, flags.Coin)
+		})
+		if err != nil {
+			log.Println("랭킹(& 유저 자산 스냅샷) 업데이트 실패:", err)
+		} else {
+			log.Println("랭킹 업데이트 완료")
+			if flags.Insight {
+				log.Println("유저 자산 스냅샷 업데이트 완료")
+			}
 		}
+	} else {
+		jobs.Skip(service.StepRank)
+		log.Println("시세 정보가 없어 랭킹 업데이트 생략")
 	}
 
-	// 5-5. 시즌 업데이트 수행
-	if flags.Season {
+	// 5-7. 시즌 업데이트 수행 (보유 자산 청산에 시세가 필요)
+	// SEASON_DRY_RUN이 true면 시즌 일정과 관계없이 변경 내역 보고서만 생성하고 DB는 변경하지 않음
+	// 시즌 전환 시각이면 전환하지 않은 사실을 시즌 단계 실패로 남겨 운영자가 승인 후 다시 실행하도록 함
+	var seasonReport *model.SeasonReport
+	seasonPending := false
+	dryRun := service.IsSeasonDryRun(obj)
+	if dryRun && seasonReady {
+		log.Println("시즌 종료 드라이런 시작")
+		err = jobs.Run(ctx, service.StepSeasonDryRun, func(ctx context.Context) error {
+			report, err := service.PreviewSeason(ctx, db, seasonID, coinPrices, obj)
+			if err == nil {
+				seasonReport = &report
+			}
+			return err
+		})
+		if err != nil {
+			log.Println("시즌 종료 드라이런 실패:", err)
+		} else {
+			log.Println("시즌 종료 드라이런 완료")
+		}
+	}
+	if flags.Season && seasonReady && dryRun {
+		seasonPending = true
+		err = jobs.Run(ctx, service.StepSeason, func(context.Context) error {
+			return service.ErrSeasonPendingApproval
+		})
+		log.Println("시즌 업데이트 보류:", err)
+	} else if flags.Season && seasonReady {
 		log.Println("시즌 업데이트 시작")
-		err = service.UpdateSeason(ctx, db, seasonID, coinPrices, obj)
+		err = jobs.Run(ctx, service.StepSeason, func(ctx context.Context) error {
+			return service.UpdateSeason(ctx, db, seasonID, coinPrices, obj)
+		})
 		if err != nil {
-			isSuccess = false
 			log.Println("시즌 업데이트 실패:", err)
 		} else {
 			log.Println("시즌 업데이트 완료")
 		}
 	} else {
+		jobs.Skip(service.StepSeason)
 		log.Println("시즌 업데이트 생략")
 	}
 
-	// 6. (추가 요구사항) 코인 가격 히스토리 업데이트
+	// 완료된 시즌 전환을 스프링 서버에 알림 (실패하면 다음 실행에서 재시도, 다른 작업은 막지 않음)
+	err = jobs.Run(ctx, service.StepSeasonNotify, func(ctx context.Context) error {
+		_, err := service.NotifySeasonRollovers(ctx, db, obj)
+		return err
+	})
+	if err != nil {
+		log.Println("시즌 전환 알림 실패:", err)
+	}
+
+	// 6. (추가 요구사항) 코인 가격 히스토리 업데이트 (Upbit 시간봉 기록 및 일/주/월봉 집계)
 	log.Println("코인 가격 히스토리 업데이트 시작")
-	err = service.UpdateCoinPriceHistory(ctx, db, coinPriceHistory)
+	err = jobs.Run(ctx, service.StepPriceHistory, func(ctx context.Context) error {
+		return service.UpdateCoinPriceHistory(ctx, db, market, symbolMap)
+	})
 	if err != nil {
-		isSuccess = false
 		log.Println("코인 가격 히스토리 업데이트 실패:", err)
 	} else {
 		log.Println("코인 가격 히스토리 업데이트 완료")
 	}
 
-	// 종료 전 고루틴 대기
-	if err := g.Wait(); err != nil {
-		isSuccess = false
-		log.Println("고루틴 수행 중 에러 발생:", err)
+	// 김치 프리미엄에 쓸 CoinGecko 시세를 위해 마켓 인덱스 업데이트만 대기
+	if err := indexGroup.Wait(); err != nil {
+		log.Println("마켓 인덱스 고루틴 수행 중 에러 발생:", err)
+	}
+
+	// 7. 김치 프리미엄 업데이트 (업비트 시세와 마켓 인덱스에서 조회한 CoinGecko 시세 비교)
+	if tickerErr == nil && len(globalCoins) > 0 {
+		log.Println("김치 프리미엄 업데이트 시작")
+		err = jobs.Run(ctx, service.StepKimchiPremium, func(ctx context.Context) error {
+			kimchiCfg, err := config.NewKimchiConfig(obj)
+			if err != nil {
+				return err
+			}
+			return service.UpdateKimchiPremium(ctx, db, tickers, globalCoins, kimchiCfg)
+		})
+		if err != nil {
+			log.Println("김치 프리미엄 업데이트 실패:", err)
+		} else {
+			log.Println("김치 프리미엄 업데이트 완료")
+		}
+	} else {
+		jobs.Skip(service.StepKimchiPremium)
+		log.Println("시세 정보가 없어 김치 프리미엄 업데이트 생략")
+	}
+
+	// 8. 시장 심리 지수 업데이트 (이번 실행에서 갱신한 코인, 가격 히스토리, 마켓 인덱스, 주문 체결 반영)
+	log.Println("시장 심리 지수 업데이트 시작")
+	err = jobs.Run(ctx, service.StepSentimentIndex, func(ctx context.Context) error {
+		return service.UpdateSentimentIndex(ctx, db, seasonID)
+	})
+	if err != nil {
+		log.Println("시장 심리 지수 업데이트 실패:", err)
+	} else {
+		log.Println("시장 심리 지수 업데이트 완료")
+	}
+
+	// 9. 인사이트 업데이트 (이번 실행에서 갱신한 마켓 인덱스, 김치 프리미엄, 시장 심리 지수를 읽으므로 마지막에 수행)
+	if flags.Insight {
+		log.Println("인사이트 업데이트 시작")
+		err = jobs.Run(ctx, service.StepInsight, func(ctx context.Context) error {
+			llmCfg := config.NewLLMConfig(obj)
+			provider, err := llm.NewChain(llmCfg.Providers...)
+			if err != nil {
+				return fmt.Errorf("LLM 제공자 설정 실패: %w", err)
+			}
+			// 호출 기록 및 같은 날 같은 제공자/모델로 재실행 시 검증된 응답 재사용 (LLM_CACHE=false면 사용하지 않음)
+			if util.GetString(obj, "LLM_CACHE", "true") != "false" {
+				runTime, err := util.RunTime(obj)
+				if err != nil {
+					return err
+				}
+				store, err := service.NewLLMCallStore(ctx, db, runTime)
+				if err != nil {
+					return err
+				}
+				provider = llm.WithCache(provider, store, llm.Route(llmCfg.Providers...))
+			}
+			insightCfg, err := config.NewInsightConfig(obj)
+			if err != nil {
+				return err
+			}
+			return service.UpdateInsight(ctx, db, provider, symbolMap, insightCfg)
+		})
+		if err != nil {
+			log.Println("인사이트 업데이트 실패:", err)
+		} else {
+			log.Println("인사이트 업데이트 완료")
+		}
+
+		// 지난 인사이트 점수와 실현 수익률 비교 (인사이트 생성 실패와 무관하게 수행)
+		err = jobs.Run(ctx, service.StepInsightBacktest, func(ctx context.Context) error {
+			return service.UpdateInsightBacktest(ctx, db)
+		})
+		if err != nil {
+			log.Println("인사이트 백테스트 실패:", err)
+		}
+	} else {
+		jobs.Skip(service.StepInsight)
+		jobs.Skip(service.StepInsightBacktest)
+		log.Println("인사이트 업데이트 생략")
+	}
+
+	// 실행 결과 기록 (전체 타임아웃이 지났어도 기록되도록 별도 컨텍스트 사용)
+	run := jobs.Finish()
+	if run.ID != 0 {
+		recordCtx, recordCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := service.FinishJobRun(recordCtx, db, run); err != nil {
+			log.Println("실행 기록 저장 실패:", err)
+		}
+		recordCancel()
 	}
 
 	// 완료 여부 메시지 생성
-	if isSuccess {
-		return makeMessage("모든 업데이트 작업이 성공적으로 완료되었습니다.")
+	var result map[string]interface{}
+	if run.Success {
+		result = makeMessage("모든 업데이트 작업이 성공적으로 완료되었습니다.")
 	} else {
-		return makeMessage("업데이트 작업 중 일부가 실패했습니다.")
+		result = makeMessage("업데이트 작업 중 일부가 실패했습니다.")
+	}
+	result["success"] = run.Success
+	result["jobRunId"] = run.ID
+	result["flags"] = run.Flags
+	result["steps"] = run.Steps
+	if seasonReport != nil {
+		result["seasonReport"] = seasonReport
+	}
+	if seasonPending {
+		result["seasonRollover"] = "PENDING_APPROVAL"
+	}
+	return result
+}
+
+// runCommand 정기 작업 외의 관리 명령 실행
+// - insight-replay: 기록된 LLM 응답을 현재 파서로 다시 파싱 (LLM_CALL_ID 또는 REPLAY_DATE, 기본 오늘)
+// - backfill: BACKFILL_FROM~BACKFILL_TO(기본 최근 7일) 가격 히스토리와 마켓 인덱스 누락 구간 채우기 (최대 31일)
+func runCommand(ctx context.Context, db *sql.DB, command string, obj map[string]interface{}) map[string]interface{} {
+	switch command {
+	case "insight-replay":
+		callID, err := util.GetInt(obj, "LLM_CALL_ID", 0)
+		if err != nil {
+			return makeMessage(err.Error())
+		}
+		date := util.GetString(obj, "REPLAY_DATE", time.Now().Format("2006-01-02"))
+
+		results, err := service.ReplayLLMCalls(ctx, db, int64(callID), date)
+		if err != nil {
+			return makeMessage("LLM 응답 재생 실패: " + err.Error())
+		}
+
+		valid := 0
+		for _, result := range results {
+			if result.Valid {
+				valid++
+			}
+		}
+		result := makeMessage(fmt.Sprintf("LLM 응답 재생 완료: %d건 중 %d건 검증 통과", len(results), valid))
+		result["results"] = results
+		return result
+	case "backfill":
+		to := time.Now()
+		if raw := util.GetString(obj, "BACKFILL_TO", ""); raw != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+			if err != nil {
+				return makeMessage("BACKFILL_TO 형식 오류 (YYYY-MM-DD): " + raw)
+			}
+			to = parsed
+		}
+		from := to.AddDate(0, 0, -6)
+		if raw := util.GetString(obj, "BACKFILL_FROM", ""); raw != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+			if err != nil {
+				return makeMessage("BACKFILL_FROM 형식 오류 (YYYY-MM-DD): " + raw)
+			}
+			from = parsed
+		}
+
+		apiCfg := config.NewAPIConfig(obj)
+		report, err := service.BackfillHistory(ctx, db, exchange.NewUpbit(apiCfg.UpbitBaseURL), apiCfg.CoinGeckoBaseURL, from, to)
+		if err != nil {
+			// 중간에 실패해도 그때까지 채운 구간은 저장되어 있으므로 보고서를 함께 반환
+			result := makeMessage("누락 구간 채우기 실패: " + err.Error())
+			result["report"] = report
+			return result
+		}
+
+		result := makeMessage(fmt.Sprintf("누락 구간 채우기 완료: 가격 %d/%d, 인덱스 %d/%d",
+			report.PriceFilled, report.PriceMissing, report.IndexFilled, report.IndexMissing))
+		result["report"] = report
+		return result
+	default:
+		return makeMessage("알 수 없는 명령: " + command)
 	}
 }
 
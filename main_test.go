@@ -0,0 +1,569 @@
+package main
+
+import (
+	"Bitground-go/config"
+	"Bitground-go/internal/fakeapi"
+	"Bitground-go/model"
+	"Bitground-go/service"
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Spring 서버가 관리하는 테이블 중 Main이 읽고 쓰는 컬럼만 담은 테스트용 스키마
+// (Main이 직접 만드는 테이블은 ensure* 함수가 생성합니다)
+var baseSchema = []string{`
+	CREATE TABLE coins (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		symbol VARCHAR(30) NOT NULL UNIQUE,
+		korean_name VARCHAR(100) NOT NULL,
+		trade_price_24h DOUBLE NOT NULL DEFAULT 0,
+		change_rate DOUBLE NOT NULL DEFAULT 0,
+		is_caution TINYINT(1) NOT NULL DEFAULT 0,
+		is_warning TINYINT(1) NOT NULL DEFAULT 0,
+		is_deleted TINYINT(1) NOT NULL DEFAULT 0
+	)`, `
+	CREATE TABLE users (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		cash BIGINT NOT NULL DEFAULT 0,
+		tier INT NOT NULL DEFAULT 0,
+		is_deleted TINYINT(1) NOT NULL DEFAULT 0
+	)`, `
+	CREATE TABLE seasons (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		start_at DATETIME NOT NULL,
+		end_at DATETIME NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+		reward_calculated TINYINT(1) NOT NULL DEFAULT 0
+	)`, `
+	CREATE TABLE orders (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		user_id INT NOT NULL,
+		symbol_id INT NOT NULL,
+		season_id INT NOT NULL,
+		amount DOUBLE NOT NULL,
+		trade_price DOUBLE NOT NULL,
+		order_type VARCHAR(10) NOT NULL,
+		status VARCHAR(20) NOT NULL
+	)`, `
+	CREATE TABLE user_assets (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		user_id INT NOT NULL,
+		symbol_id INT NOT NULL,
+		amount DOUBLE NOT NULL,
+		UNIQUE KEY uk_user_assets (user_id, symbol_id)
+	)`, `
+	CREATE TABLE user_rankings (
+		season_id INT NOT NULL,
+		user_id INT NOT NULL,
+		total_value BIGINT NOT NULL,
+		ranks INT NOT NULL,
+		tier INT NOT NULL,
+		PRIMARY KEY (season_id, user_id)
+	)`, `
+	CREATE TABLE user_daily_balances (
+		user_id INT NOT NULL,
+		season_id INT NOT NULL,
+		snapshot_date DATE NOT NULL,
+		cash_balance BIGINT NOT NULL,
+		coin_holdings_value BIGINT NOT NULL,
+		total_value BIGINT NOT NULL,
+		PRIMARY KEY (user_id, snapshot_date)
+	)`, `
+	CREATE TABLE ai_insights (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		symbol VARCHAR(30) NOT NULL,
+		insight TEXT NOT NULL,
+		score INT NOT NULL,
+		date DATE NOT NULL,
+		locale VARCHAR(10) NOT NULL DEFAULT 'ko',
+		UNIQUE KEY uk_ai_insights_symbol_date_locale (symbol, date, locale)
+	)`, `
+	CREATE TABLE market_indices (
+		date DATE NOT NULL,
+		hour INT NOT NULL,
+		market_index BIGINT NOT NULL,
+		alt_index BIGINT NOT NULL,
+		PRIMARY KEY (date, hour)
+	)`, `
+	CREATE TABLE coin_price_history (
+		coin_id INT NOT NULL,
+		date DATE NOT NULL,
+		hour INT NOT NULL,
+		open_price DOUBLE NOT NULL,
+		close_price DOUBLE NOT NULL,
+		high_price DOUBLE NOT NULL,
+		low_price DOUBLE NOT NULL,
+		volume DOUBLE NOT NULL,
+		PRIMARY KEY (coin_id, date, hour)
+	)`,
+}
+
+// testDBConfig TEST_DB_HOST, TEST_DB_USER, TEST_DB_PASSWORD, TEST_DB_NAME으로 지정한 테스트용 MySQL 설정
+// TEST_DB_HOST가 없으면 테스트를 건너뜁니다. 테스트가 DB의 모든 테이블을 지우므로 운영 DB를 지정하면 안 됩니다.
+func testDBConfig(t *testing.T) map[string]interface{} {
+	t.Helper()
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST가 없어 Main 통합 테스트를 건너뜀")
+	}
+	return map[string]interface{}{
+		"DB_HOST":     host,
+		"DB_USER":     os.Getenv("TEST_DB_USER"),
+		"DB_PASSWORD": os.Getenv("TEST_DB_PASSWORD"),
+		"DB_NAME":     os.Getenv("TEST_DB_NAME"),
+	}
+}
+
+// resetTestDB 테스트 DB의 모든 테이블을 지우고 기본 스키마를 다시 만듭니다.
+func resetTestDB(t *testing.T, db *sql.DB) {
+	t.Helper()
+	ctx := context.Background()
+
+	rows, err := db.QueryContext(ctx, `SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()`)
+	if err != nil {
+		t.Fatalf("테이블 목록 조회 실패: %v", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatalf("행 스캔 에러: %v", err)
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("행 닫기 에러: %v", err)
+	}
+	if len(tables) > 0 {
+		if _, err := db.ExecContext(ctx, "DROP TABLE "+strings.Join(tables, ", ")); err != nil {
+			t.Fatalf("테이블 삭제 실패: %v", err)
+		}
+	}
+
+	for _, query := range baseSchema {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			t.Fatalf("스키마 생성 실패: %v\n%s", err, query)
+		}
+	}
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func queryInt(t *testing.T, db *sql.DB, query string, args ...interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+// 현재 시각 이전 hours시간의 시간봉 (최신순, Upbit 응답 형식)
+func hourlyCandles(market string, now time.Time, hours int, price float64) []model.UpbitCandle {
+	candles := make([]model.UpbitCandle, 0, hours)
+	for h := 1; h <= hours; h++ {
+		start := now.Truncate(time.Hour).Add(-time.Duration(h) * time.Hour)
+		candles = append(candles, model.UpbitCandle{
+			Market:               market,
+			CandleDateTimeUTC:    start.UTC().Format("2006-01-02T15:04:05"),
+			CandleDateTimeKST:    start.UTC().Add(9 * time.Hour).Format("2006-01-02T15:04:05"),
+			OpeningPrice:         price,
+			HighPrice:            price * 1.02,
+			LowPrice:             price * 0.98,
+			TradePrice:           price * 1.01,
+			CandleAccTradeVolume: 10,
+		})
+	}
+	return candles
+}
+
+func TestMainWithFakeAPI(t *testing.T) {
+	obj := testDBConfig(t)
+
+	db, err := config.ConnectDB(context.Background(), config.NewDBConfig(obj))
+	if err != nil {
+		t.Fatalf("테스트 DB 연결 실패: %v", err)
+	}
+	defer db.Close()
+	resetTestDB(t, db)
+
+	// 코인 1: BTC, 2: ETH, 3: USDT, 유저 1, 2가 BTC 지정가 매수 대기
+	mustExec(t, db, `INSERT INTO coins (id, symbol, korean_name) VALUES (1, 'KRW-BTC', '비트코인'), (2, 'KRW-ETH', '이더리움'), (3, 'KRW-USDT', '테더')`)
+	mustExec(t, db, `INSERT INTO users (id, cash) VALUES (1, 10000000), (2, 10000000)`)
+	mustExec(t, db, `INSERT INTO seasons (id, name, start_at, end_at) VALUES (1, '테스트 시즌', NOW() - INTERVAL 1 DAY, NOW() + INTERVAL 14 DAY)`)
+	mustExec(t, db, `INSERT INTO orders (user_id, symbol_id, season_id, amount, trade_price, order_type, status) VALUES (1, 1, 1, 0.01, 99000000, 'BUY', 'PENDING')`)
+	// 주문 1은 직전 실행(23시) 때 있던 주문, 주문 2는 그 뒤에 들어온 주문
+	mustExec(t, db, `CREATE TABLE order_match_marks (season_id INT PRIMARY KEY, run_at DATETIME NOT NULL, max_order_id BIGINT NOT NULL DEFAULT 0)`)
+	mustExec(t, db, `INSERT INTO order_match_marks (season_id, run_at, max_order_id) VALUES (1, '2024-03-02 23:00:00', 1)`)
+	mustExec(t, db, `INSERT INTO orders (user_id, symbol_id, season_id, amount, trade_price, order_type, status) VALUES (2, 1, 1, 0.01, 98500000, 'BUY', 'PENDING')`)
+
+	api := fakeapi.New()
+	defer api.Close()
+
+	now := time.Now()
+	runTime := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	api.SetMarkets([]model.UpbitCoinList{
+		{Market: "KRW-BTC", KoreanName: "비트코인"},
+		{Market: "KRW-ETH", KoreanName: "이더리움"},
+		{Market: "KRW-USDT", KoreanName: "테더"},
+		{Market: "BTC-ETH", KoreanName: "이더리움"},
+	})
+	api.SetTickers([]model.UpbitCoinPrice{
+		{Market: "KRW-BTC", TradePrice: 100000000, PrevClosingPrice: 98000000, AccTradePrice: 1e11},
+		{Market: "KRW-ETH", TradePrice: 5000000, PrevClosingPrice: 5100000, AccTradePrice: 5e10},
+		{Market: "KRW-USDT", TradePrice: 1400, PrevClosingPrice: 1400, AccTradePrice: 1e9},
+	})
+	// 직전 실행 이후 시간봉 저가(98,000,000)가 지정가(99,000,000) 이하이므로 주문 1은 매수 체결,
+	// 주문 2는 직전 실행 이후에 들어왔으므로 현재가(100,000,000)로만 판단하여 대기
+	api.SetCandles("KRW-BTC", append(hourlyCandles("KRW-BTC", now, 30, 100000000), hourlyCandles("KRW-BTC", runTime, 2, 100000000)...))
+	api.SetCandles("KRW-ETH", hourlyCandles("KRW-ETH", now, 30, 5000000))
+	api.SetCandles("KRW-USDT", hourlyCandles("KRW-USDT", now, 30, 1400))
+	api.SetMarketCaps([]model.GeckoCoin{
+		{ID: "bitcoin", Symbol: "btc", MarketCap: 1400000000000, CurrentPrice: 70000},
+		{ID: "ethereum", Symbol: "eth", MarketCap: 420000000000, CurrentPrice: 3500},
+		{ID: "tether", Symbol: "usdt", MarketCap: 110000000000, CurrentPrice: 1},
+		{ID: "solana", Symbol: "sol", MarketCap: 70000000000, CurrentPrice: 150},
+	})
+	api.SetLLMReplies(`[
+		{"symbol": "MARKET_OVERALL", "insight": "시장 전반이 완만한 상승세입니다.", "score": 60},
+		{"symbol": "KRW-ETH", "insight": "이더리움은 소폭 조정 중입니다.", "score": 45}
+	]`)
+
+	for key, value := range api.Config() {
+		obj[key] = value
+	}
+	// 0시: 코인, 인사이트 업데이트 (시즌/스플릿 일정 아님)
+	obj["TEST_TIME"] = "2024-03-03 00:00:00"
+	obj["GOOGLE_API_KEY"] = "test-key"
+	obj["INSIGHT_PROMPT_VERSION"] = "v1"
+	obj["INSIGHT_WATCHLIST"] = "KRW-ETH"
+
+	result := Main(obj)
+
+	steps, _ := result["steps"].([]model.JobStep)
+	for _, step := range steps {
+		if step.Status == "FAILED" {
+			t.Errorf("%s 단계 실패: %s", step.Name, step.Error)
+		}
+	}
+	if success, _ := result["success"].(bool); !success {
+		t.Fatalf("Main 실패: %v", result["message"])
+	}
+
+	// 예약 주문 체결
+	var status string
+	if err := db.QueryRow(`SELECT status FROM orders WHERE id = 1`).Scan(&status); err != nil || status != "COMPLETED" {
+		t.Errorf("주문 상태 = %q, %v, want COMPLETED", status, err)
+	}
+	if cash := queryInt(t, db, `SELECT cash FROM users WHERE id = 1`); cash != 10000000-990000 {
+		t.Errorf("유저 현금 = %d, want %d", cash, 10000000-990000)
+	}
+	if err := db.QueryRow(`SELECT status FROM orders WHERE id = 2`).Scan(&status); err != nil || status != "PENDING" {
+		t.Errorf("새 주문 상태 = %q, %v, want PENDING", status, err)
+	}
+	if n := queryInt(t, db, `SELECT max_order_id FROM order_match_marks WHERE season_id = 1 AND run_at = '2024-03-03 00:00:00'`); n != 2 {
+		t.Errorf("체결 기록 max_order_id = %d, want 2", n)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM user_rankings WHERE season_id = 1 AND user_id = 1`); n != 1 {
+		t.Errorf("랭킹 %d건, want 1건", n)
+	}
+
+	// 마켓 인덱스 (usdt 제외 btc, eth, sol)
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM market_indices`); n != 1 {
+		t.Errorf("market_indices %d건, want 1건", n)
+	}
+	if n := queryInt(t, db, `SELECT market_index FROM market_indices WHERE definition_version = 1`); n != 18900 {
+		t.Errorf("마켓 인덱스 %d, want 18900", n)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM market_index_constituents WHERE code = 'MARKET'`); n != 3 {
+		t.Errorf("마켓 인덱스 구성 종목 %d개, want 3개", n)
+	}
+
+	// 김치 섹터 (업비트 원화 상장 종목 중 usdt 제외 btc, eth)
+	if n := queryInt(t, db, `SELECT constituent_count FROM sector_indices WHERE sector_code = 'KIMCHI'`); n != 2 {
+		t.Errorf("김치 섹터 구성 종목 %d개, want 2개", n)
+	}
+
+	// 가격 히스토리와 일봉
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM coin_price_history WHERE is_hourly_candle = 1`); n == 0 {
+		t.Error("시간봉이 기록되지 않음")
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM coin_candles_daily WHERE coin_id = 1`); n == 0 {
+		t.Error("일봉이 집계되지 않음")
+	}
+
+	// 인사이트
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM ai_insights WHERE symbol IN ('MARKET_OVERALL', 'KRW-ETH')`); n != 2 {
+		t.Errorf("인사이트 %d건, want 2건", n)
+	}
+	if prompts := api.Prompts(); len(prompts) != 1 {
+		t.Errorf("LLM 요청 %d회, want 1회", len(prompts))
+	}
+	// 호출 기록은 실행 시각(TEST_TIME) 날짜로 남음
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM llm_calls WHERE call_date = '2024-03-03' AND valid = 1`); n != 1 {
+		t.Errorf("2024-03-03 호출 기록 %d건, want 1건", n)
+	}
+
+	// 김치 프리미엄 (BTC: 100,000,000 / (70,000 × 1,400) - 1 ≈ 2.04%)
+	var premium float64
+	if err := db.QueryRow(`SELECT premium FROM kimchi_premium WHERE symbol = 'KRW-BTC'`).Scan(&premium); err != nil {
+		t.Errorf("김치 프리미엄 조회 실패: %v", err)
+	} else if premium < 2 || premium > 2.1 {
+		t.Errorf("KRW-BTC 김치 프리미엄 = %.2f, want 약 2.04", premium)
+	}
+
+	// 실행 기록
+	var jobStatus string
+	if err := db.QueryRow(`SELECT status FROM job_runs WHERE id = ?`, result["jobRunId"]).Scan(&jobStatus); err != nil || jobStatus != "SUCCESS" {
+		t.Errorf("job_runs 상태 = %q, %v, want SUCCESS", jobStatus, err)
+	}
+}
+
+func TestMainBackfillCommand(t *testing.T) {
+	obj := testDBConfig(t)
+
+	db, err := config.ConnectDB(context.Background(), config.NewDBConfig(obj))
+	if err != nil {
+		t.Fatalf("테스트 DB 연결 실패: %v", err)
+	}
+	defer db.Close()
+	resetTestDB(t, db)
+
+	mustExec(t, db, `INSERT INTO coins (id, symbol, korean_name) VALUES (1, 'KRW-BTC', '비트코인')`)
+
+	api := fakeapi.New()
+	defer api.Close()
+
+	// 어제 하루 전체의 시간봉
+	now := time.Now()
+	yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.Local)
+
+	// 24시간 시세로 기록된 이전 행은 누락으로 보고 시간봉으로 덮어씀
+	mustExec(t, db, `ALTER TABLE coin_price_history ADD COLUMN is_hourly_candle TINYINT(1) NOT NULL DEFAULT 0`)
+	mustExec(t, db, `INSERT INTO coin_price_history (coin_id, date, hour, open_price, close_price, high_price, low_price, volume)
+		VALUES (1, ?, 0, 1, 1, 999999999, 1, 1)`, yesterday.Format("2006-01-02"))
+	api.SetCandles("KRW-BTC", hourlyCandles("KRW-BTC", yesterday.Add(24*time.Hour), 24, 100000000))
+	api.SetMarketCaps([]model.GeckoCoin{{ID: "bitcoin", Symbol: "btc", MarketCap: 1400000000000, CurrentPrice: 70000}})
+
+	for key, value := range api.Config() {
+		obj[key] = value
+	}
+	obj["COMMAND"] = "backfill"
+	obj["BACKFILL_FROM"] = yesterday.Format("2006-01-02")
+	obj["BACKFILL_TO"] = yesterday.Format("2006-01-02")
+
+	result := Main(obj)
+
+	report, ok := result["report"].(model.BackfillReport)
+	if !ok {
+		t.Fatalf("보고서가 없음: %v", result["message"])
+	}
+	if report.PriceMissing != 24 || report.PriceFilled != 24 {
+		t.Errorf("가격 히스토리 %d/%d, want 24/24", report.PriceFilled, report.PriceMissing)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM coin_price_history WHERE coin_id = 1 AND date = ? AND is_hourly_candle = 1`,
+		yesterday.Format("2006-01-02")); n != 24 {
+		t.Errorf("채운 시간봉 %d건, want 24건", n)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM coin_candles_daily WHERE coin_id = 1 AND date = ?`, yesterday.Format("2006-01-02")); n != 1 {
+		t.Errorf("일봉 %d건, want 1건", n)
+	}
+	if high := queryInt(t, db, `SELECT CAST(high_price AS SIGNED) FROM coin_candles_daily WHERE coin_id = 1 AND date = ?`, yesterday.Format("2006-01-02")); high >= 999999999 {
+		t.Errorf("일봉 고가 %d에 24시간 시세 행이 섞임", high)
+	}
+
+	// 다시 실행하면 채울 구간이 없음
+	result = Main(obj)
+	if report, _ := result["report"].(model.BackfillReport); report.PriceMissing != 0 {
+		t.Errorf("재실행 누락 %d건, want 0건", report.PriceMissing)
+	}
+}
+
+func TestMainSeasonDryRunPendingApproval(t *testing.T) {
+	obj := testDBConfig(t)
+
+	db, err := config.ConnectDB(context.Background(), config.NewDBConfig(obj))
+	if err != nil {
+		t.Fatalf("테스트 DB 연결 실패: %v", err)
+	}
+	defer db.Close()
+	resetTestDB(t, db)
+
+	mustExec(t, db, `INSERT INTO coins (id, symbol, korean_name) VALUES (1, 'KRW-BTC', '비트코인')`)
+	mustExec(t, db, `INSERT INTO users (id, cash) VALUES (1, 10000000)`)
+	mustExec(t, db, `INSERT INTO seasons (id, name, start_at, end_at) VALUES (1, '테스트 시즌', NOW() - INTERVAL 14 DAY, NOW())`)
+	mustExec(t, db, `INSERT INTO user_assets (user_id, symbol_id, amount) VALUES (1, 1, 0.5)`)
+
+	api := fakeapi.New()
+	defer api.Close()
+	api.SetMarkets([]model.UpbitCoinList{{Market: "KRW-BTC", KoreanName: "비트코인"}})
+	api.SetTickers([]model.UpbitCoinPrice{{Market: "KRW-BTC", TradePrice: 100000000, PrevClosingPrice: 100000000}})
+
+	for key, value := range api.Config() {
+		obj[key] = value
+	}
+	// 16일 0시: 시즌 전환 시각 (코인/인사이트 일정은 이 시각과 겹치지 않게 지정)
+	obj["TEST_TIME"] = "2024-03-16 00:00:00"
+	obj["COIN_SCHEDULE"] = "0 12 * * *"
+	obj["INSIGHT_SCHEDULE"] = "0 12 * * *"
+	obj["SEASON_NAME"] = "테스트 시즌"
+	obj["SEASON_DRY_RUN"] = "true"
+
+	result := Main(obj)
+
+	if result["seasonRollover"] != "PENDING_APPROVAL" {
+		t.Errorf("seasonRollover = %v, want PENDING_APPROVAL", result["seasonRollover"])
+	}
+	if success, _ := result["success"].(bool); success {
+		t.Error("시즌 전환을 보류한 실행은 실패로 기록되어야 함")
+	}
+	steps, _ := result["steps"].([]model.JobStep)
+	for _, step := range steps {
+		if step.Name == service.StepSeason && step.Status != "FAILED" {
+			t.Errorf("시즌 단계 상태 = %s, want FAILED", step.Status)
+		}
+	}
+	if _, ok := result["seasonReport"].(*model.SeasonReport); !ok {
+		t.Error("드라이런 보고서가 없음")
+	}
+
+	// 드라이런은 DB를 바꾸지 않음 (시즌, 자산, 설정 테이블)
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM seasons`); n != 1 {
+		t.Errorf("시즌 %d개, want 1개", n)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM user_assets`); n != 1 {
+		t.Errorf("보유 자산 %d건, want 1건", n)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'season_economies'`); n != 0 {
+		t.Error("드라이런이 season_economies 테이블을 만들면 안 됨")
+	}
+}
+
+func TestMainResumeRolloverAtSnapshotPrices(t *testing.T) {
+	obj := testDBConfig(t)
+
+	db, err := config.ConnectDB(context.Background(), config.NewDBConfig(obj))
+	if err != nil {
+		t.Fatalf("테스트 DB 연결 실패: %v", err)
+	}
+	defer db.Close()
+	resetTestDB(t, db)
+
+	mustExec(t, db, `INSERT INTO coins (id, symbol, korean_name) VALUES (1, 'KRW-BTC', '비트코인')`)
+	mustExec(t, db, `INSERT INTO users (id, cash) VALUES (1, 10000000)`)
+	mustExec(t, db, `INSERT INTO seasons (id, name, start_at, end_at) VALUES (1, '테스트 시즌', NOW() - INTERVAL 14 DAY, NOW())`)
+	mustExec(t, db, `INSERT INTO user_assets (user_id, symbol_id, amount) VALUES (1, 1, 0.5)`)
+
+	api := fakeapi.New()
+	defer api.Close()
+	api.SetMarkets([]model.UpbitCoinList{{Market: "KRW-BTC", KoreanName: "비트코인"}})
+	api.SetTickers([]model.UpbitCoinPrice{{Market: "KRW-BTC", TradePrice: 100000000, PrevClosingPrice: 100000000}})
+
+	for key, value := range api.Config() {
+		obj[key] = value
+	}
+	obj["TEST_TIME"] = "2024-03-10 05:00:00"
+	obj["SEASON_NAME"] = "테스트 시즌"
+	obj["SEASON_UPDATE_KEY"] = "test-key"
+
+	// 첫 실행으로 시즌 전환 기록 테이블 생성 후, 90,000,000원 시세로 시작해 청산 전에 중단된 전환 기록 추가
+	Main(obj)
+	mustExec(t, db, `
+		INSERT INTO season_rollover_runs (season_id, new_season_name, scheduled_at, start_date, end_date, last_step, status, liquidation_prices)
+		VALUES (1, '다음 시즌', '2024-03-01 00:00:00', '2024-03-01', '2024-03-16', 'DELETE_PENDING_ORDERS', 'FAILED', '{"1": 90000000}')`)
+
+	// 시세가 100,000,000원인 다음 실행에서 재개해도 기록된 가격으로 청산
+	result := Main(obj)
+	steps, _ := result["steps"].([]model.JobStep)
+	for _, step := range steps {
+		if step.Name == service.StepSeasonResume && step.Status != "SUCCESS" {
+			t.Fatalf("시즌 전환 재개 %s: %s", step.Status, step.Error)
+		}
+	}
+
+	var price float64
+	if err := db.QueryRow(`SELECT trade_price FROM orders WHERE user_id = 1 AND order_type = 'SELL'`).Scan(&price); err != nil {
+		t.Fatalf("청산 주문 조회 실패: %v", err)
+	}
+	if price != 90000000 {
+		t.Errorf("청산 가격 = %.0f, want 90000000", price)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM season_rollover_runs WHERE status = 'COMPLETED'`); n != 1 {
+		t.Errorf("완료된 시즌 전환 %d건, want 1건", n)
+	}
+}
+
+func TestMainInsightWithoutLocaleKey(t *testing.T) {
+	obj := testDBConfig(t)
+
+	db, err := config.ConnectDB(context.Background(), config.NewDBConfig(obj))
+	if err != nil {
+		t.Fatalf("테스트 DB 연결 실패: %v", err)
+	}
+	defer db.Close()
+	resetTestDB(t, db)
+
+	// 스프링 서버 마이그레이션 전의 ai_insights (locale 없이 (symbol, date) 유일 키)
+	mustExec(t, db, `ALTER TABLE ai_insights DROP INDEX uk_ai_insights_symbol_date_locale, DROP COLUMN locale,
+		ADD UNIQUE KEY uk_ai_insights_symbol_date (symbol, date)`)
+	mustExec(t, db, `INSERT INTO coins (id, symbol, korean_name) VALUES (1, 'KRW-BTC', '비트코인'), (2, 'KRW-ETH', '이더리움')`)
+	mustExec(t, db, `INSERT INTO seasons (id, name, start_at, end_at) VALUES (1, '테스트 시즌', NOW() - INTERVAL 1 DAY, NOW() + INTERVAL 14 DAY)`)
+
+	api := fakeapi.New()
+	defer api.Close()
+	api.SetMarkets([]model.UpbitCoinList{{Market: "KRW-BTC", KoreanName: "비트코인"}, {Market: "KRW-ETH", KoreanName: "이더리움"}})
+	api.SetTickers([]model.UpbitCoinPrice{
+		{Market: "KRW-BTC", TradePrice: 100000000, PrevClosingPrice: 98000000},
+		{Market: "KRW-ETH", TradePrice: 5000000, PrevClosingPrice: 5100000},
+	})
+	api.SetLLMReplies(`[
+		{"symbol": "MARKET_OVERALL", "insight": "시장 전반이 완만한 상승세입니다.", "score": 60},
+		{"symbol": "KRW-ETH", "insight": "이더리움은 소폭 조정 중입니다.", "score": 45}
+	]`)
+
+	for key, value := range api.Config() {
+		obj[key] = value
+	}
+	obj["TEST_TIME"] = "2024-03-03 00:00:00"
+	obj["GOOGLE_API_KEY"] = "test-key"
+	obj["INSIGHT_PROMPT_VERSION"] = "v1"
+	obj["INSIGHT_WATCHLIST"] = "KRW-ETH"
+	obj["INSIGHT_LOCALES"] = "ko,en"
+
+	result := Main(obj)
+
+	// ko 인사이트는 저장하고, en은 번역하지 않고 인사이트 단계 실패로 남김
+	steps, _ := result["steps"].([]model.JobStep)
+	var insightStep model.JobStep
+	for _, step := range steps {
+		if step.Name == service.StepInsight {
+			insightStep = step
+		}
+	}
+	if insightStep.Status != "FAILED" || !strings.Contains(insightStep.Error, "스프링 서버 마이그레이션") {
+		t.Errorf("인사이트 단계 = %s (%s), want 마이그레이션 필요로 실패", insightStep.Status, insightStep.Error)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM ai_insights WHERE symbol IN ('MARKET_OVERALL', 'KRW-ETH')`); n != 2 {
+		t.Errorf("인사이트 %d건, want 2건", n)
+	}
+	if prompts := api.Prompts(); len(prompts) != 1 {
+		t.Errorf("LLM 요청 %d회, want 1회 (번역 요청 없음)", len(prompts))
+	}
+
+	// 스프링 서버가 관리하는 테이블은 변경하지 않음
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'ai_insights' AND COLUMN_NAME = 'locale'`); n != 0 {
+		t.Error("ai_insights에 locale 컬럼이 추가됨")
+	}
+}
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"math"
+	"time"
+)
+
 // CoinSymbol 코인 심볼 구조체
 type CoinSymbol struct {
 	Id         int     `db:"id"`
@@ -51,6 +56,7 @@ type UserCash struct {
 
 // UserAsset 유저 자산 구조체
 type UserAsset struct {
+	ID       int64   `db:"id"`
 	UserID   int     `db:"user_id"`
 	SymbolID int     `db:"symbol_id"`
 	Amount   float64 `db:"amount"`
@@ -66,8 +72,159 @@ type UserTotalAsset struct {
 
 // UpdateFlags 업데이트 필요 상태 나타내는 구조체
 type UpdateFlags struct {
-	Season  bool
-	Split   bool
-	Coin    bool
-	Insight bool
+	Season  bool `json:"season"`
+	Split   bool `json:"split"`
+	Coin    bool `json:"coin"`
+	Insight bool `json:"insight"`
+}
+
+// PendingOrder 체결 대기중인 예약 주문 구조체
+type PendingOrder struct {
+	ID         int64   `db:"id" json:"id"`
+	UserID     int     `db:"user_id" json:"userId"`
+	SymbolID   int     `db:"symbol_id" json:"symbolId"`
+	Amount     float64 `db:"amount" json:"amount"`
+	TradePrice float64 `db:"trade_price" json:"tradePrice"`
+	OrderType  string  `db:"order_type" json:"orderType"`
+}
+
+// GeckoCoin CoinGecko API에서 사용하는 코인 마켓 캡 정보를 나타내는 구조체
+type GeckoCoin struct {
+	ID           string  `json:"id"` // CoinGecko 코인 id (과거 시세 조회용)
+	Symbol       string  `json:"symbol"`
+	MarketCap    int64   `json:"market_cap"`
+	CurrentPrice float64 `json:"current_price"` // USD
+}
+
+// UpbitCandle Upbit API 캔들(분/시간 봉) 응답 구조체
+type UpbitCandle struct {
+	Market               string  `json:"market"`
+	CandleDateTimeUTC    string  `json:"candle_date_time_utc"`
+	CandleDateTimeKST    string  `json:"candle_date_time_kst"`
+	OpeningPrice         float64 `json:"opening_price"`
+	HighPrice            float64 `json:"high_price"`
+	LowPrice             float64 `json:"low_price"`
+	TradePrice           float64 `json:"trade_price"`
+	CandleAccTradePrice  float64 `json:"candle_acc_trade_price"`
+	CandleAccTradeVolume float64 `json:"candle_acc_trade_volume"`
+}
+
+// LiquidationOrder 시즌 종료 시 보유 자산 청산으로 생성되는 매도 주문
+type LiquidationOrder struct {
+	AssetID    int64   `json:"assetId"` // 청산된 user_assets id
+	UserID     int     `json:"userId"`
+	SymbolID   int     `json:"symbolId"`
+	Amount     float64 `json:"amount"`
+	TradePrice float64 `json:"tradePrice"`
+}
+
+// TierChange 시즌 종료 시 유저 티어 변경 내역
+type TierChange struct {
+	UserID  int `json:"userId"`
+	OldTier int `json:"oldTier"`
+	NewTier int `json:"newTier"`
+}
+
+// SeasonReport 시즌 종료 드라이런 결과 보고서
+type SeasonReport struct {
+	SeasonID           int                `json:"seasonId"`
+	SeasonName         string             `json:"seasonName"`
+	NewSeasonName      string             `json:"newSeasonName"`
+	NewSeasonStartAt   string             `json:"newSeasonStartAt"`
+	NewSeasonEndAt     string             `json:"newSeasonEndAt"`
+	TierChanges        []TierChange       `json:"tierChanges"`
+	LiquidationOrders  []LiquidationOrder `json:"liquidationOrders"`
+	LiquidationSkipped int                `json:"liquidationSkipped"` // 시세가 없어 청산되지 않는 자산 수
+	LiquidationValue   int64              `json:"liquidationValue"`
+	PendingOrders      []PendingOrder     `json:"pendingOrders"`
+	CashResetUsers     int                `json:"cashResetUsers"`
+	StartingCash       int64              `json:"startingCash"` // 새 시즌 시작 현금
+}
+
+// JobStep Main 실행 단계 하나의 수행 결과
+type JobStep struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"` // SUCCESS, FAILED, SKIPPED
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+	Rows       int64     `json:"rows"`
+}
+
+// JobRun Main 실행 한 번의 기록 (job_runs 테이블)
+type JobRun struct {
+	ID         int64       `json:"id"`
+	StartedAt  time.Time   `json:"startedAt"`
+	FinishedAt time.Time   `json:"finishedAt"`
+	Flags      UpdateFlags `json:"flags"`
+	Success    bool        `json:"success"`
+	Steps      []JobStep   `json:"steps"`
+}
+
+// SeasonEconomy 시즌별 경제 설정 (season_economies 테이블)
+type SeasonEconomy struct {
+	SeasonID        int
+	StartingCash    int64
+	SplitBonus      int64
+	SplitCount      int             // 시즌 중 스플릿 지급 횟수, 0이면 일정대로 제한 없이 지급
+	TierMultipliers map[int]float64 // 티어별 스플릿 지급 배율, 없는 티어는 1배
+}
+
+// SplitAmount 티어에 따른 스플릿 지급액
+func (e SeasonEconomy) SplitAmount(tier int) int64 {
+	multiplier, ok := e.TierMultipliers[tier]
+	if !ok {
+		return e.SplitBonus
+	}
+	return int64(math.Round(float64(e.SplitBonus) * multiplier))
+}
+
+// 인덱스 가중 방식
+const (
+	WeightingCap    = "cap"    // 시가총액 가중
+	WeightingEqual  = "equal"  // 동일 가중
+	WeightingCapped = "capped" // 시가총액 가중, 종목별 최대 비중 제한
+)
+
+// IndexDefinition 인덱스 산출 방법 (market_index_definitions 테이블)
+// 정의를 바꿀 때는 새 버전 행을 추가하고, 각 인덱스 기록에 사용한 버전을 함께 저장합니다.
+type IndexDefinition struct {
+	Code             string   // 인덱스 코드 (예: MARKET)
+	Version          int      // 정의 버전
+	ConstituentCount int      // 편입 종목 수 (시가총액 상위)
+	Excluded         []string // 제외 심볼 (CoinGecko 소문자 심볼, 스테이블 코인/래핑 토큰 등)
+	Weighting        string   // WeightingCap, WeightingEqual, WeightingCapped
+	MaxWeight        float64  // WeightingCapped의 종목별 최대 비중 (0~1)
+	Divisor          float64  // 첫 기록의 divisor (인덱스 값 = 가중 시가총액 / divisor), 이후 구성 변경 시 조정
+	BaseValue        float64  // 첫 기록의 인덱스 값, 0보다 크면 Divisor 대신 이 값에서 시작
+}
+
+// LLMReplayResult 기록된 LLM 응답 재생(재파싱) 결과
+type LLMReplayResult struct {
+	CallID   int64    `json:"callId"`
+	Purpose  string   `json:"purpose"`
+	Provider string   `json:"provider"`
+	Model    string   `json:"model"`
+	Valid    bool     `json:"valid"`
+	Insights int      `json:"insights"` // 파싱된 항목 수
+	Problems []string `json:"problems,omitempty"`
+}
+
+// BackfillReport 누락 구간 채우기 결과
+type BackfillReport struct {
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	PriceMissing int           `json:"priceMissing"` // coin_price_history에서 누락된 (코인, 날짜, 시간) 수
+	PriceFilled  int           `json:"priceFilled"`
+	IndexMissing int           `json:"indexMissing"` // market_indices에서 누락된 (날짜, 시간) 수
+	IndexFilled  int           `json:"indexFilled"`
+	Unfillable   []BackfillGap `json:"unfillable,omitempty"`
+}
+
+// BackfillGap 채우지 못한 누락 구간 (테이블, 심볼, 사유별로 묶음)
+type BackfillGap struct {
+	Table  string   `json:"table"`
+	Symbol string   `json:"symbol,omitempty"`
+	Reason string   `json:"reason"`
+	Slots  []string `json:"slots"` // "YYYY-MM-DD HH"
 }
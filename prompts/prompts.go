@@ -0,0 +1,11 @@
+// Package prompts 인사이트 생성에 사용하는 기본 프롬프트 템플릿을 바이너리에 포함합니다.
+// 파일 이름은 "<이름>.<버전>.tmpl" 형식이며 (예: insight.v2.tmpl), 내용은 text/template 문법을 따릅니다.
+// 인사이트 템플릿은 첫 줄에 요청 방식을 {{define "mode"}}batch{{end -}} 또는 {{define "mode"}}target{{end -}}로 선언해야 합니다.
+package prompts
+
+import "embed"
+
+// FS 기본 프롬프트 템플릿 파일
+//
+//go:embed *.tmpl
+var FS embed.FS
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"Bitground-go/exchange"
+	"Bitground-go/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 누락 구간 채우기 설정
+const (
+	backfillMaxDays          = 31               // 한 번에 채울 수 있는 최대 기간 (일)
+	upbitMaxCandleCount      = 200              // Upbit 캔들 요청 한 번의 최대 개수
+	geckoRequestInterval     = 2 * time.Second  // CoinGecko 과거 시세 요청 간격 (무료 API 호출 제한)
+	backfillCapTolerance     = 90 * time.Minute // 누락 시각과 과거 시가총액 기록의 최대 시차
+	backfillSlotFormat       = "2006-01-02 15"  // 보고서의 누락 시각 표기
+	backfillPriceTable       = "coin_price_history"
+	backfillMarketIndexTable = "market_indices"
+)
+
+// backfillGaps 채우지 못한 누락 구간을 (테이블, 심볼, 사유)별로 모읍니다. (처음 나온 순서 유지)
+type backfillGaps struct {
+	gaps  []model.BackfillGap
+	index map[string]int
+}
+
+func (g *backfillGaps) add(table, symbol, reason string, slot time.Time) {
+	if g.index == nil {
+		g.index = make(map[string]int)
+	}
+	key := table + "\x00" + symbol + "\x00" + reason
+	i, ok := g.index[key]
+	if !ok {
+		i = len(g.gaps)
+		g.index[key] = i
+		g.gaps = append(g.gaps, model.BackfillGap{Table: table, Symbol: symbol, Reason: reason})
+	}
+	g.gaps[i].Slots = append(g.gaps[i].Slots, slot.Format(backfillSlotFormat))
+}
+
+// BackfillHistory from~to(포함) 기간에서 coin_price_history의 (코인, 날짜, 시간)과 market_indices의 (날짜, 시간) 누락을 찾아
+// 거래소 과거 시간봉과 CoinGecko 과거 시가총액으로 채우고, 채우지 못한 구간을 사유와 함께 보고합니다.
+// 기존 upsert를 그대로 사용하므로 같은 기간으로 다시 실행해도 안전합니다. 진행 중인 현재 시간은 대상에서 제외합니다.
+func BackfillHistory(ctx context.Context, db *sql.DB, source exchange.MarketDataSource, geckoBaseURL string, from, to time.Time) (model.BackfillReport, error) {
+	report := model.BackfillReport{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+	if to.Before(from) {
+		return report, fmt.Errorf("종료일(%s)이 시작일(%s)보다 빠릅니다", report.To, report.From)
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > backfillMaxDays {
+		return report, fmt.Errorf("기간이 %d일로 최대 %d일을 넘습니다", days, backfillMaxDays)
+	}
+
+	slots := backfillSlots(from, to, time.Now())
+	if len(slots) == 0 {
+		return report, nil
+	}
+	var gaps backfillGaps
+
+	// 1. 코인 가격 히스토리
+	symbolMap, err := GetActiveCoinsSymbols(ctx, db)
+	if err != nil {
+		return report, fmt.Errorf("활성화된 코인 심볼 조회 실패: %w", err)
+	}
+	if err := ensurePriceHistoryColumns(ctx, db); err != nil {
+		return report, err
+	}
+	if err := backfillPriceHistory(ctx, db, source, symbolMap, slots, &report, &gaps); err != nil {
+		report.Unfillable = gaps.gaps
+		return report, fmt.Errorf("가격 히스토리 채우기 실패: %w", err)
+	}
+
+	// 2. 마켓 인덱스
+	if err := backfillMarketIndices(ctx, db, geckoBaseURL, slots, &report, &gaps); err != nil {
+		report.Unfillable = gaps.gaps
+		return report, fmt.Errorf("마켓 인덱스 채우기 실패: %w", err)
+	}
+
+	report.Unfillable = gaps.gaps
+	log.Printf("누락 구간 채우기 완료: 가격 %d/%d, 인덱스 %d/%d\n",
+		report.PriceFilled, report.PriceMissing, report.IndexFilled, report.IndexMissing)
+	return report, nil
+}
+
+// from 0시부터 to 23시까지의 시간 목록 (현재 진행 중인 시간 이후는 제외)
+func backfillSlots(from, to, now time.Time) []time.Time {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 23, 0, 0, 0, time.Local)
+	if current := now.In(time.Local).Truncate(time.Hour); !end.Before(current) {
+		end = current.Add(-time.Hour)
+	}
+
+	var slots []time.Time
+	for slot := start; !slot.After(end); slot = slot.Add(time.Hour) {
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// 누락 시각 키 ("YYYY-MM-DD HH", backfillSlotFormat과 같은 형식)
+func slotKey(date string, hour int) string {
+	return fmt.Sprintf("%s %02d", date, hour)
+}
+
+// backfillPriceHistory 활성 코인별 누락 시간을 Upbit 시간봉으로 채우고, 채운 날짜의 일/주/월봉을 다시 집계합니다.
+// 코인마다 바로 저장하고 집계하므로 중간에 시간 제한에 걸려도 그때까지 채운 구간은 남고 보고서에 반영됩니다.
+func backfillPriceHistory(ctx context.Context, db *sql.DB, source exchange.MarketDataSource, symbolMap map[string]int,
+	slots []time.Time, report *model.BackfillReport, gaps *backfillGaps) error {
+	existing, err := loadPriceHistorySlots(ctx, db, slots[0], slots[len(slots)-1])
+	if err != nil {
+		return err
+	}
+
+	markets := make([]string, 0, len(symbolMap))
+	for market := range symbolMap {
+		markets = append(markets, market)
+	}
+	sort.Strings(markets)
+
+	throttle := time.NewTicker(upbitCandleInterval)
+	defer throttle.Stop()
+
+	for _, market := range markets {
+		coinID := symbolMap[market]
+		missing := make(map[string]time.Time)
+		for _, slot := range slots {
+			key := slotKey(slot.Format("2006-01-02"), slot.Hour())
+			if !existing[coinID][key] {
+				missing[key] = slot
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		report.PriceMissing += len(missing)
+
+		candles, err := fetchMissingCandles(ctx, source, throttle, market, coinID, missing)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// 시간 제한에 걸리면 저장할 수 없으므로 이 코인부터는 다음 실행에서 채움
+			return fmt.Errorf("%s 이후 코인 중단: %w", market, ctxErr)
+		}
+		if err != nil {
+			log.Printf("%s 과거 시간봉 조회 실패: %v\n", market, err)
+		}
+		if saveErr := saveBackfilledCandles(ctx, db, coinID, candles); saveErr != nil {
+			return fmt.Errorf("%s 시간봉 저장 실패: %w", market, saveErr)
+		}
+		report.PriceFilled += len(candles)
+		for _, candle := range candles {
+			delete(missing, slotKey(candle.Date, candle.Hour))
+		}
+
+		reason := "거래소 시간봉 없음 (거래가 없었거나 상장 전)"
+		if err != nil {
+			reason = "시간봉 조회 실패: " + err.Error()
+		}
+		for _, slot := range sortedSlots(missing) {
+			gaps.add(backfillPriceTable, market, reason, slot)
+		}
+	}
+
+	return nil
+}
+
+// 코인 한 개의 채운 시간봉을 저장하고 해당 날짜의 일/주/월봉을 다시 집계합니다.
+func saveBackfilledCandles(ctx context.Context, db *sql.DB, coinID int, candles []hourlyCandle) error {
+	dates, err := upsertHourlyCandles(ctx, db, candles)
+	if err != nil {
+		return err
+	}
+	return updateCandleRollups(ctx, db, coinID, dates)
+}
+
+// 기간 안에 이미 시간봉으로 기록된 (코인 id → 날짜/시간) 목록
+// 24시간 시세로 기록된 이전 행은 누락으로 보고 시간봉으로 덮어씁니다.
+func loadPriceHistorySlots(ctx context.Context, db *sql.DB, first, last time.Time) (map[int]map[string]bool, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT coin_id, date, hour
+		FROM coin_price_history
+		WHERE is_hourly_candle = 1 AND date >= ? AND date <= ?
+	`
+
+	rows, err := db.QueryContext(queryCtx, query, first.Format("2006-01-02"), last.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	existing := make(map[int]map[string]bool)
+	for rows.Next() {
+		var coinID, hour int
+		var date time.Time
+		if err := rows.Scan(&coinID, &date, &hour); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		if existing[coinID] == nil {
+			existing[coinID] = make(map[string]bool)
+		}
+		existing[coinID][slotKey(date.Format("2006-01-02"), hour)] = true
+	}
+
+	return existing, rows.Err()
+}
+
+// 가장 늦은 누락 시간부터 과거로 시간봉을 페이지 단위로 조회하여 누락 시간에 해당하는 시간봉만 반환합니다.
+// 조회 도중 실패하면 그때까지 찾은 시간봉과 에러를 함께 반환합니다.
+func fetchMissingCandles(ctx context.Context, source exchange.MarketDataSource, throttle *time.Ticker,
+	market string, coinID int, missing map[string]time.Time) ([]hourlyCandle, error) {
+	ordered := sortedSlots(missing)
+	earliest := ordered[0]
+	to := ordered[len(ordered)-1].Add(time.Hour)
+
+	var found []hourlyCandle
+	for {
+		select {
+		case <-ctx.Done():
+			return found, ctx.Err()
+		case <-throttle.C:
+		}
+
+		upbitCandles, err := source.FetchCandles(ctx, market, upbitHourUnit, to, upbitMaxCandleCount)
+		if err != nil {
+			return found, err
+		}
+		if len(upbitCandles) == 0 {
+			return found, nil
+		}
+
+		oldest := to
+		for _, c := range upbitCandles {
+			candle, err := newHourlyCandle(coinID, c)
+			if err != nil {
+				continue
+			}
+			if candle.Start.Before(oldest) {
+				oldest = candle.Start
+			}
+			if _, ok := missing[slotKey(candle.Date, candle.Hour)]; ok {
+				found = append(found, candle)
+			}
+		}
+
+		// 가장 이른 누락 시간까지 내려왔거나 더 과거 캔들이 없으면 종료
+		if !oldest.After(earliest) || !oldest.Before(to) {
+			return found, nil
+		}
+		to = oldest
+	}
+}
+
+// 누락 시간을 시간 순으로 정렬합니다.
+func sortedSlots(slots map[string]time.Time) []time.Time {
+	ordered := make([]time.Time, 0, len(slots))
+	for _, slot := range slots {
+		ordered = append(ordered, slot)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].Before(ordered[j])
+	})
+	return ordered
+}
+
+// backfillMarketIndices 누락된 시간의 마켓/알트 인덱스를 직전 기록의 구성 종목, 정의 버전, divisor와
+// CoinGecko 과거 시가총액으로 다시 계산하여 채웁니다.
+// 직전 인덱스 기록(market_index_levels)이 없거나 구성 종목의 과거 시가총액을 찾지 못한 시간은 채우지 않습니다.
+func backfillMarketIndices(ctx context.Context, db *sql.DB, geckoBaseURL string, slots []time.Time,
+	report *model.BackfillReport, gaps *backfillGaps) error {
+	missing, err := loadMissingIndexSlots(ctx, db, slots)
+	if err != nil {
+		return err
+	}
+	report.IndexMissing = len(missing)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := ensureIndexLevelTables(ctx, db); err != nil {
+		return fmt.Errorf("인덱스 기록 테이블 생성 실패: %w", err)
+	}
+	if err := ensureColumn(ctx, db, "market_indices", "definition_version", "INT NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+
+	// 심볼 → CoinGecko id (현재 시가총액 상위 종목 기준)
+	coins, err := getMarketCap(ctx, geckoBaseURL, geckoMaxPerPage)
+	if err != nil {
+		for _, slot := range missing {
+			gaps.add(backfillMarketIndexTable, "", "CoinGecko 종목 조회 실패: "+err.Error(), slot)
+		}
+		return nil
+	}
+	geckoIDs := make(map[string]string, len(coins))
+	for symbol, coin := range geckoCoinsBySymbol(coins) {
+		if coin.ID != "" {
+			geckoIDs[symbol] = coin.ID
+		}
+	}
+
+	history := geckoCapHistory{
+		baseURL: geckoBaseURL,
+		from:    missing[0].Add(-backfillCapTolerance),
+		to:      missing[len(missing)-1].Add(backfillCapTolerance),
+		ids:     geckoIDs,
+		series:  make(map[string][]geckoCapPoint),
+		errs:    make(map[string]error),
+	}
+
+	for _, slot := range missing {
+		date, hour := slot.Format("2006-01-02"), slot.Hour()
+
+		levels := make([]indexLevel, 0, 2)
+		version := 0
+		var reason string
+		for _, code := range []string{marketIndexCode, altIndexCode} {
+			level, r, err := backfillIndexLevel(ctx, db, &history, code, date, hour, slot)
+			if err != nil {
+				return err
+			}
+			if r != "" {
+				reason = fmt.Sprintf("%s: %s", code, r)
+				break
+			}
+			levels = append(levels, level)
+			version = level.Version
+		}
+		if reason != "" {
+			gaps.add(backfillMarketIndexTable, "", reason, slot)
+			continue
+		}
+
+		if err := saveIndexLevels(ctx, db, date, hour, levels); err != nil {
+			return fmt.Errorf("saveIndexLevels 에러: %w", err)
+		}
+		if err := insertMarketIndex(ctx, db, date, hour, levels[0].Value, levels[1].Value, version); err != nil {
+			return fmt.Errorf("insertMarketIndex 에러: %w", err)
+		}
+		report.IndexFilled++
+	}
+
+	return nil
+}
+
+// 기간 안에서 market_indices에 기록이 없는 시간 목록 (시간 순)
+func loadMissingIndexSlots(ctx context.Context, db *sql.DB, slots []time.Time) ([]time.Time, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT date, hour
+		FROM market_indices
+		WHERE date >= ? AND date <= ?
+	`
+
+	rows, err := db.QueryContext(queryCtx, query, slots[0].Format("2006-01-02"), slots[len(slots)-1].Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var date time.Time
+		var hour int
+		if err := rows.Scan(&date, &hour); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		existing[slotKey(date.Format("2006-01-02"), hour)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []time.Time
+	for _, slot := range slots {
+		if !existing[slotKey(slot.Format("2006-01-02"), slot.Hour())] {
+			missing = append(missing, slot)
+		}
+	}
+	return missing, nil
+}
+
+// 인덱스 하나의 누락 시간 값을 직전 기록의 구성 종목으로 다시 계산합니다.
+// 채울 수 없으면 reason에 사유를 반환합니다. (err는 DB 오류)
+func backfillIndexLevel(ctx context.Context, db *sql.DB, history *geckoCapHistory, code, date string, hour int, slot time.Time) (level indexLevel, reason string, err error) {
+	prev, err := loadPrevIndexLevel(ctx, db, code, date, hour)
+	if err != nil {
+		return level, "", err
+	}
+	if prev == nil || len(prev.Constituents) == 0 {
+		return level, "직전 인덱스 기록 없음", nil
+	}
+
+	def, err := loadIndexDefinitionVersion(ctx, db, indexDefinitionCode(code), prev.Version)
+	if err != nil {
+		return level, "", err
+	}
+	def.Code = code
+	prev.Definition = def
+
+	constituents := make([]model.GeckoCoin, 0, len(prev.Constituents))
+	for _, symbol := range prev.Constituents {
+		marketCap, r := history.capAt(ctx, symbol, slot)
+		if r != "" {
+			return level, fmt.Sprintf("%s %s", symbol, r), nil
+		}
+		constituents = append(constituents, model.GeckoCoin{Symbol: symbol, MarketCap: marketCap})
+	}
+	sort.SliceStable(constituents, func(i, j int) bool {
+		return constituents[i].MarketCap > constituents[j].MarketCap
+	})
+
+	// 구성 종목과 정의 버전이 직전 기록과 같으므로 직전 divisor를 그대로 사용합니다.
+	return computeIndexLevel(def, constituents, nil, prev), "", nil
+}
+
+// geckoCapPoint CoinGecko 과거 시가총액 한 점
+type geckoCapPoint struct {
+	At        time.Time
+	MarketCap float64
+}
+
+// geckoCapHistory 심볼별 과거 시가총액을 기간 전체로 한 번만 조회해 재사용합니다.
+type geckoCapHistory struct {
+	baseURL  string
+	from, to time.Time
+	ids      map[string]string // 심볼 → CoinGecko id
+	series   map[string][]geckoCapPoint
+	errs     map[string]error
+	lastCall time.Time
+}
+
+// capAt symbol의 at 시각에 가장 가까운 과거 시가총액을 반환합니다. 찾지 못하면 reason에 사유를 반환합니다.
+func (h *geckoCapHistory) capAt(ctx context.Context, symbol string, at time.Time) (marketCap int64, reason string) {
+	symbol = strings.ToLower(symbol)
+	if err, failed := h.errs[symbol]; failed {
+		return 0, "과거 시가총액 조회 실패: " + err.Error()
+	}
+
+	points, ok := h.series[symbol]
+	if !ok {
+		id, known := h.ids[symbol]
+		if !known {
+			return 0, "CoinGecko id를 찾을 수 없음"
+		}
+
+		if wait := geckoRequestInterval - time.Since(h.lastCall); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, "과거 시가총액 조회 실패: " + ctx.Err().Error()
+			case <-time.After(wait):
+			}
+		}
+		var err error
+		points, err = getMarketCapHistory(ctx, h.baseURL, id, h.from, h.to)
+		h.lastCall = time.Now()
+		if err != nil {
+			h.errs[symbol] = err
+			return 0, "과거 시가총액 조회 실패: " + err.Error()
+		}
+		h.series[symbol] = points
+	}
+
+	var nearest *geckoCapPoint
+	for i := range points {
+		if nearest == nil || absDuration(points[i].At.Sub(at)) < absDuration(nearest.At.Sub(at)) {
+			nearest = &points[i]
+		}
+	}
+	if nearest == nil || absDuration(nearest.At.Sub(at)) > backfillCapTolerance || nearest.MarketCap <= 0 {
+		return 0, "해당 시각의 과거 시가총액 없음"
+	}
+	return int64(math.Round(nearest.MarketCap)), ""
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// getMarketCapHistory CoinGecko market_chart/range API로 코인의 기간 내 과거 시가총액을 조회합니다.
+func getMarketCapHistory(ctx context.Context, baseURL, id string, from, to time.Time) ([]geckoCapPoint, error) {
+	apiURL := fmt.Sprintf("%s/api/v3/coins/%s/market_chart/range?vs_currency=usd&from=%d&to=%d",
+		baseURL, id, from.Unix(), to.Unix())
+
+	// Context를 활용한 HTTP 요청 (타임아웃: 30초)
+	reqCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP 요청 생성 에러: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API 요청 에러: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		// 응답 본문을 닫아 리소스 누수 방지
+		if err := Body.Close(); err != nil {
+			log.Printf("응답 본문 닫기 에러: %v\n", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("서버 응답 에러: 상태 코드 %d", resp.StatusCode)
+	}
+
+	var body struct {
+		MarketCaps [][2]float64 `json:"market_caps"` // [밀리초 타임스탬프, 시가총액]
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("JSON 디코딩 에러: %w", err)
+	}
+
+	points := make([]geckoCapPoint, 0, len(body.MarketCaps))
+	for _, p := range body.MarketCaps {
+		points = append(points, geckoCapPoint{At: time.UnixMilli(int64(p[0])), MarketCap: p[1]})
+	}
+	return points, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// hourlyCandleSource first~last 시간의 시간봉을 Upbit처럼 to 이전부터 최신순으로 count개씩 돌려주는 테스트용 시세 조회
+type hourlyCandleSource struct {
+	fakeCandleSource
+	first, last time.Time
+	failAfter   int // 0보다 크면 이 횟수만큼 조회한 뒤 실패
+}
+
+func (s *hourlyCandleSource) FetchCandles(ctx context.Context, market string, unit int, to time.Time, count int) ([]model.UpbitCandle, error) {
+	s.calls = append(s.calls, to)
+	if s.failAfter > 0 && len(s.calls) > s.failAfter {
+		return nil, errors.New("timeout")
+	}
+
+	var candles []model.UpbitCandle
+	for t := to.Add(-time.Hour); !t.Before(s.first) && len(candles) < count; t = t.Add(-time.Hour) {
+		if t.After(s.last) {
+			continue
+		}
+		candles = append(candles, model.UpbitCandle{
+			Market:            market,
+			CandleDateTimeUTC: t.UTC().Format("2006-01-02T15:04:05"),
+			TradePrice:        float64(t.Hour()),
+		})
+	}
+	return candles, nil
+}
+
+func TestBackfillSlots(t *testing.T) {
+	local := func(day, hour int) time.Time {
+		return time.Date(2024, 3, day, hour, 0, 0, 0, time.Local)
+	}
+
+	slots := backfillSlots(local(14, 15), local(15, 0), local(20, 0))
+	if len(slots) != 48 || !slots[0].Equal(local(14, 0)) || !slots[47].Equal(local(15, 23)) {
+		t.Errorf("지난 날짜는 0시~23시 전체: %d개 %s ~ %s", len(slots), slots[0], slots[len(slots)-1])
+	}
+
+	// 오늘은 현재 진행 중인 시간 직전까지만
+	slots = backfillSlots(local(15, 0), local(15, 0), local(15, 10).Add(30*time.Minute))
+	if len(slots) != 10 || !slots[9].Equal(local(15, 9)) {
+		t.Errorf("진행 중인 시간 제외: %d개, 마지막 %s", len(slots), slots[len(slots)-1])
+	}
+
+	if slots := backfillSlots(local(15, 0), local(15, 0), local(15, 0)); len(slots) != 0 {
+		t.Errorf("채울 시간이 없으면 빈 목록: %d개", len(slots))
+	}
+}
+
+func TestFetchMissingCandles(t *testing.T) {
+	first := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	source := &hourlyCandleSource{first: first, last: first.Add(999 * time.Hour)}
+	throttle := time.NewTicker(time.Millisecond)
+	defer throttle.Stop()
+
+	// 페이지(200개)를 넘어 떨어진 누락 시간
+	missing := make(map[string]time.Time)
+	for _, offset := range []int{10, 11, 500, 900} {
+		slot := first.Add(time.Duration(offset) * time.Hour)
+		missing[slotKey(slot.Format("2006-01-02"), slot.Hour())] = slot
+	}
+
+	candles, err := fetchMissingCandles(context.Background(), source, throttle, "KRW-BTC", 1, missing)
+	if err != nil {
+		t.Fatalf("fetchMissingCandles 에러: %v", err)
+	}
+	if len(candles) != len(missing) {
+		t.Fatalf("시간봉 %d개, want %d개", len(candles), len(missing))
+	}
+	for _, c := range candles {
+		slot, ok := missing[slotKey(c.Date, c.Hour)]
+		if !ok || !c.Start.Equal(slot) || c.CoinID != 1 {
+			t.Errorf("누락 시간이 아닌 시간봉 %+v", c)
+		}
+	}
+	// 가장 늦은 누락 시간 다음 시각부터 가장 이른 누락 시간까지만 조회 (901시간 → 5페이지)
+	if want := first.Add(901 * time.Hour); !source.calls[0].Equal(want) {
+		t.Errorf("첫 조회 to = %s, want %s", source.calls[0], want)
+	}
+	if len(source.calls) != 5 {
+		t.Errorf("조회 %d회, want 5회", len(source.calls))
+	}
+}
+
+func TestFetchMissingCandlesPartialFailure(t *testing.T) {
+	first := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	source := &hourlyCandleSource{first: first, last: first.Add(999 * time.Hour), failAfter: 1}
+	throttle := time.NewTicker(time.Millisecond)
+	defer throttle.Stop()
+
+	missing := make(map[string]time.Time)
+	for _, offset := range []int{10, 900} {
+		slot := first.Add(time.Duration(offset) * time.Hour)
+		missing[slotKey(slot.Format("2006-01-02"), slot.Hour())] = slot
+	}
+
+	candles, err := fetchMissingCandles(context.Background(), source, throttle, "KRW-BTC", 1, missing)
+	if err == nil {
+		t.Fatal("조회 실패가 반환되어야 함")
+	}
+	if len(candles) != 1 || !candles[0].Start.Equal(first.Add(900*time.Hour)) {
+		t.Errorf("실패 전까지 찾은 시간봉은 반환해야 함: %+v", candles)
+	}
+}
+
+func TestFetchMissingCandlesBeforeListing(t *testing.T) {
+	first := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)
+	source := &hourlyCandleSource{first: first, last: first.Add(48 * time.Hour)}
+	throttle := time.NewTicker(time.Millisecond)
+	defer throttle.Stop()
+
+	// 상장 전 시간은 찾지 못하고, 더 과거 캔들이 없으면 조회를 멈춤
+	early := first.Add(-500 * time.Hour)
+	missing := map[string]time.Time{
+		slotKey(early.Format("2006-01-02"), early.Hour()): early,
+		slotKey(first.Format("2006-01-02"), first.Hour()): first,
+	}
+
+	candles, err := fetchMissingCandles(context.Background(), source, throttle, "KRW-NEW", 2, missing)
+	if err != nil {
+		t.Fatalf("fetchMissingCandles 에러: %v", err)
+	}
+	if len(candles) != 1 || !candles[0].Start.Equal(first) {
+		t.Errorf("상장 이후 시간봉만 찾아야 함: %+v", candles)
+	}
+	if len(source.calls) > 2 {
+		t.Errorf("더 과거 캔들이 없으면 멈춰야 함: 조회 %d회", len(source.calls))
+	}
+}
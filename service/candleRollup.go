@@ -0,0 +1,233 @@
+package service
+
+import (
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// candlePeriod 시간봉을 집계하는 캔들 단위
+type candlePeriod struct {
+	Name   string
+	Table  string
+	Column string                    // 기간 시작일 컬럼
+	Start  func(time.Time) time.Time // 날짜가 속한 기간의 시작일
+	End    func(time.Time) time.Time // 기간 시작일의 다음 기간 시작일
+}
+
+// 일봉은 시간봉에서, 주봉(월요일 시작)과 월봉은 일봉에서 집계합니다.
+var (
+	dailyCandles = candlePeriod{
+		Name: "일봉", Table: "coin_candles_daily", Column: "date",
+		Start: func(t time.Time) time.Time { return t },
+		End:   func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+	}
+	weeklyCandles = candlePeriod{
+		Name: "주봉", Table: "coin_candles_weekly", Column: "week_start",
+		Start: func(t time.Time) time.Time { return t.AddDate(0, 0, -(int(t.Weekday())+6)%7) },
+		End:   func(t time.Time) time.Time { return t.AddDate(0, 0, 7) },
+	}
+	monthlyCandles = candlePeriod{
+		Name: "월봉", Table: "coin_candles_monthly", Column: "month_start",
+		Start: func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()) },
+		End:   func(t time.Time) time.Time { return t.AddDate(0, 1, 0) },
+	}
+)
+
+// periodCandle 코인 한 개의 기간 캔들
+type periodCandle struct {
+	CoinID                 int
+	Start                  time.Time
+	Open, High, Low, Close float64
+	Volume                 float64
+}
+
+// ensureCandleTables 일/주/월봉 테이블이 없으면 생성
+func ensureCandleTables(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for _, period := range []candlePeriod{dailyCandles, weeklyCandles, monthlyCandles} {
+		createQuery := fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				coin_id INT NOT NULL,
+				%s DATE NOT NULL,
+				open_price DOUBLE NOT NULL,
+				high_price DOUBLE NOT NULL,
+				low_price DOUBLE NOT NULL,
+				close_price DOUBLE NOT NULL,
+				volume DOUBLE NOT NULL,
+				PRIMARY KEY (coin_id, %s),
+				INDEX idx_%s_start (%s)
+			)
+		`, period.Table, period.Column, period.Column, period.Table, period.Column)
+		if _, err := db.ExecContext(queryCtx, createQuery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateCandleRollups dates(YYYY-MM-DD)의 일봉과 그 날짜가 속한 주봉, 월봉을 다시 집계합니다.
+// coinID가 0보다 크면 해당 코인만 집계합니다.
+// 해당 기간 전체를 다시 계산해 덮어쓰므로 같은 날짜로 여러 번 실행해도 결과가 같습니다.
+func updateCandleRollups(ctx context.Context, db *sql.DB, coinID int, dates []string) error {
+	if len(dates) == 0 {
+		return nil
+	}
+	if err := ensureCandleTables(ctx, db); err != nil {
+		return fmt.Errorf("캔들 테이블 생성 실패: %w", err)
+	}
+
+	first, last := dates[0], dates[0]
+	for _, date := range dates {
+		if date < first {
+			first = date
+		}
+		if date > last {
+			last = date
+		}
+	}
+	firstDay, err := time.Parse("2006-01-02", first)
+	if err != nil {
+		return fmt.Errorf("날짜 파싱 실패: %w", err)
+	}
+	lastDay, err := time.Parse("2006-01-02", last)
+	if err != nil {
+		return fmt.Errorf("날짜 파싱 실패: %w", err)
+	}
+
+	// 1. 일봉 (시간봉에서 집계, 24시간 시세로 기록된 이전 행은 제외)
+	if err := rollupCandles(ctx, db, dailyCandles, coinID, firstDay, lastDay, `
+		SELECT coin_id, date, open_price, high_price, low_price, close_price, volume
+		FROM coin_price_history
+		WHERE is_hourly_candle = 1 AND date >= ? AND date < ?%s
+		ORDER BY coin_id, date, hour
+	`); err != nil {
+		return err
+	}
+
+	// 2. 주봉, 월봉 (일봉에서 집계)
+	for _, period := range []candlePeriod{weeklyCandles, monthlyCandles} {
+		if err := rollupCandles(ctx, db, period, coinID, firstDay, lastDay, `
+			SELECT coin_id, date, open_price, high_price, low_price, close_price, volume
+			FROM coin_candles_daily
+			WHERE date >= ? AND date < ?%s
+			ORDER BY coin_id, date
+		`); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// firstDay~lastDay가 속한 period 기간 전체를 sourceQuery(시작일, 종료일 다음 기간 시작일)로 한 번에 조회해 집계하고 저장합니다.
+// sourceQuery의 %s에는 코인 조건이 들어가며, 결과는 코인별로 시간 순서여야 합니다.
+func rollupCandles(ctx context.Context, db *sql.DB, period candlePeriod, coinID int, firstDay, lastDay time.Time, sourceQuery string) error {
+	start := period.Start(firstDay)
+	end := period.End(period.Start(lastDay))
+	args := []interface{}{start.Format("2006-01-02"), end.Format("2006-01-02")}
+	coinCondition := ""
+	if coinID > 0 {
+		coinCondition = " AND coin_id = ?"
+		args = append(args, coinID)
+	}
+
+	candles, err := loadRollupCandles(ctx, db, fmt.Sprintf(sourceQuery, coinCondition), period, args)
+	if err != nil {
+		return fmt.Errorf("%s 집계 대상 조회 실패: %w", period.Name, err)
+	}
+	if err := upsertPeriodCandles(ctx, db, period, candles); err != nil {
+		return fmt.Errorf("%s 저장 실패: %w", period.Name, err)
+	}
+	return nil
+}
+
+// 하위 캔들을 조회하여 코인별, 기간별 캔들로 합칩니다.
+func loadRollupCandles(ctx context.Context, db *sql.DB, query string, period candlePeriod, args []interface{}) ([]periodCandle, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	var candles []periodCandle
+	for rows.Next() {
+		var c periodCandle
+		if err := rows.Scan(&c.CoinID, &c.Start, &c.Open, &c.High, &c.Low, &c.Close, &c.Volume); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		candles = appendRollupCandle(candles, period, c)
+	}
+
+	return candles, rows.Err()
+}
+
+// appendRollupCandle 시간 순서로 들어오는 하위 캔들 c를 period 캔들 목록에 합칩니다.
+// 코인이나 기간이 바뀌면 새 캔들을 시작하고, 같은 기간이면 시가는 첫 캔들의 시가, 종가는 마지막 캔들의 종가,
+// 고가/저가는 최댓값/최솟값, 거래량은 합계로 합칩니다.
+func appendRollupCandle(candles []periodCandle, period candlePeriod, c periodCandle) []periodCandle {
+	c.Start = period.Start(c.Start)
+	last := len(candles) - 1
+	if last < 0 || candles[last].CoinID != c.CoinID || !candles[last].Start.Equal(c.Start) {
+		return append(candles, c)
+	}
+
+	candle := &candles[last]
+	if c.High > candle.High {
+		candle.High = c.High
+	}
+	if c.Low < candle.Low {
+		candle.Low = c.Low
+	}
+	candle.Close = c.Close
+	candle.Volume += c.Volume
+	return candles
+}
+
+// 기간 캔들 저장 (이미 있으면 덮어씁니다)
+func upsertPeriodCandles(ctx context.Context, db *sql.DB, period candlePeriod, candles []periodCandle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	valueStrings := make([]string, 0, len(candles))
+	valueArgs := make([]interface{}, 0, len(candles)*7)
+	for _, c := range candles {
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?, ?)")
+		valueArgs = append(valueArgs, c.CoinID, c.Start.Format("2006-01-02"), c.Open, c.High, c.Low, c.Close, c.Volume)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (coin_id, %s, open_price, high_price, low_price, close_price, volume)
+		VALUES %s
+		ON DUPLICATE KEY UPDATE
+			open_price = VALUES(open_price),
+			high_price = VALUES(high_price),
+			low_price = VALUES(low_price),
+			close_price = VALUES(close_price),
+			volume = VALUES(volume)
+	`, period.Table, period.Column, strings.Join(valueStrings, ","))
+
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(queryCtx, query, valueArgs...); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	util.AddRows(ctx, int64(len(candles)))
+
+	return nil
+}
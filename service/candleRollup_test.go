@@ -0,0 +1,155 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"context"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// 기간 캔들 비교 (시작일은 시각으로 비교)
+func samePeriodCandle(a, b periodCandle) bool {
+	return a.CoinID == b.CoinID && a.Start.Equal(b.Start) && a.Open == b.Open && a.High == b.High &&
+		a.Low == b.Low && a.Close == b.Close && a.Volume == b.Volume
+}
+
+func TestCandlePeriodStart(t *testing.T) {
+	tests := []struct {
+		period candlePeriod
+		date   string
+		start  string
+		end    string
+	}{
+		{dailyCandles, "2024-03-13", "2024-03-13", "2024-03-14"},
+		// 주봉은 월요일 시작 (2024-03-11 월요일)
+		{weeklyCandles, "2024-03-11", "2024-03-11", "2024-03-18"},
+		{weeklyCandles, "2024-03-13", "2024-03-11", "2024-03-18"},
+		{weeklyCandles, "2024-03-17", "2024-03-11", "2024-03-18"},
+		{weeklyCandles, "2025-01-01", "2024-12-30", "2025-01-06"},
+		{monthlyCandles, "2024-02-29", "2024-02-01", "2024-03-01"},
+		{monthlyCandles, "2024-12-31", "2024-12-01", "2025-01-01"},
+	}
+
+	for _, tt := range tests {
+		start := tt.period.Start(day(tt.date))
+		if got := start.Format("2006-01-02"); got != tt.start {
+			t.Errorf("%s Start(%s) = %s, want %s", tt.period.Name, tt.date, got, tt.start)
+		}
+		if got := tt.period.End(start).Format("2006-01-02"); got != tt.end {
+			t.Errorf("%s End(%s) = %s, want %s", tt.period.Name, tt.start, got, tt.end)
+		}
+	}
+}
+
+func TestAppendRollupCandle(t *testing.T) {
+	// 코인별, 시간 순서로 들어오는 일봉 (2024-03-17 일요일, 03-18 월요일)
+	source := []periodCandle{
+		{CoinID: 1, Start: day("2024-03-16"), Open: 100, High: 120, Low: 90, Close: 110, Volume: 1},
+		{CoinID: 1, Start: day("2024-03-17"), Open: 110, High: 130, Low: 105, Close: 125, Volume: 2},
+		{CoinID: 1, Start: day("2024-03-18"), Open: 125, High: 126, Low: 80, Close: 85, Volume: 3},
+		{CoinID: 2, Start: day("2024-03-17"), Open: 10, High: 11, Low: 9, Close: 10, Volume: 5},
+	}
+
+	var candles []periodCandle
+	for _, c := range source {
+		candles = appendRollupCandle(candles, weeklyCandles, c)
+	}
+
+	want := []periodCandle{
+		{CoinID: 1, Start: day("2024-03-11"), Open: 100, High: 130, Low: 90, Close: 125, Volume: 3},
+		{CoinID: 1, Start: day("2024-03-18"), Open: 125, High: 126, Low: 80, Close: 85, Volume: 3},
+		{CoinID: 2, Start: day("2024-03-11"), Open: 10, High: 11, Low: 9, Close: 10, Volume: 5},
+	}
+	if len(candles) != len(want) {
+		t.Fatalf("주봉 %d개, want %d개: %+v", len(candles), len(want), candles)
+	}
+	for i := range want {
+		if !samePeriodCandle(candles[i], want[i]) {
+			t.Errorf("candles[%d] = %+v, want %+v", i, candles[i], want[i])
+		}
+	}
+
+	candles = nil
+	for _, c := range source[:3] {
+		candles = appendRollupCandle(candles, monthlyCandles, c)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("월봉 %d개, want 1개", len(candles))
+	}
+	if got, want := candles[0], (periodCandle{CoinID: 1, Start: day("2024-03-01"), Open: 100, High: 130, Low: 80, Close: 85, Volume: 6}); !samePeriodCandle(got, want) {
+		t.Errorf("월봉 = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewHourlyCandleLocalTime(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("KST", 9*60*60)
+	defer func() { time.Local = local }()
+
+	c, err := newHourlyCandle(7, model.UpbitCandle{
+		CandleDateTimeUTC:    "2024-03-15T16:00:00",
+		CandleDateTimeKST:    "2024-03-16T01:00:00",
+		OpeningPrice:         100,
+		HighPrice:            110,
+		LowPrice:             95,
+		TradePrice:           105,
+		CandleAccTradeVolume: 12.5,
+	})
+	if err != nil {
+		t.Fatalf("newHourlyCandle 에러: %v", err)
+	}
+	// UTC 16시 캔들은 서버 시간(KST) 다음 날 1시로 기록
+	if c.Date != "2024-03-16" || c.Hour != 1 {
+		t.Errorf("date/hour = %s %d, want 2024-03-16 1", c.Date, c.Hour)
+	}
+	if c.CoinID != 7 || c.Open != 100 || c.High != 110 || c.Low != 95 || c.Close != 105 || c.Volume != 12.5 {
+		t.Errorf("candle = %+v", c)
+	}
+
+	if _, err := newHourlyCandle(7, model.UpbitCandle{CandleDateTimeUTC: "bad"}); err == nil {
+		t.Error("잘못된 캔들 시각은 에러가 나야 함")
+	}
+}
+
+func TestLoadRollupCandles(t *testing.T) {
+	db := openTestDB(t)
+
+	// 코인별 시간 순서의 시간봉 (coin_price_history 대신 고정 행)
+	query := `
+		SELECT coin_id, date, open_price, high_price, low_price, close_price, volume FROM (
+			SELECT 1 AS coin_id, DATE('2024-03-15') AS date, 0 AS hour, 100.0 AS open_price, 120.0 AS high_price, 95.0 AS low_price, 110.0 AS close_price, 1.0 AS volume
+			UNION ALL SELECT 1, DATE('2024-03-15'), 1, 110, 115, 90, 100, 2
+			UNION ALL SELECT 1, DATE('2024-03-16'), 0, 100, 101, 99, 101, 4
+			UNION ALL SELECT 1, DATE('2024-03-17'), 0, 1, 1, 1, 1, 1
+			UNION ALL SELECT 2, DATE('2024-03-15'), 0, 10, 12, 8, 11, 3
+		) t
+		WHERE date >= ? AND date < ?
+		ORDER BY coin_id, date, hour
+	`
+	candles, err := loadRollupCandles(context.Background(), db, query, dailyCandles, []interface{}{"2024-03-15", "2024-03-17"})
+	if err != nil {
+		t.Fatalf("loadRollupCandles 에러: %v", err)
+	}
+
+	want := []periodCandle{
+		{CoinID: 1, Start: day("2024-03-15"), Open: 100, High: 120, Low: 90, Close: 100, Volume: 3},
+		{CoinID: 1, Start: day("2024-03-16"), Open: 100, High: 101, Low: 99, Close: 101, Volume: 4},
+		{CoinID: 2, Start: day("2024-03-15"), Open: 10, High: 12, Low: 8, Close: 11, Volume: 3},
+	}
+	if len(candles) != len(want) {
+		t.Fatalf("일봉 %d개, want %d개: %+v", len(candles), len(want), candles)
+	}
+	for i := range want {
+		if !samePeriodCandle(candles[i], want[i]) {
+			t.Errorf("candles[%d] = %+v, want %+v", i, candles[i], want[i])
+		}
+	}
+}
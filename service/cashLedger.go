@@ -0,0 +1,166 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cash_ledger 이벤트 종류
+const (
+	ledgerSplitBonus  = "SPLIT_BONUS"
+	ledgerSeasonReset = "SEASON_RESET"
+	ledgerLiquidation = "LIQUIDATION"
+)
+
+// ensureCashLedgerTable cash_ledger 테이블이 없으면 생성
+// 모든 현금 변경은 (season_id, event_type, event_key, user_id) 유일 키로 한 번만 기록되고,
+// 기록과 같은 트랜잭션에서 applied = 0인 행만 users.cash에 반영하므로 재실행해도 중복 지급되지 않습니다.
+func ensureCashLedgerTable(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS cash_ledger (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			season_id INT NOT NULL,
+			event_type VARCHAR(30) NOT NULL,
+			event_key VARCHAR(50) NOT NULL DEFAULT '',
+			user_id INT NOT NULL,
+			amount BIGINT NOT NULL,
+			applied TINYINT(1) NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uk_cash_ledger_event (season_id, event_type, event_key, user_id),
+			INDEX idx_cash_ledger_user (user_id)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// 아직 반영되지 않은 장부 기록을 users.cash에 반영합니다. 반영한 기록 수를 반환합니다.
+// 한 유저에게 기록이 여러 건일 수 있어 유저별 합계로 한 번에 더합니다. (다중 테이블 UPDATE는 행당 한 번만 갱신)
+func applyLedgerEntries(ctx context.Context, tx execer, seasonID int, eventType string) (int64, error) {
+	updateCashQuery := `
+		UPDATE users u
+		JOIN (
+			SELECT user_id, SUM(amount) AS total
+			FROM cash_ledger
+			WHERE season_id = ? AND event_type = ? AND applied = 0
+			GROUP BY user_id
+		) l ON l.user_id = u.id
+		SET u.cash = u.cash + l.total
+	`
+	if _, err := tx.ExecContext(ctx, updateCashQuery, seasonID, eventType); err != nil {
+		return 0, fmt.Errorf("현금 반영 실패: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE cash_ledger SET applied = 1 WHERE season_id = ? AND event_type = ? AND applied = 0`,
+		seasonID, eventType)
+	if err != nil {
+		return 0, fmt.Errorf("장부 반영 표시 실패: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// 탈퇴하지 않은 모든 유저에게 시즌 경제 설정에 따른 스플릿 지급 기록을 남기고 반영합니다.
+// eventKey는 스플릿 일정 시각이므로 같은 일정으로 다시 실행하면 새로 지급되는 유저가 없습니다.
+// 시즌 스플릿 횟수를 이미 채웠다면 지급하지 않습니다.
+func creditSplitBonus(ctx context.Context, tx *sql.Tx, economy model.SeasonEconomy, eventKey string) (int64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	if economy.SplitCount > 0 {
+		var paid int
+		countQuery := `
+			SELECT COUNT(DISTINCT event_key)
+			FROM cash_ledger
+			WHERE season_id = ? AND event_type = ? AND event_key <> ?
+		`
+		if err := tx.QueryRowContext(queryCtx, countQuery, economy.SeasonID, ledgerSplitBonus, eventKey).Scan(&paid); err != nil {
+			return 0, fmt.Errorf("스플릿 지급 횟수 조회 실패: %w", err)
+		}
+		if paid >= economy.SplitCount {
+			log.Printf("시즌 %d 스플릿 지급 횟수(%d회)를 모두 채워 지급 생략\n", economy.SeasonID, economy.SplitCount)
+			return 0, nil
+		}
+	}
+
+	// 티어별 지급액 (배율이 없는 티어는 기본 지급액)
+	amountExpr := "?"
+	args := []interface{}{economy.SeasonID, ledgerSplitBonus, eventKey}
+	if len(economy.TierMultipliers) > 0 {
+		cases := make([]string, 0, len(economy.TierMultipliers))
+		for tier := range economy.TierMultipliers {
+			cases = append(cases, "WHEN ? THEN ?")
+			args = append(args, tier, economy.SplitAmount(tier))
+		}
+		amountExpr = fmt.Sprintf("CASE tier %s ELSE ? END", strings.Join(cases, " "))
+	}
+	args = append(args, economy.SplitBonus)
+
+	insertQuery := fmt.Sprintf(`
+		INSERT IGNORE INTO cash_ledger (season_id, event_type, event_key, user_id, amount)
+		SELECT ?, ?, ?, id, %s
+		FROM users
+		WHERE is_deleted = 0
+	`, amountExpr)
+	if _, err := tx.ExecContext(queryCtx, insertQuery, args...); err != nil {
+		return 0, fmt.Errorf("스플릿 장부 기록 실패: %w", err)
+	}
+
+	return applyLedgerEntries(queryCtx, tx, economy.SeasonID, ledgerSplitBonus)
+}
+
+// 시즌 종료 시 유저 현금을 시작 금액으로 맞추는 차액을 기록하고 반영합니다.
+func recordSeasonReset(ctx context.Context, tx execer, seasonID int, startingCash int64) (int64, error) {
+	insertQuery := `
+		INSERT IGNORE INTO cash_ledger (season_id, event_type, event_key, user_id, amount)
+		SELECT ?, ?, '', id, ? - cash
+		FROM users
+		WHERE is_deleted = 0
+	`
+	if _, err := tx.ExecContext(ctx, insertQuery, seasonID, ledgerSeasonReset, startingCash); err != nil {
+		return 0, fmt.Errorf("시즌 초기화 장부 기록 실패: %w", err)
+	}
+
+	return applyLedgerEntries(ctx, tx, seasonID, ledgerSeasonReset)
+}
+
+// 청산 주문의 매도 대금을 자산 id 단위로 기록하고 반영합니다.
+func recordLiquidationProceeds(ctx context.Context, tx execer, seasonID int, orders []model.LiquidationOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	valueStrings := make([]string, 0, len(orders))
+	valueArgs := make([]interface{}, 0, len(orders)*5)
+	for _, order := range orders {
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?)")
+		valueArgs = append(valueArgs,
+			seasonID,
+			ledgerLiquidation,
+			strconv.FormatInt(order.AssetID, 10),
+			order.UserID,
+			int64(math.Round(order.Amount*order.TradePrice)))
+	}
+
+	insertQuery := fmt.Sprintf(`
+		INSERT IGNORE INTO cash_ledger (season_id, event_type, event_key, user_id, amount)
+		VALUES %s`, strings.Join(valueStrings, ","))
+	if _, err := tx.ExecContext(ctx, insertQuery, valueArgs...); err != nil {
+		return fmt.Errorf("청산 대금 장부 기록 실패: %w", err)
+	}
+
+	_, err := applyLedgerEntries(ctx, tx, seasonID, ledgerLiquidation)
+	return err
+}
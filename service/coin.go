@@ -1,53 +1,31 @@
 package service
 
 import (
+	"Bitground-go/exchange"
 	"Bitground-go/model"
 	"Bitground-go/util"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"golang.org/x/sync/errgroup"
-	"io"
 	"log"
-	"net/http"
 	"time"
 )
 
-func UpdateCoins(ctx context.Context, db *sql.DB) error {
-	// 고루틴 사용
-	var coinList []model.UpbitCoinList
-	var coinDetailList map[string]model.UpbitCoinPrice
-
-	g, gCtx := errgroup.WithContext(ctx)
-	// 1. API에서 코인 정보를 가져와 "KRW-"로 시작하는 코인 심볼을 필터링 (비동기)
-	g.Go(func() error {
-		symbols, err := getSymbolList(gCtx) // gCtx를 사용
-		if err != nil {
-			return fmt.Errorf("getSymbolList 에러: %w", err)
-		}
-		coinList = symbols // 결과 저장
-		return nil
-	})
+// UpdateCoins 거래소 마켓 목록과 시세로 coins 테이블을 갱신합니다.
+// tickers는 Main에서 한 번만 조회하여 랭킹 업데이트와 공유합니다.
+func UpdateCoins(ctx context.Context, db *sql.DB, source exchange.MarketDataSource, tickers []model.UpbitCoinPrice) error {
+	// 1. API에서 코인 정보를 가져와 "KRW-"로 시작하는 코인 심볼을 필터링
+	coinList, err := getSymbolList(ctx, source)
+	if err != nil {
+		return fmt.Errorf("getSymbolList 에러: %w", err)
+	}
 
-	// 2. 심볼에 해당하는 코인 정보들 가져오기
-	// 3. DB에서 코인 심볼 목록을 조회하여 is_deleted 업데이트
-	g.Go(func() error {
-		details, err := getCoinDetails(gCtx) // gCtx를 사용
-		if err != nil {
-			return fmt.Errorf("getCoinDetails 에러: %w", err)
-		}
-		// getCoinDetails가 성공적으로 완료된 후에 updateDeletedVal을 호출합니다.
-		if err := updateDeletedVal(gCtx, db, details); err != nil { // gCtx를 사용
-			return fmt.Errorf("updateDeletedVal 에러: %w", err)
-		}
-		coinDetailList = details // 결과 저장
-		return nil
-	})
+	// 2. 심볼에 해당하는 코인 정보들 정리
+	coinDetailList := getCoinDetails(tickers)
 
-	if err := g.Wait(); err != nil {
-		// 에러가 발생하면 여기서 처리하고 함수를 종료합니다.
-		return fmt.Errorf("고루틴 수행 중 에러 발생: %w", err)
+	// 3. DB에서 코인 심볼 목록을 조회하여 is_deleted 업데이트
+	if err := updateDeletedVal(ctx, db, coinDetailList); err != nil {
+		return fmt.Errorf("updateDeletedVal 에러: %w", err)
 	}
 
 	// 4. 데이터들을 가공하여 db에 저장할 수 있는 형태로 변환
@@ -62,36 +40,10 @@ func UpdateCoins(ctx context.Context, db *sql.DB) error {
 }
 
 // api에서 코인 전체 정보를 가져와 "KRW-" 로 시작하는 코인 심볼을 필터링합니다.
-func getSymbolList(ctx context.Context) ([]model.UpbitCoinList, error) {
-	apiURL := "https://api.upbit.com/v1/market/all?is_details=true"
-
-	// Context를 활용한 HTTP 요청 (타임아웃: 10초)
-	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(reqCtx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("HTTP 요청 생성 에러: %w", err)
-	}
-
-	// API 요청 보내기
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API 요청 에러: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		// 응답 본문을 닫아 리소스 누수 방지
-		if err := Body.Close(); err != nil {
-			log.Printf("응답 본문 닫기 에러: %v\n", err)
-		}
-	}(resp.Body)
-
-	// JSON 응답을 Coin 슬라이스로 디코딩
-	var coins []model.UpbitCoinList
-	err = json.NewDecoder(resp.Body).Decode(&coins)
+func getSymbolList(ctx context.Context, source exchange.MarketDataSource) ([]model.UpbitCoinList, error) {
+	coins, err := source.ListMarkets(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("JSON 디코딩 에러: %w", err)
+		return nil, err
 	}
 
 	// "KRW-"로 시작하는 코인 심볼 필터링
@@ -103,48 +55,16 @@ func getSymbolList(ctx context.Context) ([]model.UpbitCoinList, error) {
 	}
 
 	return filteredCoins, nil
-
 }
 
-// api에서 코인별 정보를 가져와 정보를 구체화합니다.
-func getCoinDetails(ctx context.Context) (map[string]model.UpbitCoinPrice, error) {
-	apiURL := "https://api.upbit.com/v1/ticker/all?quote_currencies=KRW"
-
-	// Context를 활용한 HTTP 요청 (타임아웃: 10초)
-	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(reqCtx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("HTTP 요청 생성 에러: %w", err)
-	}
-
-	// API 요청 보내기
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API 요청 에러: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		if err := Body.Close(); err != nil {
-			log.Printf("응답 본문 닫기 에러: %v\n", err)
-		}
-	}(resp.Body)
-
-	// 응답 본문 읽기
-	var coinDetails []model.UpbitCoinPrice
-	err = json.NewDecoder(resp.Body).Decode(&coinDetails)
-	if err != nil {
-		return nil, fmt.Errorf("JSON 디코딩 에러: %w", err)
-	}
-
-	// 코인 심볼을 키로 하는 맵 생성
+// 코인별 시세 정보를 마켓 심볼을 키로 하는 맵으로 정리합니다.
+func getCoinDetails(tickers []model.UpbitCoinPrice) map[string]model.UpbitCoinPrice {
 	coinMap := make(map[string]model.UpbitCoinPrice)
-	for _, coin := range coinDetails {
+	for _, coin := range tickers {
 		coinMap[coin.Market] = coin
 	}
 
-	return coinMap, nil
+	return coinMap
 }
 
 // 데이터들을 가공하여 db에 저장할 수 있는 형태로 변환합니다.
@@ -323,5 +243,9 @@ func insertCoinSymbols(ctx context.Context, db *sql.DB, coinSymbols []model.Coin
 		}
 	}
 
-	return tx.Commit() // 트랜잭션 커밋
+	if err = tx.Commit(); err != nil { // 트랜잭션 커밋
+		return err
+	}
+	util.AddRows(ctx, int64(len(coinSymbols)))
+	return nil
 }
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"Bitground-go/config"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// openTestDB TEST_DB_HOST, TEST_DB_USER, TEST_DB_PASSWORD, TEST_DB_NAME으로 지정한 테스트용 MySQL에 연결합니다.
+// TEST_DB_HOST가 없으면 테스트를 건너뜁니다. 테스트가 테이블을 만들고 지우므로 운영 DB를 지정하면 안 됩니다.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST가 없어 DB 테스트를 건너뜀")
+	}
+	db, err := config.ConnectDB(context.Background(), config.DBConfig{
+		Host:     host,
+		User:     os.Getenv("TEST_DB_USER"),
+		Password: os.Getenv("TEST_DB_PASSWORD"),
+		DBName:   os.Getenv("TEST_DB_NAME"),
+		Charset:  "utf8mb4",
+	})
+	if err != nil {
+		t.Fatalf("테스트 DB 연결 실패: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("테스트 DB 종료 실패: %v", err)
+		}
+	})
+	return db
+}
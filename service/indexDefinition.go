@@ -0,0 +1,194 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// 인덱스 코드 (market_index_definitions.code, market_index_levels.code)
+// 알트 인덱스는 마켓 인덱스 정의에서 1위 종목을 뺀 구성으로 계산하므로 별도 정의가 없습니다.
+const (
+	marketIndexCode = "MARKET"
+	altIndexCode    = "ALT"
+)
+
+// 정의가 없을 때 사용하는 기본 마켓 인덱스 정의 (버전 1)
+// 상위 10개 종목 시가총액 합 / 1억으로, 정의를 DB로 옮기기 전의 산출 방식과 같습니다.
+func defaultMarketIndexDefinition() model.IndexDefinition {
+	return model.IndexDefinition{
+		Code:             marketIndexCode,
+		Version:          1,
+		ConstituentCount: 10,
+		Excluded: []string{
+			"usdt", "bnb", "usdc", "steth", "wbtc", "wsteth", "leo", "usds", "weth", "weeth", "bsc-usd",
+			"bgb", "usde", "cbbtc", "wbt", "okb", "jitosol", "susde", "tkx", "buidl", "ondo", "cro",
+		},
+		Weighting: model.WeightingCap,
+		Divisor:   100000000,
+	}
+}
+
+// ensureIndexDefinitionTable market_index_definitions 테이블이 없으면 생성
+// 코드별로 is_active = 1인 가장 높은 버전을 사용합니다. 정의를 바꿀 때는 기존 행을 수정하지 말고 새 버전을 추가합니다.
+func ensureIndexDefinitionTable(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS market_index_definitions (
+			code VARCHAR(30) NOT NULL,
+			version INT NOT NULL,
+			constituent_count INT NOT NULL,
+			excluded_symbols JSON NULL,
+			weighting VARCHAR(10) NOT NULL DEFAULT 'cap',
+			max_weight DOUBLE NOT NULL DEFAULT 0,
+			divisor DOUBLE NOT NULL,
+			is_active TINYINT(1) NOT NULL DEFAULT 1,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (code, version)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// ensureIndexDefinitionColumns 기준값/기준일 컬럼이 없으면 추가합니다.
+func ensureIndexDefinitionColumns(ctx context.Context, db *sql.DB) error {
+	if err := ensureColumn(ctx, db, "market_index_definitions", "base_value", "DOUBLE NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	return ensureColumn(ctx, db, "market_index_definitions", "base_date", "DATE NULL")
+}
+
+// loadIndexDefinition 코드의 활성 인덱스 정의 중 가장 높은 버전을 조회합니다.
+// 정의가 하나도 없으면 fallback을 저장한 뒤 반환합니다.
+func loadIndexDefinition(ctx context.Context, db *sql.DB, code string, fallback model.IndexDefinition) (model.IndexDefinition, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT version, constituent_count, excluded_symbols, weighting, max_weight, divisor, base_value
+		FROM market_index_definitions
+		WHERE code = ? AND is_active = 1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	def, err := scanIndexDefinition(db.QueryRowContext(queryCtx, query, code), code)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := saveIndexDefinition(queryCtx, db, fallback); err != nil {
+			return fallback, err
+		}
+		log.Printf("%s 인덱스 정의가 없어 기본 정의(버전 %d) 저장\n", code, fallback.Version)
+		return fallback, nil
+	}
+	return def, err
+}
+
+// loadIndexDefinitionVersion 활성 여부와 관계없이 code 인덱스의 특정 버전 정의를 조회합니다. (과거 기록 재계산용)
+func loadIndexDefinitionVersion(ctx context.Context, db *sql.DB, code string, version int) (model.IndexDefinition, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT version, constituent_count, excluded_symbols, weighting, max_weight, divisor, base_value
+		FROM market_index_definitions
+		WHERE code = ? AND version = ?
+	`
+
+	def, err := scanIndexDefinition(db.QueryRowContext(queryCtx, query, code, version), code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return def, fmt.Errorf("%s 인덱스 버전 %d 정의가 없습니다", code, version)
+	}
+	return def, err
+}
+
+// 인덱스 정의 한 행을 읽고 검증합니다. 행이 없으면 sql.ErrNoRows를 그대로 반환합니다.
+func scanIndexDefinition(row *sql.Row, code string) (model.IndexDefinition, error) {
+	def := model.IndexDefinition{Code: code}
+	var excluded sql.NullString
+	err := row.Scan(&def.Version, &def.ConstituentCount, &excluded, &def.Weighting, &def.MaxWeight, &def.Divisor, &def.BaseValue)
+	if errors.Is(err, sql.ErrNoRows) {
+		return def, err
+	}
+	if err != nil {
+		return def, fmt.Errorf("인덱스 정의 조회 실패: %w", err)
+	}
+
+	if excluded.Valid && excluded.String != "" {
+		if err := json.Unmarshal([]byte(excluded.String), &def.Excluded); err != nil {
+			return def, fmt.Errorf("%s 인덱스 버전 %d 제외 목록 파싱 실패: %w", code, def.Version, err)
+		}
+	}
+	if err := validateIndexDefinition(def); err != nil {
+		return def, fmt.Errorf("%s 인덱스 버전 %d 정의 오류: %w", code, def.Version, err)
+	}
+
+	return def, nil
+}
+
+// 인덱스 정의 저장 (같은 코드/버전이 이미 있으면 무시)
+func saveIndexDefinition(ctx context.Context, db execer, def model.IndexDefinition) error {
+	excluded, err := json.Marshal(def.Excluded)
+	if err != nil {
+		return err
+	}
+
+	insertQuery := `
+		INSERT IGNORE INTO market_index_definitions
+			(code, version, constituent_count, excluded_symbols, weighting, max_weight, divisor, base_value)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := db.ExecContext(ctx, insertQuery, def.Code, def.Version, def.ConstituentCount,
+		string(excluded), def.Weighting, def.MaxWeight, def.Divisor, def.BaseValue); err != nil {
+		return fmt.Errorf("인덱스 정의 저장 실패: %w", err)
+	}
+	return nil
+}
+
+// 인덱스 첫 기록 날짜를 정의의 기준일로 기록합니다. (이미 기준일이 있으면 유지)
+func markIndexBaseDate(ctx context.Context, db execer, code string, version int, date string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	updateQuery := `UPDATE market_index_definitions SET base_date = ? WHERE code = ? AND version = ? AND base_date IS NULL`
+	if _, err := db.ExecContext(queryCtx, updateQuery, date, code, version); err != nil {
+		return fmt.Errorf("%s 인덱스 기준일 기록 실패: %w", code, err)
+	}
+	return nil
+}
+
+func validateIndexDefinition(def model.IndexDefinition) error {
+	var problems []string
+	if def.ConstituentCount <= 0 {
+		problems = append(problems, "편입 종목 수는 1 이상이어야 함")
+	}
+	if def.Divisor <= 0 {
+		problems = append(problems, "divisor는 0보다 커야 함")
+	}
+	if def.BaseValue < 0 {
+		problems = append(problems, "base_value는 0 이상이어야 함")
+	}
+	switch def.Weighting {
+	case model.WeightingCap, model.WeightingEqual:
+	case model.WeightingCapped:
+		if def.MaxWeight <= 0 || def.MaxWeight > 1 {
+			problems = append(problems, "max_weight는 0 초과 1 이하여야 함")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("지원하지 않는 가중 방식 '%s'", def.Weighting))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// indexLevel 한 시간의 인덱스 값과 산출 근거
+type indexLevel struct {
+	Code         string
+	Version      int     // 정의 버전
+	MarketValue  float64 // 가중 시가총액 (divisor 적용 전)
+	Divisor      float64
+	Value        float64 // MarketValue / Divisor
+	Constituents []model.GeckoCoin
+	Weights      []float64
+}
+
+// prevIndexLevel 직전 시간의 인덱스 기록
+type prevIndexLevel struct {
+	Version      int
+	Definition   model.IndexDefinition // Version의 정의 (divisor 조정 시 직전 구성 종목 재평가에 사용)
+	Value        float64
+	Divisor      float64
+	Constituents []string
+}
+
+// ensureIndexLevelTables market_index_levels, market_index_constituents 테이블이 없으면 생성
+// 인덱스 코드별로 시간마다 값과 divisor, 구성 종목을 기록합니다.
+func ensureIndexLevelTables(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	levelQuery := `
+		CREATE TABLE IF NOT EXISTS market_index_levels (
+			code VARCHAR(30) NOT NULL,
+			date DATE NOT NULL,
+			hour INT NOT NULL,
+			definition_version INT NOT NULL,
+			market_value DOUBLE NOT NULL,
+			divisor DOUBLE NOT NULL,
+			value DOUBLE NOT NULL,
+			PRIMARY KEY (code, date, hour)
+		)
+	`
+	if _, err := db.ExecContext(queryCtx, levelQuery); err != nil {
+		return err
+	}
+
+	constituentQuery := `
+		CREATE TABLE IF NOT EXISTS market_index_constituents (
+			code VARCHAR(30) NOT NULL,
+			date DATE NOT NULL,
+			hour INT NOT NULL,
+			symbol VARCHAR(30) NOT NULL,
+			market_cap BIGINT NOT NULL,
+			weight DOUBLE NOT NULL,
+			PRIMARY KEY (code, date, hour, symbol)
+		)
+	`
+	_, err := db.ExecContext(queryCtx, constituentQuery)
+	return err
+}
+
+// indexTarget 계산할 인덱스 정의와 구성 종목
+type indexTarget struct {
+	def          model.IndexDefinition
+	constituents []model.GeckoCoin
+}
+
+// computeIndexLevels 인덱스별로 직전 기록을 조회하여 이번 시간의 값을 계산합니다.
+// universe는 이번 시간에 조회한 전체 종목 시가총액으로, 구성 변경 시 divisor 조정에 사용합니다.
+func computeIndexLevels(ctx context.Context, db *sql.DB, date string, hour int, targets []indexTarget, universe []model.GeckoCoin) ([]indexLevel, error) {
+	levels := make([]indexLevel, 0, len(targets))
+	for _, target := range targets {
+		prev, err := loadPrevIndexLevel(ctx, db, target.def.Code, date, hour)
+		if err != nil {
+			return nil, err
+		}
+		if prev == nil {
+			if err := markIndexBaseDate(ctx, db, target.def.Code, target.def.Version, date); err != nil {
+				return nil, err
+			}
+		} else if prev.Version == target.def.Version {
+			prev.Definition = target.def
+		} else if prev.Definition, err = loadIndexDefinitionVersion(ctx, db, indexDefinitionCode(target.def.Code), prev.Version); err != nil {
+			return nil, err
+		}
+		levels = append(levels, computeIndexLevel(target.def, target.constituents, universe, prev))
+	}
+	return levels, nil
+}
+
+// 인덱스 코드의 정의가 저장된 코드 (알트 인덱스는 마켓 인덱스 정의를 사용)
+func indexDefinitionCode(code string) string {
+	if code == altIndexCode {
+		return marketIndexCode
+	}
+	return code
+}
+
+// 인덱스를 기록할 날짜와 시간 (정시 기준 반올림)
+func indexHour(now time.Time) (string, int) {
+	now = now.Round(time.Hour)
+	return now.Format("2006-01-02"), now.Hour()
+}
+
+// loadPrevIndexLevel date/hour 이전의 가장 최근 인덱스 기록과 그 시간의 구성 종목을 조회합니다.
+func loadPrevIndexLevel(ctx context.Context, db *sql.DB, code, date string, hour int) (*prevIndexLevel, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT date, hour, definition_version, value, divisor
+		FROM market_index_levels
+		WHERE code = ? AND (date < ? OR (date = ? AND hour < ?))
+		ORDER BY date DESC, hour DESC
+		LIMIT 1
+	`
+
+	var prev prevIndexLevel
+	var prevDate time.Time
+	var prevHour int
+	err := db.QueryRowContext(queryCtx, query, code, date, date, hour).
+		Scan(&prevDate, &prevHour, &prev.Version, &prev.Value, &prev.Divisor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s 직전 인덱스 조회 실패: %w", code, err)
+	}
+
+	rows, err := db.QueryContext(queryCtx,
+		`SELECT symbol FROM market_index_constituents WHERE code = ? AND date = ? AND hour = ?`,
+		code, prevDate.Format("2006-01-02"), prevHour)
+	if err != nil {
+		return nil, fmt.Errorf("%s 직전 구성 종목 조회 실패: %w", code, err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		prev.Constituents = append(prev.Constituents, symbol)
+	}
+
+	return &prev, rows.Err()
+}
+
+// computeIndexLevel 구성 종목의 가중 시가총액을 divisor로 나눠 인덱스 값을 계산합니다.
+//   - 첫 기록: 정의의 base_value가 있으면 그 값에서, 없으면 가중 시가총액 / 정의 divisor에서 시작합니다.
+//   - 구성 종목과 정의 버전이 그대로면 직전 divisor를 그대로 사용합니다.
+//   - 바뀌었으면 직전 구성 종목의 현재 시가총액을 직전 정의(prev.Definition)로 평가한 값과 같아지도록 divisor를 조정해
+//     값이 끊기지 않게 합니다. (빠진 종목의 현재 시가총액을 알 수 없으면 직전 값에 맞춥니다)
+func computeIndexLevel(def model.IndexDefinition, constituents, universe []model.GeckoCoin, prev *prevIndexLevel) indexLevel {
+	level := indexLevel{
+		Code:         def.Code,
+		Version:      def.Version,
+		MarketValue:  indexMarketValue(def, constituents),
+		Constituents: constituents,
+		Weights:      indexWeights(def, constituents),
+	}
+
+	switch {
+	case prev == nil:
+		level.Divisor = def.Divisor
+		if def.BaseValue > 0 {
+			level.Divisor = level.MarketValue / def.BaseValue
+		}
+	case prev.Version == def.Version && sameSymbols(prev.Constituents, constituents):
+		level.Divisor = prev.Divisor
+	default:
+		continuous := prev.Value
+		if old, ok := lookupConstituents(prev.Constituents, universe); ok {
+			continuous = indexMarketValue(prev.Definition, old) / prev.Divisor
+		}
+		level.Divisor = level.MarketValue / continuous
+		log.Printf("%s 인덱스 구성 변경 (정의 버전 %d → %d), divisor %.4f → %.4f\n",
+			def.Code, prev.Version, def.Version, prev.Divisor, level.Divisor)
+	}
+
+	level.Value = level.MarketValue / level.Divisor
+	return level
+}
+
+// 구성 종목 심볼 목록이 같은지 비교합니다. (순서 무관)
+func sameSymbols(symbols []string, coins []model.GeckoCoin) bool {
+	if len(symbols) != len(coins) {
+		return false
+	}
+	current := make([]string, len(coins))
+	for i, coin := range coins {
+		current[i] = strings.ToLower(coin.Symbol)
+	}
+	previous := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		previous[i] = strings.ToLower(symbol)
+	}
+	sort.Strings(current)
+	sort.Strings(previous)
+	return strings.Join(current, ",") == strings.Join(previous, ",")
+}
+
+// 심볼 목록의 현재 시가총액을 universe에서 찾습니다. 하나라도 없으면 ok는 false입니다.
+func lookupConstituents(symbols []string, universe []model.GeckoCoin) ([]model.GeckoCoin, bool) {
+	bySymbol := geckoCoinsBySymbol(universe)
+
+	coins := make([]model.GeckoCoin, 0, len(symbols))
+	for _, symbol := range symbols {
+		coin, ok := bySymbol[strings.ToLower(symbol)]
+		if !ok || coin.MarketCap <= 0 {
+			return nil, false
+		}
+		coins = append(coins, coin)
+	}
+	return coins, len(coins) > 0
+}
+
+// saveIndexLevels 인덱스 값과 구성 종목을 한 트랜잭션으로 저장합니다. 같은 시간에 다시 실행하면 덮어씁니다.
+func saveIndexLevels(ctx context.Context, db *sql.DB, date string, hour int, levels []indexLevel) (err error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 에러: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	levelQuery := `
+		INSERT INTO market_index_levels (code, date, hour, definition_version, market_value, divisor, value)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			definition_version = VALUES(definition_version),
+			market_value = VALUES(market_value),
+			divisor = VALUES(divisor),
+			value = VALUES(value)
+	`
+	for _, level := range levels {
+		if _, err = tx.ExecContext(queryCtx, levelQuery, level.Code, date, hour,
+			level.Version, level.MarketValue, level.Divisor, level.Value); err != nil {
+			return fmt.Errorf("%s 인덱스 값 저장 에러: %w", level.Code, err)
+		}
+
+		if _, err = tx.ExecContext(queryCtx,
+			`DELETE FROM market_index_constituents WHERE code = ? AND date = ? AND hour = ?`,
+			level.Code, date, hour); err != nil {
+			return fmt.Errorf("%s 기존 구성 종목 삭제 에러: %w", level.Code, err)
+		}
+
+		valueStrings := make([]string, 0, len(level.Constituents))
+		valueArgs := make([]interface{}, 0, len(level.Constituents)*6)
+		for i, coin := range level.Constituents {
+			valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?)")
+			valueArgs = append(valueArgs, level.Code, date, hour, strings.ToLower(coin.Symbol), coin.MarketCap, level.Weights[i])
+		}
+		if len(valueStrings) == 0 {
+			continue
+		}
+		insertQuery := fmt.Sprintf(`
+			INSERT INTO market_index_constituents (code, date, hour, symbol, market_cap, weight)
+			VALUES %s`, strings.Join(valueStrings, ","))
+		if _, err = tx.ExecContext(queryCtx, insertQuery, valueArgs...); err != nil {
+			return fmt.Errorf("%s 구성 종목 저장 에러: %w", level.Code, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	util.AddRows(ctx, int64(len(levels)))
+	return nil
+}
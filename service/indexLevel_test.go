@@ -0,0 +1,90 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"testing"
+)
+
+func TestComputeIndexLevelFirstRecord(t *testing.T) {
+	coins := testCoins(600, 300, 100)
+
+	def := model.IndexDefinition{Code: "TEST", Version: 1, Weighting: model.WeightingCap, Divisor: 10}
+	if level := computeIndexLevel(def, coins, coins, nil); level.Divisor != 10 || level.Value != 100 {
+		t.Errorf("정의 divisor로 시작: divisor %v, value %v", level.Divisor, level.Value)
+	}
+
+	def.BaseValue = 1000
+	if level := computeIndexLevel(def, coins, coins, nil); !almostEqual(level.Value, 1000) || !almostEqual(level.Divisor, 1) {
+		t.Errorf("base_value에서 시작: divisor %v, value %v", level.Divisor, level.Value)
+	}
+}
+
+func TestComputeIndexLevelUnchanged(t *testing.T) {
+	def := model.IndexDefinition{Code: "TEST", Version: 1, Weighting: model.WeightingCap}
+	prev := &prevIndexLevel{Version: 1, Definition: def, Value: 100, Divisor: 10, Constituents: []string{"ETH", "btc", "sol"}}
+
+	// 구성 종목이 같으면 (순서/대소문자 무관) divisor를 유지하고 시가총액 변화를 그대로 반영
+	coins := testCoins(1200, 600, 200)
+	level := computeIndexLevel(def, coins, coins, prev)
+	if level.Divisor != 10 || level.Value != 200 {
+		t.Errorf("divisor %v, value %v, want 10, 200", level.Divisor, level.Value)
+	}
+}
+
+func TestComputeIndexLevelConstituentChange(t *testing.T) {
+	def := model.IndexDefinition{Code: "TEST", Version: 1, Weighting: model.WeightingCap}
+	universe := testCoins(600, 300, 100, 400)
+	// 직전 구성: btc, eth, sol (현재 시가총액 1000, divisor 10 → 연속 값 100)
+	prev := &prevIndexLevel{Version: 1, Definition: def, Value: 90, Divisor: 10, Constituents: []string{"btc", "eth", "sol"}}
+
+	// sol이 빠지고 xrp 편입
+	current := []model.GeckoCoin{universe[0], universe[1], universe[3]}
+	level := computeIndexLevel(def, current, universe, prev)
+	if !almostEqual(level.Value, 100) {
+		t.Errorf("구성 변경 전후 값이 이어져야 함: %v, want 100", level.Value)
+	}
+	if !almostEqual(level.Divisor, 13) {
+		t.Errorf("divisor = %v, want 13", level.Divisor)
+	}
+
+	// 빠진 종목의 현재 시가총액을 모르면 직전 값에 맞춤
+	level = computeIndexLevel(def, current, current, prev)
+	if !almostEqual(level.Value, 90) {
+		t.Errorf("직전 값에 맞춰야 함: %v, want 90", level.Value)
+	}
+}
+
+func TestComputeIndexLevelDefinitionChange(t *testing.T) {
+	oldDef := model.IndexDefinition{Code: "TEST", Version: 1, Weighting: model.WeightingCap}
+	newDef := model.IndexDefinition{Code: "TEST", Version: 2, Weighting: model.WeightingEqual}
+	coins := testCoins(600, 300, 100)
+	prev := &prevIndexLevel{Version: 1, Definition: oldDef, Value: 100, Divisor: 10, Constituents: []string{"btc", "eth", "sol"}}
+
+	// 구성 종목이 같아도 정의가 바뀌면 직전 정의(시가총액 합 1000 / 10)로 평가한 값에 이어 붙임
+	level := computeIndexLevel(newDef, coins, coins, prev)
+	if level.Version != 2 {
+		t.Errorf("version = %d, want 2", level.Version)
+	}
+	if !almostEqual(level.Value, 100) {
+		t.Errorf("정의 변경 전후 값이 이어져야 함: %v, want 100", level.Value)
+	}
+	if want := indexMarketValue(newDef, coins) / 100; !almostEqual(level.Divisor, want) {
+		t.Errorf("divisor = %v, want %v", level.Divisor, want)
+	}
+
+	// 직전 정의로 재평가하지 않으면 (새 정의로 평가하면) 값이 이어지지 않음
+	wrong := *prev
+	wrong.Definition = newDef
+	if level := computeIndexLevel(newDef, coins, coins, &wrong); almostEqual(level.Value, 100) {
+		t.Error("새 정의로 재평가하면 값이 달라져야 함 (테스트 전제 확인)")
+	}
+}
+
+func TestIndexDefinitionCode(t *testing.T) {
+	if got := indexDefinitionCode(altIndexCode); got != marketIndexCode {
+		t.Errorf("알트 인덱스 정의 코드 = %s, want %s", got, marketIndexCode)
+	}
+	if got := indexDefinitionCode(sectorIndexCode("DEFI")); got != "SECTOR_DEFI" {
+		t.Errorf("섹터 인덱스 정의 코드 = %s", got)
+	}
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// 인사이트 점수를 검증할 실현 수익률 기간 (시간)
+var insightHorizons = []int{24, 72}
+
+// 점수 구간 크기 (1-20, 21-40, ...)
+const insightScoreBucketSize = 20
+
+// 점수가 이 값보다 크면 상승, 작으면 하락 예측으로 봅니다. (같으면 방향 적중률에서 제외)
+const insightNeutralScore = 50
+
+// insight_accuracy 집계 단위
+const (
+	accuracyGroupAll    = "ALL"
+	accuracyGroupBucket = "BUCKET"
+	accuracyGroupPrompt = "PROMPT"
+	accuracyGroupModel  = "MODEL"
+)
+
+// insightOutcome 인사이트 한 건의 실현 수익률
+type insightOutcome struct {
+	Horizon       int
+	Score         int
+	ReturnRate    float64 // %
+	PromptVersion string
+	Model         string
+}
+
+// insightAccuracy 집계 단위별 정확도 지표
+type insightAccuracy struct {
+	Horizon         int
+	GroupType       string
+	GroupKey        string
+	Samples         int
+	DirectionalHits int
+	DirectionalN    int // 중립 점수를 제외한 표본 수
+	SumReturn       float64
+	scores, returns []float64
+}
+
+// UpdateInsightBacktest 지난 인사이트의 실현 수익률을 계산하고 점수 정확도 지표를 갱신합니다.
+// 인사이트 날짜의 첫 가격 기록을 기준으로 24시간/72시간 뒤 같은 시각의 가격과 비교하며,
+// MARKET_OVERALL은 마켓 인덱스로 비교합니다. 번역본은 점수가 같으므로 원문(ko)만 사용합니다. 아직 기간이 지나지 않았거나 가격 기록이 없는 인사이트는 다음 실행에서 다시 시도합니다.
+func UpdateInsightBacktest(ctx context.Context, db *sql.DB) error {
+	if err := ensureInsightBacktestTables(ctx, db); err != nil {
+		return fmt.Errorf("백테스트 테이블 생성 실패: %w", err)
+	}
+	if err := ensureInsightSchema(ctx, db); err != nil {
+		return err
+	}
+	localeSupported, err := insightLocaleSupported(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// 1. 실현 수익률 기록
+	for _, horizon := range insightHorizons {
+		if err := insertInsightOutcomes(ctx, db, horizon, localeSupported); err != nil {
+			return fmt.Errorf("%d시간 실현 수익률 기록 실패: %w", horizon, err)
+		}
+	}
+
+	// 2. 정확도 지표 재계산
+	outcomes, err := loadInsightOutcomes(ctx, db)
+	if err != nil {
+		return fmt.Errorf("실현 수익률 조회 실패: %w", err)
+	}
+	accuracies := computeInsightAccuracy(outcomes)
+	if err := replaceInsightAccuracy(ctx, db, accuracies); err != nil {
+		return fmt.Errorf("정확도 지표 저장 실패: %w", err)
+	}
+
+	log.Printf("인사이트 백테스트 완료: 표본 %d건, 지표 %d건\n", len(outcomes), len(accuracies))
+	return nil
+}
+
+// ensureInsightBacktestTables insight_outcomes, insight_accuracy 테이블이 없으면 생성
+func ensureInsightBacktestTables(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	outcomeQuery := `
+		CREATE TABLE IF NOT EXISTS insight_outcomes (
+			symbol VARCHAR(30) NOT NULL,
+			date DATE NOT NULL,
+			horizon_hours INT NOT NULL,
+			score INT NOT NULL,
+			prompt_version VARCHAR(20) NULL,
+			model VARCHAR(100) NULL,
+			base_price DOUBLE NOT NULL,
+			realized_price DOUBLE NOT NULL,
+			return_rate DOUBLE NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (symbol, date, horizon_hours)
+		)
+	`
+	if _, err := db.ExecContext(queryCtx, outcomeQuery); err != nil {
+		return err
+	}
+
+	accuracyQuery := `
+		CREATE TABLE IF NOT EXISTS insight_accuracy (
+			horizon_hours INT NOT NULL,
+			group_type VARCHAR(20) NOT NULL,
+			group_key VARCHAR(100) NOT NULL,
+			samples INT NOT NULL,
+			hit_rate DOUBLE NULL,
+			avg_return DOUBLE NOT NULL,
+			rank_correlation DOUBLE NULL,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (horizon_hours, group_type, group_key)
+		)
+	`
+	_, err := db.ExecContext(queryCtx, accuracyQuery)
+	return err
+}
+
+// 기간이 지난 인사이트 중 아직 기록되지 않은 실현 수익률을 기록합니다.
+// 언어별로 저장된 인사이트는 원문(ko)만 사용합니다. (localeSupported가 false면 모든 행이 원문)
+func insertInsightOutcomes(ctx context.Context, db *sql.DB, horizon int, localeSupported bool) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	localeCond := ""
+	if localeSupported {
+		localeCond = fmt.Sprintf("i.locale = '%s' AND ", insightBaseLocale)
+	}
+
+	// 코인별 인사이트: 인사이트 날짜 첫 기록의 종가 → horizon 시간 뒤 같은 시각의 종가
+	coinQuery := fmt.Sprintf(`
+		INSERT IGNORE INTO insight_outcomes
+			(symbol, date, horizon_hours, score, prompt_version, model, base_price, realized_price, return_rate)
+		SELECT i.symbol, i.date, ?, i.score, i.prompt_version, i.model,
+			b.close_price, t.close_price, (t.close_price - b.close_price) / b.close_price * 100
+		FROM ai_insights i
+		JOIN coins c ON c.symbol = i.symbol
+		JOIN coin_price_history b ON b.coin_id = c.id AND b.date = i.date
+			AND b.hour = (SELECT MIN(hour) FROM coin_price_history WHERE coin_id = c.id AND date = i.date)
+		JOIN coin_price_history t ON t.coin_id = c.id
+			AND t.date = DATE(TIMESTAMP(i.date) + INTERVAL (b.hour + ?) HOUR)
+			AND t.hour = HOUR(TIMESTAMP(i.date) + INTERVAL (b.hour + ?) HOUR)
+		LEFT JOIN insight_outcomes o ON o.symbol = i.symbol AND o.date = i.date AND o.horizon_hours = ?
+		WHERE %so.symbol IS NULL AND b.close_price > 0
+	`, localeCond)
+	coinResult, err := db.ExecContext(queryCtx, coinQuery, horizon, horizon, horizon, horizon)
+	if err != nil {
+		return fmt.Errorf("코인 인사이트 쿼리 실행 에러: %w", err)
+	}
+
+	// 시장 전체 인사이트: 마켓 인덱스 기준
+	marketQuery := fmt.Sprintf(`
+		INSERT IGNORE INTO insight_outcomes
+			(symbol, date, horizon_hours, score, prompt_version, model, base_price, realized_price, return_rate)
+		SELECT i.symbol, i.date, ?, i.score, i.prompt_version, i.model,
+			b.market_index, t.market_index, (t.market_index - b.market_index) / b.market_index * 100
+		FROM ai_insights i
+		JOIN market_indices b ON b.date = i.date
+			AND b.hour = (SELECT MIN(hour) FROM market_indices WHERE date = i.date)
+		JOIN market_indices t
+			ON t.date = DATE(TIMESTAMP(i.date) + INTERVAL (b.hour + ?) HOUR)
+			AND t.hour = HOUR(TIMESTAMP(i.date) + INTERVAL (b.hour + ?) HOUR)
+		LEFT JOIN insight_outcomes o ON o.symbol = i.symbol AND o.date = i.date AND o.horizon_hours = ?
+		WHERE i.symbol = ? AND %so.symbol IS NULL AND b.market_index > 0
+	`, localeCond)
+	marketResult, err := db.ExecContext(queryCtx, marketQuery, horizon, horizon, horizon, horizon, insightMarketSymbol)
+	if err != nil {
+		return fmt.Errorf("시장 인사이트 쿼리 실행 에러: %w", err)
+	}
+
+	for _, result := range []sql.Result{coinResult, marketResult} {
+		if affected, err := result.RowsAffected(); err == nil {
+			util.AddRows(ctx, affected)
+		}
+	}
+	return nil
+}
+
+// 기록된 실현 수익률 전체 조회
+func loadInsightOutcomes(ctx context.Context, db *sql.DB) ([]insightOutcome, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT horizon_hours, score, return_rate, COALESCE(prompt_version, ''), COALESCE(model, '')
+		FROM insight_outcomes
+	`
+
+	rows, err := db.QueryContext(queryCtx, query)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	var outcomes []insightOutcome
+	for rows.Next() {
+		var o insightOutcome
+		if err := rows.Scan(&o.Horizon, &o.Score, &o.ReturnRate, &o.PromptVersion, &o.Model); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		outcomes = append(outcomes, o)
+	}
+
+	return outcomes, rows.Err()
+}
+
+// 기간별로 전체, 점수 구간, 프롬프트 버전, 모델 단위 정확도를 집계합니다.
+func computeInsightAccuracy(outcomes []insightOutcome) []*insightAccuracy {
+	var result []*insightAccuracy
+	groups := make(map[string]*insightAccuracy)
+
+	add := func(o insightOutcome, groupType, groupKey string) {
+		key := fmt.Sprintf("%d|%s|%s", o.Horizon, groupType, groupKey)
+		acc, ok := groups[key]
+		if !ok {
+			acc = &insightAccuracy{Horizon: o.Horizon, GroupType: groupType, GroupKey: groupKey}
+			groups[key] = acc
+			result = append(result, acc)
+		}
+
+		acc.Samples++
+		acc.SumReturn += o.ReturnRate
+		acc.scores = append(acc.scores, float64(o.Score))
+		acc.returns = append(acc.returns, o.ReturnRate)
+		if o.Score != insightNeutralScore {
+			acc.DirectionalN++
+			if (o.Score > insightNeutralScore) == (o.ReturnRate > 0) {
+				acc.DirectionalHits++
+			}
+		}
+	}
+
+	for _, o := range outcomes {
+		add(o, accuracyGroupAll, "")
+		add(o, accuracyGroupBucket, scoreBucket(o.Score))
+		add(o, accuracyGroupPrompt, o.PromptVersion)
+		add(o, accuracyGroupModel, o.Model)
+	}
+
+	return result
+}
+
+// 점수 구간 이름 (예: 41-60)
+func scoreBucket(score int) string {
+	start := (score-1)/insightScoreBucketSize*insightScoreBucketSize + 1
+	return fmt.Sprintf("%d-%d", start, start+insightScoreBucketSize-1)
+}
+
+// 정확도 지표를 새로 계산한 값으로 교체합니다.
+func replaceInsightAccuracy(ctx context.Context, db *sql.DB, accuracies []*insightAccuracy) (err error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 에러: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(queryCtx, `DELETE FROM insight_accuracy`); err != nil {
+		return fmt.Errorf("기존 지표 삭제 에러: %w", err)
+	}
+
+	if len(accuracies) > 0 {
+		valueStrings := make([]string, 0, len(accuracies))
+		valueArgs := make([]interface{}, 0, len(accuracies)*7)
+		for _, acc := range accuracies {
+			var hitRate, correlation sql.NullFloat64
+			if acc.DirectionalN > 0 {
+				hitRate = sql.NullFloat64{Float64: float64(acc.DirectionalHits) / float64(acc.DirectionalN), Valid: true}
+			}
+			if c := util.SpearmanCorrelation(acc.scores, acc.returns); !math.IsNaN(c) {
+				correlation = sql.NullFloat64{Float64: c, Valid: true}
+			}
+
+			valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?, ?)")
+			valueArgs = append(valueArgs, acc.Horizon, acc.GroupType, acc.GroupKey, acc.Samples,
+				hitRate, acc.SumReturn/float64(acc.Samples), correlation)
+		}
+
+		insertQuery := fmt.Sprintf(`
+			INSERT INTO insight_accuracy
+				(horizon_hours, group_type, group_key, samples, hit_rate, avg_return, rank_correlation)
+			VALUES %s`, strings.Join(valueStrings, ","))
+		if _, err = tx.ExecContext(queryCtx, insertQuery, valueArgs...); err != nil {
+			return fmt.Errorf("지표 삽입 에러: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
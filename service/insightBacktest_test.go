@@ -0,0 +1,96 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+const floatTolerance = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= floatTolerance*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func TestScoreBucket(t *testing.T) {
+	tests := []struct {
+		score int
+		want  string
+	}{
+		{1, "1-20"},
+		{20, "1-20"},
+		{21, "21-40"},
+		{50, "41-60"},
+		{60, "41-60"},
+		{61, "61-80"},
+		{81, "81-100"},
+		{100, "81-100"},
+	}
+
+	for _, tt := range tests {
+		if got := scoreBucket(tt.score); got != tt.want {
+			t.Errorf("scoreBucket(%d) = %s, want %s", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestComputeInsightAccuracy(t *testing.T) {
+	outcomes := []insightOutcome{
+		{Horizon: 24, Score: 80, ReturnRate: 3, PromptVersion: "v1", Model: "gemini/g"},  // 적중
+		{Horizon: 24, Score: 70, ReturnRate: -1, PromptVersion: "v1", Model: "gemini/g"}, // 빗나감
+		{Horizon: 24, Score: 20, ReturnRate: -2, PromptVersion: "v2", Model: "openai/o"}, // 적중
+		{Horizon: 24, Score: 50, ReturnRate: 4, PromptVersion: "v2", Model: "openai/o"},  // 중립은 적중률에서 제외
+		{Horizon: 24, Score: 90, ReturnRate: 0, PromptVersion: "v2", Model: "openai/o"},  // 수익률 0은 상승 아님
+		{Horizon: 72, Score: 80, ReturnRate: 5, PromptVersion: "v1", Model: "gemini/g"},
+	}
+
+	accuracies := computeInsightAccuracy(outcomes)
+
+	tests := []struct {
+		horizon    int
+		groupType  string
+		groupKey   string
+		samples    int
+		hits       int
+		directions int
+		sumReturn  float64
+	}{
+		{24, accuracyGroupAll, "", 5, 2, 4, 4},
+		{24, accuracyGroupBucket, "61-80", 2, 1, 2, 2},
+		{24, accuracyGroupBucket, "1-20", 1, 1, 1, -2},
+		{24, accuracyGroupBucket, "41-60", 1, 0, 0, 4},
+		{24, accuracyGroupBucket, "81-100", 1, 0, 1, 0},
+		{24, accuracyGroupPrompt, "v1", 2, 1, 2, 2},
+		{24, accuracyGroupPrompt, "v2", 3, 1, 2, 2},
+		{24, accuracyGroupModel, "openai/o", 3, 1, 2, 2},
+		{72, accuracyGroupAll, "", 1, 1, 1, 5},
+		{72, accuracyGroupModel, "gemini/g", 1, 1, 1, 5},
+	}
+
+	for _, tt := range tests {
+		var found *insightAccuracy
+		for _, acc := range accuracies {
+			if acc.Horizon == tt.horizon && acc.GroupType == tt.groupType && acc.GroupKey == tt.groupKey {
+				found = acc
+				break
+			}
+		}
+		if found == nil {
+			t.Errorf("%d시간 %s %q 지표가 없음", tt.horizon, tt.groupType, tt.groupKey)
+			continue
+		}
+		if found.Samples != tt.samples || found.DirectionalHits != tt.hits || found.DirectionalN != tt.directions || !almostEqual(found.SumReturn, tt.sumReturn) {
+			t.Errorf("%d시간 %s %q = 표본 %d, 적중 %d/%d, 수익률 합 %v, want %d, %d/%d, %v",
+				tt.horizon, tt.groupType, tt.groupKey, found.Samples, found.DirectionalHits, found.DirectionalN, found.SumReturn,
+				tt.samples, tt.hits, tt.directions, tt.sumReturn)
+		}
+		if len(found.scores) != found.Samples || len(found.returns) != found.Samples {
+			t.Errorf("%d시간 %s %q 상관계수 표본 = %d, %d, want %d", tt.horizon, tt.groupType, tt.groupKey,
+				len(found.scores), len(found.returns), found.Samples)
+		}
+	}
+
+	// 24시간 (전체 1 + 구간 4 + 프롬프트 2 + 모델 2) + 72시간 (전체, 구간, 프롬프트, 모델 각 1)
+	if len(accuracies) != 13 {
+		t.Errorf("지표 %d개, want 13개", len(accuracies))
+	}
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 프롬프트에 항상 포함하는 시장 기준 코인
+// 기준 코인은 데이터 표에만 넣고, 관심 코인(config.InsightConfig.Watchlist)은 개별 분석을 요청합니다.
+const insightBenchmark = "KRW-BTC"
+
+// 주요 변동 코인 선정 기준
+const (
+	insightMoverCount       = 10            // 데이터 표에 넣을 주요 변동 코인 수 (이 중 상위 insightMoverRequestCount개는 개별 분석 요청)
+	insightMoverMinTradeKRW = 1_000_000_000 // 24시간 거래대금 하한 (거래가 거의 없는 코인 제외)
+)
+
+// insightCoinData 프롬프트에 넣을 코인 한 개의 실제 시세 데이터
+type insightCoinData struct {
+	Symbol        string
+	KoreanName    string
+	ChangeRate    float64 // 전일 종가 대비 변동률(%)
+	TradePrice24h int64   // 24시간 거래대금(원)
+	IsCaution     bool
+	IsWarning     bool
+
+	// kimchi_premium 기준일 마지막 기록 (HasPremium이 false면 기록 없음)
+	HasPremium bool
+	Premium    float64 // 해외 시세 대비 원화 가격 프리미엄(%)
+
+	// coin_candles_daily 기준일 일봉 (HasOHLC가 false면 기록 없음)
+	HasOHLC                bool
+	Open, High, Low, Close float64
+	Volume                 float64 // 거래량 (코인 수량)
+}
+
+// insightMarketData 프롬프트에 넣을 기준일 시장 데이터
+type insightMarketData struct {
+	Date string
+
+	// market_indices 기준일 첫/마지막 시간 값 (HasIndex가 false면 기록 없음)
+	HasIndex              bool
+	MarketOpen, MarketEnd float64
+	AltOpen, AltEnd       float64
+
+	// kimchi_premium 기준일 마지막 시간의 환율과 전체 코인 평균 프리미엄 (PremiumCount가 0이면 기록 없음)
+	FXRate       float64
+	AvgPremium   float64
+	PremiumCount int
+
+	// sentiment_index 기준일 마지막 기록 (HasSentiment가 false면 기록 없음)
+	HasSentiment        bool
+	Sentiment           float64
+	SentimentLabel      string
+	SentimentComponents map[string]sql.NullFloat64
+
+	Watchlist []insightCoinData // 시장 기준 코인 + 관심 코인
+	Movers    []insightCoinData // 관심 코인을 제외한 변동률 절댓값 상위 코인
+}
+
+// loadInsightMarketData DB에 저장된 시세 데이터로 date(기준일) 프롬프트 데이터를 구성합니다.
+func loadInsightMarketData(ctx context.Context, db *sql.DB, date time.Time, watchlist []string) (insightMarketData, error) {
+	data := insightMarketData{Date: date.Format("2006-01-02")}
+
+	// 1. 마켓 인덱스
+	if err := loadInsightIndex(ctx, db, &data); err != nil {
+		return data, fmt.Errorf("마켓 인덱스 조회 실패: %w", err)
+	}
+
+	// 2. 코인 현황
+	coins, err := loadInsightCoins(ctx, db)
+	if err != nil {
+		return data, fmt.Errorf("코인 현황 조회 실패: %w", err)
+	}
+	data.Watchlist, data.Movers = selectInsightCoins(coins, append([]string{insightBenchmark}, watchlist...))
+
+	// 3. 선정된 코인의 일봉
+	if err := loadInsightOHLC(ctx, db, data.Date, data.Watchlist, data.Movers); err != nil {
+		return data, fmt.Errorf("가격 히스토리 조회 실패: %w", err)
+	}
+
+	// 4. 김치 프리미엄
+	if err := loadInsightPremium(ctx, db, &data); err != nil {
+		return data, fmt.Errorf("김치 프리미엄 조회 실패: %w", err)
+	}
+
+	// 5. 시장 심리 지수
+	if err := loadInsightSentiment(ctx, db, &data); err != nil {
+		return data, fmt.Errorf("시장 심리 지수 조회 실패: %w", err)
+	}
+
+	return data, nil
+}
+
+// 기준일 마켓/알트 인덱스의 첫 시간 값과 마지막 시간 값 조회
+func loadInsightIndex(ctx context.Context, db *sql.DB, data *insightMarketData) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT market_index, alt_index
+		FROM market_indices
+		WHERE date = ?
+		ORDER BY hour
+	`
+
+	rows, err := db.QueryContext(queryCtx, query, data.Date)
+	if err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var marketIndex, altIndex float64
+		if err := rows.Scan(&marketIndex, &altIndex); err != nil {
+			return fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		if !data.HasIndex {
+			data.HasIndex = true
+			data.MarketOpen, data.AltOpen = marketIndex, altIndex
+		}
+		data.MarketEnd, data.AltEnd = marketIndex, altIndex
+	}
+
+	return rows.Err()
+}
+
+// 상장중인 코인 현황 조회 (coin id별)
+func loadInsightCoins(ctx context.Context, db *sql.DB) (map[int]*insightCoinData, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, symbol, korean_name, change_rate, trade_price_24h, is_caution, is_warning
+		FROM coins
+		WHERE is_deleted = 0
+	`
+
+	rows, err := db.QueryContext(queryCtx, query)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	coins := make(map[int]*insightCoinData)
+	for rows.Next() {
+		var id int
+		coin := &insightCoinData{}
+		if err := rows.Scan(&id, &coin.Symbol, &coin.KoreanName, &coin.ChangeRate,
+			&coin.TradePrice24h, &coin.IsCaution, &coin.IsWarning); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		coins[id] = coin
+	}
+
+	return coins, rows.Err()
+}
+
+// 관심 코인과 주요 변동 코인을 선정합니다.
+func selectInsightCoins(coins map[int]*insightCoinData, watchlist []string) (watch, movers []insightCoinData) {
+	bySymbol := make(map[string]int, len(coins))
+	for id, coin := range coins {
+		bySymbol[coin.Symbol] = id
+	}
+
+	watched := make(map[string]bool, len(watchlist))
+	for _, symbol := range watchlist {
+		if id, ok := bySymbol[symbol]; ok {
+			watch = append(watch, *coins[id])
+			watched[symbol] = true
+		}
+	}
+
+	for _, coin := range coins {
+		if watched[coin.Symbol] || coin.TradePrice24h < insightMoverMinTradeKRW {
+			continue
+		}
+		movers = append(movers, *coin)
+	}
+	sort.Slice(movers, func(i, j int) bool {
+		if math.Abs(movers[i].ChangeRate) != math.Abs(movers[j].ChangeRate) {
+			return math.Abs(movers[i].ChangeRate) > math.Abs(movers[j].ChangeRate)
+		}
+		return movers[i].Symbol < movers[j].Symbol
+	})
+	if len(movers) > insightMoverCount {
+		movers = movers[:insightMoverCount]
+	}
+
+	return watch, movers
+}
+
+// 선정된 코인들의 기준일 일봉을 조회합니다. (시간봉에서 집계된 coin_candles_daily)
+// 일봉 테이블이 아직 없으면 기록 없음으로 봅니다. (테이블 생성은 가격 히스토리 업데이트에서 수행)
+func loadInsightOHLC(ctx context.Context, db *sql.DB, date string, coinLists ...[]insightCoinData) error {
+	bySymbol := make(map[string]*insightCoinData)
+	var symbols []interface{}
+	for _, list := range coinLists {
+		for i := range list {
+			bySymbol[list[i].Symbol] = &list[i]
+			symbols = append(symbols, list[i].Symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT c.symbol, d.open_price, d.high_price, d.low_price, d.close_price, d.volume
+		FROM coin_candles_daily d
+		JOIN coins c ON c.id = d.coin_id
+		WHERE d.date = ? AND c.symbol IN (%s)
+	`, util.GeneratePlaceholders(len(symbols)))
+
+	rows, err := db.QueryContext(queryCtx, query, append([]interface{}{date}, symbols...)...)
+	if isMissingTable(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var symbol string
+		var candle insightCoinData
+		if err := rows.Scan(&symbol, &candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Volume); err != nil {
+			return fmt.Errorf("행 스캔 에러: %w", err)
+		}
+
+		coin := bySymbol[symbol]
+		coin.HasOHLC = true
+		coin.Open, coin.High, coin.Low, coin.Close, coin.Volume = candle.Open, candle.High, candle.Low, candle.Close, candle.Volume
+	}
+
+	return rows.Err()
+}
+
+// 기준일 마지막 시간의 김치 프리미엄을 조회하여 평균과 선정된 코인별 값을 채웁니다.
+// 테이블이 아직 없으면 기록 없음으로 봅니다. (테이블 생성은 김치 프리미엄 업데이트에서 수행)
+func loadInsightPremium(ctx context.Context, db *sql.DB, data *insightMarketData) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT symbol, premium, fx_rate
+		FROM kimchi_premium
+		WHERE date = ? AND hour = (SELECT MAX(hour) FROM kimchi_premium WHERE date = ?)
+	`
+
+	rows, err := db.QueryContext(queryCtx, query, data.Date, data.Date)
+	if isMissingTable(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	premiums := make(map[string]float64)
+	var total float64
+	for rows.Next() {
+		var symbol string
+		var premium, fxRate float64
+		if err := rows.Scan(&symbol, &premium, &fxRate); err != nil {
+			return fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		premiums[symbol] = premium
+		total += premium
+		data.FXRate = fxRate
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(premiums) == 0 {
+		return nil
+	}
+
+	data.PremiumCount = len(premiums)
+	data.AvgPremium = total / float64(len(premiums))
+	for _, list := range [][]insightCoinData{data.Watchlist, data.Movers} {
+		for i := range list {
+			if premium, ok := premiums[list[i].Symbol]; ok {
+				list[i].HasPremium = true
+				list[i].Premium = premium
+			}
+		}
+	}
+	return nil
+}
+
+// 기준일 마지막 시장 심리 지수와 구성 요소 점수 조회
+// 테이블이 아직 없으면 기록 없음으로 봅니다. (테이블 생성은 시장 심리 지수 업데이트에서 수행)
+func loadInsightSentiment(ctx context.Context, db *sql.DB, data *insightMarketData) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT value, label, breadth_score, caution_score, volatility_score, momentum_score, behavior_score
+		FROM sentiment_index
+		WHERE date = ?
+		ORDER BY hour DESC
+		LIMIT 1
+	`
+
+	scores := make([]sql.NullFloat64, len(sentimentComponents))
+	dest := []interface{}{&data.Sentiment, &data.SentimentLabel}
+	for i := range scores {
+		dest = append(dest, &scores[i])
+	}
+	err := db.QueryRowContext(queryCtx, query, data.Date).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) || isMissingTable(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+
+	data.HasSentiment = true
+	data.SentimentComponents = make(map[string]sql.NullFloat64, len(sentimentComponents))
+	for i, name := range sentimentComponents {
+		data.SentimentComponents[name] = scores[i]
+	}
+	return nil
+}
+
+// 프롬프트에 넣을 데이터 표를 만듭니다.
+func (d insightMarketData) promptTables() string {
+	var b strings.Builder
+
+	b.WriteString("[마켓 인덱스 (상위 코인 시가총액 기반, 기준일 첫 기록 → 마지막 기록)]\n")
+	if d.HasIndex {
+		fmt.Fprintf(&b, "- 마켓 인덱스: %.0f → %.0f (%s)\n", d.MarketOpen, d.MarketEnd, formatRate(d.MarketOpen, d.MarketEnd))
+		fmt.Fprintf(&b, "- 알트 인덱스: %.0f → %.0f (%s)\n", d.AltOpen, d.AltEnd, formatRate(d.AltOpen, d.AltEnd))
+	} else {
+		b.WriteString("- 기록 없음\n")
+	}
+
+	b.WriteString("\n[시장 심리 지수 (Bitground 내부 데이터로 계산한 공포-탐욕 지수, 0-100, 50이 중립, 기준일 마지막 기록)]\n")
+	if d.HasSentiment {
+		fmt.Fprintf(&b, "- 지수: %.1f (%s)\n", d.Sentiment, d.SentimentLabel)
+		for _, name := range sentimentComponents {
+			score := "-"
+			if component := d.SentimentComponents[name]; component.Valid {
+				score = fmt.Sprintf("%.1f", component.Float64)
+			}
+			fmt.Fprintf(&b, "- %s: %s\n", sentimentComponentNames[name], score)
+		}
+	} else {
+		b.WriteString("- 기록 없음\n")
+	}
+
+	b.WriteString("\n[김치 프리미엄 (업비트 원화 가격이 CoinGecko 달러 가격 × 환율보다 높은 비율, 기준일 마지막 기록)]\n")
+	if d.PremiumCount > 0 {
+		fmt.Fprintf(&b, "- 적용 환율: %.2f원/달러\n", d.FXRate)
+		fmt.Fprintf(&b, "- 평균 프리미엄: %+.2f%% (%d개 코인)\n", d.AvgPremium, d.PremiumCount)
+		b.WriteString("- 코인별 값은 아래 표의 '김프(%)' 열 참고. 프리미엄이 크게 벌어진 코인은 업비트 '해외 가격 괴리(GLOBAL_PRICE_DIFFERENCES)' 유의 지정 대상이 될 수 있습니다.\n")
+	} else {
+		b.WriteString("- 기록 없음\n")
+	}
+
+	b.WriteString("\n[관심 코인 시세]\n")
+	writeCoinTable(&b, d.Watchlist)
+
+	b.WriteString("\n[주요 변동 코인 (관심 코인 제외, 24시간 거래대금 10억원 이상 중 변동률 절댓값 상위)]\n")
+	writeCoinTable(&b, d.Movers)
+
+	return b.String()
+}
+
+func writeCoinTable(b *strings.Builder, coins []insightCoinData) {
+	if len(coins) == 0 {
+		b.WriteString("- 데이터 없음\n")
+		return
+	}
+
+	b.WriteString("| 심볼 | 이름 | 시가 | 고가 | 저가 | 종가 | 거래량 | 변동률(%) | 24h 거래대금(억원) | 김프(%) | 유의 | 경고 |\n")
+	for _, coin := range coins {
+		ohlc := "- | - | - | - | -"
+		if coin.HasOHLC {
+			ohlc = fmt.Sprintf("%s | %s | %s | %s | %s",
+				formatPrice(coin.Open), formatPrice(coin.High), formatPrice(coin.Low), formatPrice(coin.Close), formatPrice(coin.Volume))
+		}
+		premium := "-"
+		if coin.HasPremium {
+			premium = fmt.Sprintf("%+.2f", coin.Premium)
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %+.2f | %d | %s | %s | %s |\n",
+			coin.Symbol, coin.KoreanName, ohlc, coin.ChangeRate,
+			coin.TradePrice24h/100_000_000, premium, yesNo(coin.IsCaution), yesNo(coin.IsWarning))
+	}
+}
+
+// 변동률 문자열 (기준값이 0이면 "-")
+func formatRate(from, to float64) string {
+	if from == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f%%", (to-from)/from*100)
+}
+
+// 1원 미만 코인도 구분되도록 가격 크기에 맞춰 소수점 자리수를 정합니다.
+func formatPrice(price float64) string {
+	switch {
+	case price >= 100:
+		return fmt.Sprintf("%.0f", price)
+	case price >= 1:
+		return fmt.Sprintf("%.2f", price)
+	default:
+		return fmt.Sprintf("%.4f", price)
+	}
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "Y"
+	}
+	return "N"
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteCoinTable(t *testing.T) {
+	coins := []insightCoinData{
+		{Symbol: "KRW-BTC", KoreanName: "비트코인", ChangeRate: 1.5, TradePrice24h: 300_000_000_000,
+			HasOHLC: true, Open: 100000000, High: 102000000, Low: 98000000, Close: 101000000, Volume: 1234.5},
+		{Symbol: "KRW-ETH", KoreanName: "이더리움", ChangeRate: -0.5, TradePrice24h: 100_000_000_000},
+	}
+
+	var b strings.Builder
+	writeCoinTable(&b, coins)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("표 %d줄, want 3줄:\n%s", len(lines), b.String())
+	}
+
+	// 모든 행의 열 수가 머리글과 같아야 함
+	columns := strings.Count(lines[0], "|")
+	for _, line := range lines[1:] {
+		if n := strings.Count(line, "|"); n != columns {
+			t.Errorf("열 구분자 %d개, want %d개: %s", n, columns, line)
+		}
+	}
+	if !strings.Contains(lines[0], "거래량") {
+		t.Errorf("머리글에 거래량 열이 없음: %s", lines[0])
+	}
+	if want := "| 100000000 | 102000000 | 98000000 | 101000000 | 1234 |"; !strings.Contains(lines[1], want) {
+		t.Errorf("일봉 행 = %s, want %s 포함", lines[1], want)
+	}
+	if want := "| - | - | - | - | - |"; !strings.Contains(lines[2], want) {
+		t.Errorf("일봉이 없는 행 = %s, want %s 포함", lines[2], want)
+	}
+}
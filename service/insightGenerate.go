@@ -0,0 +1,169 @@
+package service
+
+import (
+	"Bitground-go/config"
+	"Bitground-go/llm"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// 심볼별 인사이트 요청 종류
+const (
+	insightTargetMarket = "market" // 시장 전체 (MARKET_OVERALL)
+	insightTargetWatch  = "watch"  // 관심 코인
+	insightTargetMover  = "mover"  // 주요 변동 코인
+)
+
+// 개별 분석을 요청할 주요 변동 코인 수 (변동률 절댓값 순)
+const insightMoverRequestCount = 5
+
+// insightTarget 인사이트 요청 한 건의 대상
+type insightTarget struct {
+	Symbol string
+	Name   string
+	Kind   string
+}
+
+// insightTargetData 심볼별 템플릿에 전달하는 데이터
+type insightTargetData struct {
+	insightPromptData
+	Target    insightTarget
+	MaxLength int
+}
+
+// insightTargets 시장 전체, 관심 코인, 주요 변동 코인 상위 순으로 요청 대상을 만듭니다.
+// symbolMap에 없는 (상장 폐지된) 코인은 제외합니다.
+func insightTargets(data insightMarketData, symbolMap map[string]int) []insightTarget {
+	targets := []insightTarget{{Symbol: insightMarketSymbol, Name: "전체 시장", Kind: insightTargetMarket}}
+
+	for _, coin := range data.Watchlist {
+		if coin.Symbol == insightBenchmark || symbolMap[coin.Symbol] == 0 {
+			continue // 시장 기준 코인은 전체 시장 분석에서 다룸
+		}
+		targets = append(targets, insightTarget{Symbol: coin.Symbol, Name: coin.KoreanName, Kind: insightTargetWatch})
+	}
+
+	movers := 0
+	for _, coin := range data.Movers {
+		if movers == insightMoverRequestCount {
+			break
+		}
+		if symbolMap[coin.Symbol] == 0 {
+			continue
+		}
+		targets = append(targets, insightTarget{Symbol: coin.Symbol, Name: coin.KoreanName, Kind: insightTargetMover})
+		movers++
+	}
+
+	return targets
+}
+
+// generateInsights 대상별로 인사이트를 요청합니다.
+// 최대 cfg.Concurrency개씩 동시에 요청하며, 실패한 대상은 건너뛰고 나머지 결과를 대상 순서대로 반환합니다.
+// 템플릿의 요청 방식이 batch면 (v1) 한 번의 요청으로 모든 대상의 인사이트를 받습니다.
+func generateInsights(ctx context.Context, provider llm.Provider, tmpl promptTemplate, data insightMarketData,
+	targets []insightTarget, symbolMap map[string]int, cfg config.InsightConfig) (insights []Insight, failed []string) {
+	promptData := newInsightPromptData(data)
+	if tmpl.Mode != insightModeTarget {
+		insights, err := generateBatchInsights(ctx, provider, tmpl, promptData, symbolMap, cfg)
+		if err != nil {
+			log.Printf("인사이트 일괄 생성 실패: %v\n", err)
+			for _, target := range targets {
+				failed = append(failed, target.Symbol)
+			}
+		}
+		return insights, failed
+	}
+
+	results := make([]*Insight, len(targets))
+
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(cfg.Concurrency)
+	for i, target := range targets {
+		i, target := i, target
+		g.Go(func() error {
+			insight, err := generateTargetInsight(ctx, provider, tmpl, promptData, target, cfg)
+			if err != nil {
+				log.Printf("%s 인사이트 생성 실패: %v\n", target.Symbol, err)
+				mu.Lock()
+				failed = append(failed, target.Symbol)
+				mu.Unlock()
+				return nil // 다른 대상은 계속 진행
+			}
+			results[i] = &insight
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for _, insight := range results {
+		if insight != nil {
+			insights = append(insights, *insight)
+		}
+	}
+	return insights, failed
+}
+
+// 대상 하나의 인사이트를 요청하고 검증합니다.
+func generateTargetInsight(ctx context.Context, provider llm.Provider, tmpl promptTemplate, promptData insightPromptData,
+	target insightTarget, cfg config.InsightConfig) (Insight, error) {
+	prompt, err := tmpl.render(insightTargetData{insightPromptData: promptData, Target: target, MaxLength: insightMaxLength})
+	if err != nil {
+		return Insight{}, err
+	}
+
+	insights, resp, err := generateValidInsights(ctx, provider, insightRequest{
+		Prompt:      prompt,
+		Purpose:     llmPurposeInsight + target.Symbol,
+		MaxAttempts: cfg.MaxAttempts,
+		Timeout:     cfg.RequestTimeout,
+		Validate: func(insights []Insight) []string {
+			return validateTargetInsight(target.Symbol, insights)
+		},
+	})
+	if err != nil {
+		return Insight{}, err
+	}
+
+	insight := insights[0]
+	insight.Model = fmt.Sprintf("%s/%s", resp.Provider, resp.Model)
+	if resp.Cached {
+		log.Printf("%s 인사이트는 저장된 응답 재사용 (llm_calls id %d)\n", target.Symbol, resp.CallID)
+	}
+	return insight, nil
+}
+
+// 시장 전체와 요청 코인 분석을 한 번에 요청하는 템플릿(v1)의 인사이트를 요청하고 검증합니다.
+func generateBatchInsights(ctx context.Context, provider llm.Provider, tmpl promptTemplate, promptData insightPromptData,
+	symbolMap map[string]int, cfg config.InsightConfig) ([]Insight, error) {
+	prompt, err := tmpl.render(insightTargetData{insightPromptData: promptData, MaxLength: insightMaxLength})
+	if err != nil {
+		return nil, err
+	}
+
+	insights, resp, err := generateValidInsights(ctx, provider, insightRequest{
+		Prompt:      prompt,
+		Purpose:     llmPurposeInsight + insightBatchSymbol,
+		MaxAttempts: cfg.MaxAttempts,
+		Timeout:     cfg.RequestTimeout,
+		Validate: func(insights []Insight) []string {
+			return validateInsights(insights, symbolMap)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range insights {
+		insights[i].Model = fmt.Sprintf("%s/%s", resp.Provider, resp.Model)
+	}
+	if resp.Cached {
+		log.Printf("인사이트 일괄 요청은 저장된 응답 재사용 (llm_calls id %d)\n", resp.CallID)
+	}
+	return insights, nil
+}
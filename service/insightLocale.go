@@ -0,0 +1,105 @@
+package service
+
+import (
+	"Bitground-go/config"
+	"Bitground-go/llm"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// 인사이트 원문 언어 (분석 프롬프트가 생성하는 언어)
+const insightBaseLocale = "ko"
+
+// 번역 프롬프트 템플릿 이름 (파일 이름은 insight-translate.<버전>.tmpl)
+const insightTranslatePromptName = "insight-translate"
+
+// 번역 프롬프트에 넣을 언어 이름, 목록에 없는 로케일은 코드를 그대로 사용합니다.
+var localeLanguages = map[string]string{
+	"ko": "한국어",
+	"en": "English",
+	"ja": "日本語",
+	"zh": "简体中文",
+}
+
+// insightTranslateData 번역 템플릿에 전달하는 데이터
+type insightTranslateData struct {
+	Locale    string
+	Language  string
+	MaxLength int
+	Insights  string // 원문 인사이트 JSON 배열
+}
+
+// translateInsights 원문 인사이트를 locale로 번역합니다.
+// 심볼 목록이 원문과 같은지 검증하고, 점수와 모델은 번역 응답과 관계없이 원문 값을 사용합니다.
+func translateInsights(ctx context.Context, provider llm.Provider, tmpl promptTemplate, source []Insight, locale string, cfg config.InsightConfig) ([]Insight, error) {
+	raw, err := json.MarshalIndent(source, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("원문 인사이트 변환 실패: %w", err)
+	}
+
+	language, ok := localeLanguages[locale]
+	if !ok {
+		language = locale
+	}
+	prompt, err := tmpl.render(insightTranslateData{
+		Locale:    locale,
+		Language:  language,
+		MaxLength: insightMaxLength,
+		Insights:  string(raw),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	translated, _, err := generateValidInsights(ctx, provider, insightRequest{
+		Prompt:      prompt,
+		Purpose:     llmPurposeTranslate + locale,
+		MaxAttempts: cfg.MaxAttempts,
+		Timeout:     cfg.RequestTimeout,
+		Validate: func(insights []Insight) []string {
+			return validateTranslation(source, insights)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := make(map[string]Insight, len(source))
+	for _, insight := range source {
+		bySymbol[insight.Symbol] = insight
+	}
+	for i := range translated {
+		original := bySymbol[translated[i].Symbol]
+		translated[i].Score = original.Score
+		translated[i].Model = original.Model
+		translated[i].Locale = locale
+	}
+
+	return translated, nil
+}
+
+// 번역 결과가 원문과 같은 심볼을 한 번씩 포함하는지 검증합니다.
+func validateTranslation(source, translated []Insight) []string {
+	var problems []string
+	remaining := make(map[string]bool, len(source))
+	for _, insight := range source {
+		remaining[insight.Symbol] = true
+	}
+
+	for i, insight := range translated {
+		label := fmt.Sprintf("%d번째 항목(%s)", i+1, insight.Symbol)
+		if !remaining[insight.Symbol] {
+			problems = append(problems, label+": 원문에 없거나 중복된 symbol")
+			continue
+		}
+		delete(remaining, insight.Symbol)
+		problems = append(problems, validateInsightText(label, insight.Insight)...)
+	}
+
+	for symbol := range remaining {
+		problems = append(problems, symbol+" 항목이 없음")
+	}
+
+	return problems
+}
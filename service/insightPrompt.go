@@ -0,0 +1,197 @@
+package service
+
+import (
+	"Bitground-go/prompts"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 인사이트 프롬프트 템플릿 이름 (파일 이름은 insight.<버전>.tmpl)
+const insightPromptName = "insight"
+
+// 인사이트 템플릿 요청 방식, 템플릿에 {{define "mode"}}target{{end}}처럼 선언합니다.
+const (
+	insightModeBatch  = "batch"  // 시장 전체와 관심 코인을 한 번에 요청 (v1)
+	insightModeTarget = "target" // 대상(.Target)별로 요청 (v2)
+)
+
+// promptTemplate 버전이 붙은 프롬프트 템플릿
+type promptTemplate struct {
+	Name    string
+	Version string
+	Source  string // dir, db, embed
+	Mode    string // 템플릿에 선언된 요청 방식 (선언이 없으면 빈 값)
+	tmpl    *template.Template
+}
+
+// insightPromptData 인사이트 템플릿에 전달하는 데이터
+// 템플릿에서는 insightMarketData의 필드(.Date, .Watchlist, .Movers 등)도 그대로 사용할 수 있습니다.
+type insightPromptData struct {
+	insightMarketData
+	Tables    string            // 마켓 인덱스, 관심 코인, 주요 변동 코인 데이터 표
+	Requested []insightCoinData // 개별 분석을 요청할 관심 코인 (시장 기준 코인 제외)
+}
+
+// 프롬프트 생성, 모든 수치는 data(DB에 저장된 실제 시세)만 사용하도록 데이터 표를 함께 넣습니다.
+func newInsightPromptData(data insightMarketData) insightPromptData {
+	promptData := insightPromptData{insightMarketData: data, Tables: data.promptTables()}
+	for _, coin := range data.Watchlist {
+		if coin.Symbol == insightBenchmark {
+			continue // 시장 기준 코인은 전체 시장 분석에서 다룸
+		}
+		promptData.Requested = append(promptData.Requested, coin)
+	}
+	return promptData
+}
+
+// ensurePromptTemplateTable prompt_templates 테이블이 없으면 생성
+// 같은 이름의 템플릿은 버전별로 한 행씩 저장하고, is_active = 1인 최신 행을 기본으로 사용합니다.
+func ensurePromptTemplateTable(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS prompt_templates (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(50) NOT NULL,
+			version VARCHAR(20) NOT NULL,
+			body TEXT NOT NULL,
+			is_active TINYINT(1) NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uk_prompt_templates_version (name, version)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// loadPromptTemplate 프롬프트 템플릿을 불러옵니다.
+// dir이 지정되면 해당 디렉터리에서만 찾고, 아니면 prompt_templates 테이블, 기본 템플릿(prompts 패키지) 순으로 찾습니다.
+// version이 비어 있으면 디렉터리/기본 템플릿은 가장 높은 버전을, DB는 활성화된 최신 행을 사용합니다.
+func loadPromptTemplate(ctx context.Context, db *sql.DB, name, dir, version string) (promptTemplate, error) {
+	if dir != "" {
+		return loadPromptFromFS(os.DirFS(dir), "dir", name, version)
+	}
+
+	if err := ensurePromptTemplateTable(ctx, db); err != nil {
+		return promptTemplate{}, fmt.Errorf("prompt_templates 테이블 생성 실패: %w", err)
+	}
+	p, found, err := loadPromptFromDB(ctx, db, name, version)
+	if err != nil || found {
+		return p, err
+	}
+
+	return loadPromptFromFS(prompts.FS, "embed", name, version)
+}
+
+// 파일 시스템에서 "<이름>.<버전>.tmpl" 템플릿을 찾습니다.
+func loadPromptFromFS(fsys fs.FS, source, name, version string) (promptTemplate, error) {
+	if version == "" {
+		matches, err := fs.Glob(fsys, name+".*.tmpl")
+		if err != nil {
+			return promptTemplate{}, err
+		}
+		if len(matches) == 0 {
+			return promptTemplate{}, fmt.Errorf("%s 프롬프트 템플릿 파일 없음 (%s)", name, source)
+		}
+
+		versions := make([]string, len(matches))
+		for i, match := range matches {
+			versions[i] = strings.TrimSuffix(strings.TrimPrefix(path.Base(match), name+"."), ".tmpl")
+		}
+		sort.Slice(versions, func(i, j int) bool { return versionLess(versions[i], versions[j]) })
+		version = versions[len(versions)-1]
+	}
+
+	body, err := fs.ReadFile(fsys, fmt.Sprintf("%s.%s.tmpl", name, version))
+	if err != nil {
+		return promptTemplate{}, fmt.Errorf("%s 프롬프트 템플릿 %s 버전 읽기 실패 (%s): %w", name, version, source, err)
+	}
+
+	return parsePromptTemplate(source, name, version, string(body))
+}
+
+// prompt_templates 테이블에서 템플릿을 찾습니다. 해당하는 행이 없으면 found는 false입니다.
+func loadPromptFromDB(ctx context.Context, db *sql.DB, name, version string) (p promptTemplate, found bool, err error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var row *sql.Row
+	if version != "" {
+		row = db.QueryRowContext(queryCtx,
+			`SELECT version, body FROM prompt_templates WHERE name = ? AND version = ?`, name, version)
+	} else {
+		row = db.QueryRowContext(queryCtx,
+			`SELECT version, body FROM prompt_templates WHERE name = ? AND is_active = 1 ORDER BY id DESC LIMIT 1`, name)
+	}
+
+	var body string
+	if err := row.Scan(&version, &body); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, false, nil
+		}
+		return p, false, fmt.Errorf("프롬프트 템플릿 조회 실패: %w", err)
+	}
+
+	p, err = parsePromptTemplate("db", name, version, body)
+	return p, true, err
+}
+
+func parsePromptTemplate(source, name, version, body string) (promptTemplate, error) {
+	tmpl, err := template.New(name + "." + version).Parse(body)
+	if err != nil {
+		return promptTemplate{}, fmt.Errorf("%s 프롬프트 템플릿 %s 버전 파싱 실패 (%s): %w", name, version, source, err)
+	}
+	p := promptTemplate{Name: name, Version: version, Source: source, tmpl: tmpl}
+	if tmpl.Lookup("mode") != nil {
+		var b strings.Builder
+		if err := tmpl.ExecuteTemplate(&b, "mode", nil); err != nil {
+			return promptTemplate{}, fmt.Errorf("%s 프롬프트 템플릿 %s 버전 요청 방식 실행 실패 (%s): %w", name, version, source, err)
+		}
+		p.Mode = strings.TrimSpace(b.String())
+	}
+	return p, nil
+}
+
+// validateInsightMode 인사이트 템플릿에 요청 방식이 올바르게 선언되어 있는지 확인합니다.
+func (p promptTemplate) validateInsightMode() error {
+	switch p.Mode {
+	case insightModeBatch, insightModeTarget:
+		return nil
+	case "":
+		return fmt.Errorf("%s 프롬프트 템플릿 %s 버전 (%s)에 요청 방식 선언이 없음 ({{define \"mode\"}}%s 또는 %s{{end}})",
+			p.Name, p.Version, p.Source, insightModeBatch, insightModeTarget)
+	default:
+		return fmt.Errorf("%s 프롬프트 템플릿 %s 버전 (%s)의 알 수 없는 요청 방식 '%s'", p.Name, p.Version, p.Source, p.Mode)
+	}
+}
+
+// render 템플릿에 데이터를 넣어 프롬프트를 만듭니다.
+func (p promptTemplate) render(data interface{}) (string, error) {
+	var b strings.Builder
+	if err := p.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%s 프롬프트 템플릿 %s 버전 실행 실패: %w", p.Name, p.Version, err)
+	}
+	return b.String(), nil
+}
+
+// 버전 비교 ("v2" < "v10"), 숫자가 아닌 버전은 문자열로 비교합니다.
+func versionLess(a, b string) bool {
+	na, errA := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	nb, errB := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
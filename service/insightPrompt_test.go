@@ -0,0 +1,96 @@
+package service
+
+import (
+	"Bitground-go/prompts"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPromptFromFSVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"insight.v1.tmpl":  {Data: []byte("v1")},
+		"insight.v2.tmpl":  {Data: []byte("v2")},
+		"insight.v10.tmpl": {Data: []byte("v10")},
+	}
+
+	latest, err := loadPromptFromFS(fsys, "test", insightPromptName, "")
+	if err != nil {
+		t.Fatalf("loadPromptFromFS 에러: %v", err)
+	}
+	if latest.Version != "v10" {
+		t.Errorf("버전을 지정하지 않으면 최신 버전: %s, want v10", latest.Version)
+	}
+
+	v1, err := loadPromptFromFS(fsys, "test", insightPromptName, "v1")
+	if err != nil {
+		t.Fatalf("loadPromptFromFS 에러: %v", err)
+	}
+	if out, err := v1.render(nil); err != nil || out != "v1" {
+		t.Errorf("v1 render = %q, %v", out, err)
+	}
+
+	if _, err := loadPromptFromFS(fsys, "test", insightPromptName, "v3"); err == nil {
+		t.Error("없는 버전은 에러가 나야 함")
+	}
+}
+
+func TestInsightTemplateMode(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"v1", insightModeBatch},  // 시장 전체와 코인을 한 번에 요청
+		{"v2", insightModeTarget}, // 대상별 요청
+	}
+
+	for _, tt := range tests {
+		tmpl, err := loadPromptFromFS(prompts.FS, "embed", insightPromptName, tt.version)
+		if err != nil {
+			t.Fatalf("%s 템플릿 로드 에러: %v", tt.version, err)
+		}
+		if tmpl.Mode != tt.want {
+			t.Errorf("%s 요청 방식 = %q, want %q", tt.version, tmpl.Mode, tt.want)
+		}
+		if err := tmpl.validateInsightMode(); err != nil {
+			t.Errorf("%s 요청 방식 검증 에러: %v", tt.version, err)
+		}
+		// 선언은 프롬프트에 포함되지 않음
+		out, err := tmpl.render(insightTargetData{Target: insightTarget{Kind: insightTargetMarket}})
+		if err != nil {
+			t.Fatalf("%s render 에러: %v", tt.version, err)
+		}
+		if strings.HasPrefix(out, "\n") || strings.Contains(out, tt.want+"[") {
+			t.Errorf("%s 프롬프트에 요청 방식 선언이 남음: %.40q", tt.version, out)
+		}
+	}
+}
+
+func TestValidateInsightMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"batch", `{{define "mode"}}batch{{end -}}본문`, false},
+		{"target 앞뒤 공백", `{{define "mode"}} target
+{{end -}}본문`, false},
+		{"선언 없음", `본문`, true},
+		{"알 수 없는 방식", `{{define "mode"}}single{{end -}}본문`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parsePromptTemplate("test", insightPromptName, "v9", tt.body)
+			if err != nil {
+				t.Fatalf("parsePromptTemplate 에러: %v", err)
+			}
+			if err := tmpl.validateInsightMode(); (err != nil) != tt.wantErr {
+				t.Errorf("validateInsightMode 에러 = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out, _ := tmpl.render(nil); out != "본문" {
+				t.Errorf("render = %q, want 본문", out)
+			}
+		})
+	}
+}
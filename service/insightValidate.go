@@ -0,0 +1,202 @@
+package service
+
+import (
+	"Bitground-go/llm"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 인사이트 검증 기준
+const (
+	insightMarketSymbol    = "MARKET_OVERALL"
+	insightBatchSymbol     = "ALL" // 한 번에 모든 대상을 요청하는 템플릿(v1)의 호출 기록 용도
+	insightMinScore        = 1
+	insightMaxScore        = 100
+	insightMaxLength       = 500 // 글자 수 (바이트 아님)
+	defaultInsightAttempts = 3
+)
+
+// insightSchema 모델에 전달하는 인사이트 응답 JSON 스키마
+// 글자 수 제한처럼 스키마를 지원하는 제공자도 강제하지 않는 조건은 validateTargetInsight에서 검사합니다.
+var insightSchema = json.RawMessage(`{
+	"type": "array",
+	"minItems": 1,
+	"items": {
+		"type": "object",
+		"properties": {
+			"symbol": {"type": "string"},
+			"insight": {"type": "string"},
+			"score": {"type": "integer", "minimum": 1, "maximum": 100}
+		},
+		"required": ["symbol", "insight", "score"]
+	}
+}`)
+
+// insightValidator 파싱한 인사이트 목록의 검증 에러 목록을 반환합니다.
+type insightValidator func(insights []Insight) []string
+
+// insightRequest 검증을 거치는 인사이트 생성 요청
+type insightRequest struct {
+	Prompt      string
+	Purpose     string        // 호출 기록용 용도 (llmPurpose* 접두사)
+	MaxAttempts int           // 검증 실패 시 최대 요청 횟수 (0 이하면 기본값)
+	Timeout     time.Duration // 요청 한 번의 시간 제한 (0이면 ctx 기한만 적용)
+	Validate    insightValidator
+}
+
+// generateValidInsights 모델 응답을 r.Validate로 검증하고, 실패하면 검증 에러를 덧붙여 다시 요청합니다.
+// MaxAttempts번 모두 실패하면 마지막 검증 에러를 반환합니다.
+// 검증을 통과한 응답은 호출 기록에 확인 표시하여 같은 날 재실행 시 재사용되도록 합니다.
+func generateValidInsights(ctx context.Context, provider llm.Provider, r insightRequest) ([]Insight, llm.Response, error) {
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultInsightAttempts
+	}
+
+	req := llm.Request{Prompt: r.Prompt, Schema: insightSchema, Purpose: r.Purpose}
+	var problems []string
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		timeout := requestTimeout(ctx, r.Timeout, maxAttempts-attempt+1)
+		resp, err := generateWithTimeout(ctx, provider, req, timeout)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		var insights []Insight
+		insights, problems = parseInsights(resp.Text, r.Validate)
+		if len(problems) == 0 {
+			if err := llm.Confirm(ctx, provider, resp); err != nil {
+				log.Printf("LLM 응답 확인 기록 실패: %v\n", err)
+			}
+			return insights, resp, nil
+		}
+
+		log.Printf("인사이트 응답 검증 실패 (%d/%d회): %s\n", attempt, maxAttempts, strings.Join(problems, "; "))
+		req.Prompt = retryPrompt(r.Prompt, problems)
+	}
+
+	return nil, llm.Response{}, fmt.Errorf("인사이트 응답 검증 %d회 실패: %s", maxAttempts, strings.Join(problems, "; "))
+}
+
+// requestTimeout 남은 ctx 기한을 남은 요청 횟수로 나눈 시간과 timeout 중 짧은 쪽을 요청 한 번의 시간 제한으로 사용합니다.
+// 첫 요청이 기한을 모두 써 버려 재요청할 시간이 없는 일을 막습니다.
+func requestTimeout(ctx context.Context, timeout time.Duration, attemptsLeft int) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok || attemptsLeft <= 0 {
+		return timeout
+	}
+	share := time.Until(deadline) / time.Duration(attemptsLeft)
+	if share <= 0 {
+		share = time.Nanosecond // 이미 기한이 지났으면 바로 실패
+	}
+	if timeout <= 0 || share < timeout {
+		return share
+	}
+	return timeout
+}
+
+func generateWithTimeout(ctx context.Context, provider llm.Provider, req llm.Request, timeout time.Duration) (llm.Response, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return provider.Generate(ctx, req)
+}
+
+// parseInsights 모델 응답을 파싱하고 검증합니다. 문제가 없으면 problems는 비어 있습니다.
+func parseInsights(text string, validate insightValidator) (insights []Insight, problems []string) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&insights); err != nil {
+		return nil, []string{fmt.Sprintf("응답이 스키마에 맞는 JSON 배열이 아님: %v", err)}
+	}
+
+	return insights, validate(insights)
+}
+
+// validateTargetInsight 심볼별 요청 응답의 검증 에러 목록을 반환합니다.
+// target 심볼 항목이 정확히 하나 있어야 하고, 점수 범위와 본문 길이를 지켜야 합니다.
+func validateTargetInsight(target string, insights []Insight) []string {
+	if len(insights) != 1 {
+		return []string{fmt.Sprintf("항목이 %d개임 (%s 항목 하나만 있어야 함)", len(insights), target)}
+	}
+
+	insight := insights[0]
+	label := fmt.Sprintf("항목(%s)", insight.Symbol)
+	var problems []string
+	if insight.Symbol != target {
+		problems = append(problems, fmt.Sprintf("%s: symbol은 %s여야 함", label, target))
+	}
+	if insight.Score < insightMinScore || insight.Score > insightMaxScore {
+		problems = append(problems, fmt.Sprintf("%s: score %d가 %d-%d 범위를 벗어남",
+			label, insight.Score, insightMinScore, insightMaxScore))
+	}
+
+	return append(problems, validateInsightText(label, insight.Insight)...)
+}
+
+// validateInsights 한 번에 모든 대상을 요청한 응답의 검증 에러 목록을 반환합니다.
+// MARKET_OVERALL 항목이 정확히 하나 있어야 하고, 나머지 심볼은 symbolMap에 있는 상장 코인이어야 합니다.
+// symbolMap이 nil이면 (재생 시) 상장 여부는 검사하지 않습니다.
+func validateInsights(insights []Insight, symbolMap map[string]int) []string {
+	var problems []string
+	seen := make(map[string]bool, len(insights))
+
+	for i, insight := range insights {
+		label := fmt.Sprintf("%d번째 항목(%s)", i+1, insight.Symbol)
+
+		switch {
+		case insight.Symbol == "":
+			problems = append(problems, fmt.Sprintf("%d번째 항목: symbol이 비어 있음", i+1))
+		case seen[insight.Symbol]:
+			problems = append(problems, label+": 중복된 symbol")
+		case insight.Symbol != insightMarketSymbol && symbolMap != nil && symbolMap[insight.Symbol] == 0:
+			problems = append(problems, label+": 상장 코인 목록에 없는 symbol")
+		}
+		seen[insight.Symbol] = true
+
+		if insight.Score < insightMinScore || insight.Score > insightMaxScore {
+			problems = append(problems, fmt.Sprintf("%s: score %d가 %d-%d 범위를 벗어남",
+				label, insight.Score, insightMinScore, insightMaxScore))
+		}
+
+		problems = append(problems, validateInsightText(label, insight.Insight)...)
+	}
+
+	if !seen[insightMarketSymbol] {
+		problems = append(problems, insightMarketSymbol+" 항목이 없음")
+	}
+
+	return problems
+}
+
+// 인사이트 본문 길이 검증
+func validateInsightText(label, text string) []string {
+	length := utf8.RuneCountInString(strings.TrimSpace(text))
+	if length == 0 {
+		return []string{label + ": insight가 비어 있음"}
+	}
+	if length > insightMaxLength {
+		return []string{fmt.Sprintf("%s: insight가 %d자로 %d자를 초과함", label, length, insightMaxLength)}
+	}
+	return nil
+}
+
+// 이전 응답의 검증 에러를 덧붙인 재요청 프롬프트
+func retryPrompt(prompt string, problems []string) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n[이전 응답 검증 실패: 아래 문제를 모두 수정하여 전체 결과를 [출력 형식]에 맞는 JSON 배열로만 다시 작성하십시오.]\n")
+	for _, problem := range problems {
+		b.WriteString("- ")
+		b.WriteString(problem)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
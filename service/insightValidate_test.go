@@ -0,0 +1,164 @@
+package service
+
+import (
+	"Bitground-go/llm"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInsights(t *testing.T) {
+	validate := func(insights []Insight) []string { return validateTargetInsight("KRW-BTC", insights) }
+	long := strings.Repeat("가", insightMaxLength+1)
+
+	tests := []struct {
+		name     string
+		text     string
+		problems []string // 각 문제에 포함되어야 하는 문구
+	}{
+		{"정상", `[{"symbol":"KRW-BTC","insight":"상승 추세","score":70}]`, nil},
+		{"최대 길이", `[{"symbol":"KRW-BTC","insight":"` + strings.Repeat("가", insightMaxLength) + `","score":100}]`, nil},
+		{"JSON 아님", `상승 추세입니다`, []string{"JSON 배열이 아님"}},
+		{"배열 아님", `{"symbol":"KRW-BTC","insight":"x","score":70}`, []string{"JSON 배열이 아님"}},
+		{"알 수 없는 필드", `[{"symbol":"KRW-BTC","insight":"x","score":70,"reason":"y"}]`, []string{"JSON 배열이 아님"}},
+		{"항목 수", `[]`, []string{"항목이 0개임"}},
+		{"다른 심볼", `[{"symbol":"KRW-ETH","insight":"x","score":70}]`, []string{"symbol은 KRW-BTC여야 함"}},
+		{"점수 범위", `[{"symbol":"KRW-BTC","insight":"x","score":0}]`, []string{"score 0가 1-100 범위를 벗어남"}},
+		{"빈 본문", `[{"symbol":"KRW-BTC","insight":"  ","score":50}]`, []string{"insight가 비어 있음"}},
+		{"본문 길이", `[{"symbol":"KRW-BTC","insight":"` + long + `","score":50}]`, []string{"501자로 500자를 초과함"}},
+		{"여러 문제", `[{"symbol":"KRW-ETH","insight":"","score":101}]`, []string{"symbol은", "score 101", "비어 있음"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := parseInsights(tt.text, validate)
+			if len(problems) != len(tt.problems) {
+				t.Fatalf("problems = %q, want %d개", problems, len(tt.problems))
+			}
+			for i, want := range tt.problems {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("problems[%d] = %q, %q 포함해야 함", i, problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateInsights(t *testing.T) {
+	symbolMap := map[string]int{"KRW-BTC": 1, "KRW-ETH": 2}
+	market := Insight{Symbol: insightMarketSymbol, Insight: "시장 전반", Score: 50}
+
+	if problems := validateInsights([]Insight{market, {Symbol: "KRW-BTC", Insight: "x", Score: 60}}, symbolMap); len(problems) != 0 {
+		t.Errorf("정상 응답 problems = %q", problems)
+	}
+
+	problems := validateInsights([]Insight{
+		{Symbol: "KRW-BTC", Insight: "x", Score: 60},
+		{Symbol: "KRW-BTC", Insight: "y", Score: 60},
+		{Symbol: "KRW-DOGE", Insight: "z", Score: 60},
+		{Symbol: "", Insight: "w", Score: 60},
+	}, symbolMap)
+	for _, want := range []string{"중복된 symbol", "상장 코인 목록에 없는 symbol", "symbol이 비어 있음", insightMarketSymbol + " 항목이 없음"} {
+		if !containsProblem(problems, want) {
+			t.Errorf("problems = %q, %q 포함해야 함", problems, want)
+		}
+	}
+
+	// 재생 시 (symbolMap nil) 상장 여부는 검사하지 않음
+	if problems := validateInsights([]Insight{market, {Symbol: "KRW-DOGE", Insight: "z", Score: 60}}, nil); len(problems) != 0 {
+		t.Errorf("symbolMap 없이 problems = %q", problems)
+	}
+}
+
+func containsProblem(problems []string, want string) bool {
+	for _, problem := range problems {
+		if strings.Contains(problem, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// replyProvider 정해진 응답을 차례로 돌려주고 받은 프롬프트를 기록하는 테스트용 제공자
+type replyProvider struct {
+	replies []string
+	prompts []string
+}
+
+func (p *replyProvider) Generate(ctx context.Context, req llm.Request) (llm.Response, error) {
+	p.prompts = append(p.prompts, req.Prompt)
+	reply := p.replies[0]
+	if len(p.replies) > 1 {
+		p.replies = p.replies[1:]
+	}
+	return llm.Response{Text: reply, Raw: reply, Provider: "fake"}, nil
+}
+
+func TestGenerateValidInsightsRetry(t *testing.T) {
+	provider := &replyProvider{replies: []string{
+		`[{"symbol":"KRW-BTC","insight":"x","score":500}]`,
+		`[{"symbol":"KRW-BTC","insight":"상승 추세","score":70}]`,
+	}}
+
+	insights, _, err := generateValidInsights(context.Background(), provider, insightRequest{
+		Prompt:   "프롬프트",
+		Validate: func(insights []Insight) []string { return validateTargetInsight("KRW-BTC", insights) },
+	})
+	if err != nil {
+		t.Fatalf("generateValidInsights 에러: %v", err)
+	}
+	if len(insights) != 1 || insights[0].Score != 70 {
+		t.Errorf("insights = %+v", insights)
+	}
+	if len(provider.prompts) != 2 || !strings.Contains(provider.prompts[1], "score 500") {
+		t.Errorf("재요청 프롬프트에 검증 에러를 덧붙여야 함: %q", provider.prompts)
+	}
+
+	provider = &replyProvider{replies: []string{`not json`}}
+	if _, _, err := generateValidInsights(context.Background(), provider, insightRequest{
+		Prompt:      "프롬프트",
+		MaxAttempts: 2,
+		Validate:    func(insights []Insight) []string { return nil },
+	}); err == nil {
+		t.Error("모든 시도가 실패하면 에러가 나야 함")
+	}
+	if len(provider.prompts) != 2 {
+		t.Errorf("MaxAttempts번 요청해야 함: %d회", len(provider.prompts))
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	// 기한이 없으면 설정값을 그대로 사용
+	if got := requestTimeout(context.Background(), time.Minute, 3); got != time.Minute {
+		t.Errorf("기한 없음: %s, want 1m", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		attemptsLeft int
+		min, max     time.Duration
+	}{
+		{"남은 기한을 남은 요청 횟수로 나눔", time.Minute, 3, 29 * time.Second, 30 * time.Second},
+		{"나눈 시간보다 짧은 설정값", 10 * time.Second, 3, 10 * time.Second, 10 * time.Second},
+		{"마지막 요청은 남은 기한 전체", 2 * time.Minute, 1, 89 * time.Second, 90 * time.Second},
+		{"설정값 없음", 0, 2, 44 * time.Second, 45 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := requestTimeout(ctx, tt.timeout, tt.attemptsLeft)
+			if got < tt.min || got > tt.max {
+				t.Errorf("requestTimeout = %s, want %s~%s", got, tt.min, tt.max)
+			}
+		})
+	}
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+	if got := requestTimeout(expired, time.Minute, 2); got <= 0 || got > time.Millisecond {
+		t.Errorf("기한이 지났으면 바로 실패해야 함: %s", got)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Main 실행 단계 이름
+const (
+	StepActiveCoins     = "ACTIVE_COINS"
+	StepCurrentSeason   = "CURRENT_SEASON"
+	StepFlags           = "FLAGS"
+	StepMarketIndex     = "MARKET_INDEX"
+	StepTickers         = "TICKERS"
+	StepSeasonResume    = "SEASON_RESUME"
+	StepCoins           = "COINS"
+	StepSplit           = "SPLIT"
+	StepOrderMatch      = "ORDER_MATCH"
+	StepRank            = "RANK"
+	StepSeason          = "SEASON"
+	StepSeasonDryRun    = "SEASON_DRY_RUN"
+	StepSeasonNotify    = "SEASON_NOTIFY"
+	StepPriceHistory    = "PRICE_HISTORY"
+	StepKimchiPremium   = "KIMCHI_PREMIUM"
+	StepSentimentIndex  = "SENTIMENT_INDEX"
+	StepInsight         = "INSIGHT"
+	StepInsightBacktest = "INSIGHT_BACKTEST"
+)
+
+// 단계 및 실행 상태
+const (
+	jobStepSuccess = "SUCCESS"
+	jobStepFailed  = "FAILED"
+	jobStepSkipped = "SKIPPED"
+	jobRunRunning  = "RUNNING"
+)
+
+// JobRecorder Main 실행 단계별 결과를 모으는 기록기
+// 고루틴에서 실행되는 단계도 함께 기록하므로 모든 메서드는 동시에 호출해도 안전합니다.
+type JobRecorder struct {
+	mu  sync.Mutex
+	run model.JobRun
+}
+
+// NewJobRecorder 실행 기록기 생성 함수
+func NewJobRecorder() *JobRecorder {
+	return &JobRecorder{run: model.JobRun{StartedAt: time.Now(), Steps: []model.JobStep{}}}
+}
+
+// Run 단계 하나를 실행하고 상태, 소요 시간, 에러, 처리 행 수를 기록합니다.
+// fn에 넘기는 컨텍스트에는 행 카운터가 담겨 있어 서비스 함수의 util.AddRows 호출이 집계됩니다.
+func (r *JobRecorder) Run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	stepCtx, rows := util.WithRowCounter(ctx)
+	step := model.JobStep{Name: name, StartedAt: time.Now()}
+
+	err := fn(stepCtx)
+
+	step.DurationMs = time.Since(step.StartedAt).Milliseconds()
+	step.Rows = atomic.LoadInt64(rows)
+	step.Status = jobStepSuccess
+	if err != nil {
+		step.Status = jobStepFailed
+		step.Error = err.Error()
+	}
+	r.add(step)
+
+	return err
+}
+
+// Skip 실행하지 않은 단계를 기록합니다.
+func (r *JobRecorder) Skip(name string) {
+	r.add(model.JobStep{Name: name, Status: jobStepSkipped, StartedAt: time.Now()})
+}
+
+// SetFlags 이번 실행에서 계산된 업데이트 플래그를 기록합니다.
+func (r *JobRecorder) SetFlags(flags model.UpdateFlags) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Flags = flags
+}
+
+// Finish 실행 종료 시각과 성공 여부(실패한 단계가 없으면 성공)를 확정하고 실행 기록을 반환합니다.
+func (r *JobRecorder) Finish() model.JobRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.run.FinishedAt = time.Now()
+	r.run.Success = true
+	for _, step := range r.run.Steps {
+		if step.Status == jobStepFailed {
+			r.run.Success = false
+			break
+		}
+	}
+
+	run := r.run
+	run.Steps = append([]model.JobStep(nil), r.run.Steps...)
+	return run
+}
+
+func (r *JobRecorder) add(step model.JobStep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Steps = append(r.run.Steps, step)
+}
+
+// StartJobRun job_runs 테이블에 실행 시작을 기록하고 기록 ID를 반환합니다.
+// 함수가 중간에 종료되면 status가 RUNNING으로 남으므로 비정상 종료를 확인할 수 있습니다.
+func StartJobRun(ctx context.Context, db *sql.DB, recorder *JobRecorder) (int64, error) {
+	if err := ensureJobRunsTable(ctx, db); err != nil {
+		return 0, fmt.Errorf("job_runs 테이블 생성 실패: %w", err)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	recorder.mu.Lock()
+	startedAt := recorder.run.StartedAt
+	recorder.mu.Unlock()
+
+	result, err := db.ExecContext(queryCtx,
+		`INSERT INTO job_runs (started_at, status) VALUES (?, ?)`,
+		startedAt.Format("2006-01-02 15:04:05"), jobRunRunning)
+	if err != nil {
+		return 0, fmt.Errorf("실행 기록 삽입 실패: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("실행 기록 ID 조회 실패: %w", err)
+	}
+
+	recorder.mu.Lock()
+	recorder.run.ID = id
+	recorder.mu.Unlock()
+
+	return id, nil
+}
+
+// FinishJobRun 실행 결과(종료 시각, 플래그, 단계별 결과)를 job_runs 테이블에 기록합니다.
+func FinishJobRun(ctx context.Context, db *sql.DB, run model.JobRun) error {
+	flags, err := json.Marshal(run.Flags)
+	if err != nil {
+		return fmt.Errorf("플래그 직렬화 실패: %w", err)
+	}
+	steps, err := json.Marshal(run.Steps)
+	if err != nil {
+		return fmt.Errorf("단계 결과 직렬화 실패: %w", err)
+	}
+
+	status := jobStepSuccess
+	if !run.Success {
+		status = jobStepFailed
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE job_runs
+		SET finished_at = ?, status = ?, flags = ?, steps = ?
+		WHERE id = ?
+	`
+
+	_, err = db.ExecContext(queryCtx, query,
+		run.FinishedAt.Format("2006-01-02 15:04:05"), status, string(flags), string(steps), run.ID)
+	if err != nil {
+		return fmt.Errorf("실행 기록 업데이트 실패: %w", err)
+	}
+
+	return nil
+}
+
+// job_runs 테이블이 없으면 생성
+func ensureJobRunsTable(ctx context.Context, db *sql.DB) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS job_runs (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME NULL,
+			status VARCHAR(20) NOT NULL,
+			flags JSON NULL,
+			steps JSON NULL,
+			INDEX idx_job_runs_started_at (started_at)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
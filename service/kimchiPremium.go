@@ -0,0 +1,163 @@
+package service
+
+import (
+	"Bitground-go/config"
+	"Bitground-go/model"
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 김치 프리미엄 절댓값 상한(%)
+// 업비트와 CoinGecko 심볼이 같아도 다른 코인일 수 있어, 이보다 크게 벌어진 종목은 저장하지 않습니다.
+const kimchiPremiumMaxAbs = 50
+
+// kimchiPremium 코인 한 개의 원화 가격과 해외 가격 비교
+type kimchiPremium struct {
+	Symbol   string  // 업비트 마켓 코드 (예: KRW-BTC)
+	KRWPrice float64 // 업비트 원화 가격
+	USDPrice float64 // CoinGecko 달러 가격
+	Premium  float64 // KRWPrice / (USDPrice × 환율) - 1 (%)
+}
+
+// UpdateKimchiPremium 함수는 업비트 원화 시세와 CoinGecko 달러 시세를 환율로 비교하여
+// 코인별 김치 프리미엄을 시간마다 kimchi_premium 테이블에 기록합니다.
+// 환율은 cfg에 따라 고정값 또는 업비트 시세(기본 KRW-USDT)를 사용합니다.
+func UpdateKimchiPremium(ctx context.Context, db *sql.DB, tickers []model.UpbitCoinPrice, globalCoins []model.GeckoCoin, cfg config.KimchiConfig) error {
+	// 1. 원/달러 환율
+	fxRate, fxSource, err := kimchiFXRate(tickers, cfg)
+	if err != nil {
+		return err
+	}
+
+	// 2. 코인별 프리미엄 계산
+	premiums := computeKimchiPremiums(tickers, globalCoins, fxRate, cfg.FXMarket)
+	if len(premiums) == 0 {
+		return fmt.Errorf("해외 시세와 비교할 수 있는 코인이 없습니다")
+	}
+
+	// 3. 데이터베이스에 삽입
+	if err := ensureKimchiPremiumTable(ctx, db); err != nil {
+		return fmt.Errorf("김치 프리미엄 테이블 생성 실패: %w", err)
+	}
+	date, hour := indexHour(time.Now())
+	if err := insertKimchiPremiums(ctx, db, date, hour, fxRate, fxSource, premiums); err != nil {
+		return fmt.Errorf("insertKimchiPremiums 에러: %w", err)
+	}
+
+	log.Printf("김치 프리미엄 %d개 코인 기록 (환율 %.2f, %s)\n", len(premiums), fxRate, fxSource)
+	return nil
+}
+
+// 원/달러 환율과 그 출처를 반환합니다.
+func kimchiFXRate(tickers []model.UpbitCoinPrice, cfg config.KimchiConfig) (float64, string, error) {
+	if cfg.FXRate > 0 {
+		return cfg.FXRate, "FIXED", nil
+	}
+
+	for _, ticker := range tickers {
+		if ticker.Market == cfg.FXMarket {
+			if ticker.TradePrice <= 0 {
+				break
+			}
+			return ticker.TradePrice, cfg.FXMarket, nil
+		}
+	}
+	return 0, "", fmt.Errorf("환율 시세(%s)를 찾을 수 없습니다", cfg.FXMarket)
+}
+
+// computeKimchiPremiums 업비트 원화 마켓 중 CoinGecko 심볼과 일치하는 코인의 프리미엄을 계산합니다.
+// 같은 심볼이 여러 개면 시가총액이 가장 큰 코인을 사용하고, 환율로 쓴 마켓은 제외합니다.
+func computeKimchiPremiums(tickers []model.UpbitCoinPrice, globalCoins []model.GeckoCoin, fxRate float64, fxMarket string) []kimchiPremium {
+	bySymbol := geckoCoinsBySymbol(globalCoins)
+
+	premiums := make([]kimchiPremium, 0, len(tickers))
+	for _, ticker := range tickers {
+		if ticker.Market == fxMarket || !strings.HasPrefix(ticker.Market, "KRW-") || ticker.TradePrice <= 0 {
+			continue
+		}
+		coin, ok := bySymbol[strings.ToLower(strings.TrimPrefix(ticker.Market, "KRW-"))]
+		if !ok || coin.CurrentPrice <= 0 {
+			continue
+		}
+
+		premium := (ticker.TradePrice/(coin.CurrentPrice*fxRate) - 1) * 100
+		if math.Abs(premium) > kimchiPremiumMaxAbs {
+			log.Printf("%s 김치 프리미엄 %.2f%%가 상한을 넘어 제외 (다른 코인일 가능성)\n", ticker.Market, premium)
+			continue
+		}
+		premiums = append(premiums, kimchiPremium{
+			Symbol:   ticker.Market,
+			KRWPrice: ticker.TradePrice,
+			USDPrice: coin.CurrentPrice,
+			Premium:  premium,
+		})
+	}
+	sort.Slice(premiums, func(i, j int) bool {
+		return premiums[i].Symbol < premiums[j].Symbol
+	})
+
+	return premiums
+}
+
+// ensureKimchiPremiumTable kimchi_premium 테이블이 없으면 생성
+func ensureKimchiPremiumTable(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS kimchi_premium (
+			symbol VARCHAR(20) NOT NULL,
+			date DATE NOT NULL,
+			hour INT NOT NULL,
+			krw_price DOUBLE NOT NULL,
+			usd_price DOUBLE NOT NULL,
+			fx_rate DOUBLE NOT NULL,
+			fx_source VARCHAR(20) NOT NULL,
+			premium DOUBLE NOT NULL,
+			PRIMARY KEY (symbol, date, hour),
+			INDEX idx_kimchi_premium_date (date, hour)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// 코인별 프리미엄 삽입 (같은 시간에 다시 실행하면 덮어씁니다)
+func insertKimchiPremiums(ctx context.Context, db *sql.DB, date string, hour int, fxRate float64, fxSource string, premiums []kimchiPremium) error {
+	valueStrings := make([]string, 0, len(premiums))
+	valueArgs := make([]interface{}, 0, len(premiums)*8)
+	for _, p := range premiums {
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?, ?, ?)")
+		valueArgs = append(valueArgs, p.Symbol, date, hour, p.KRWPrice, p.USDPrice, fxRate, fxSource, p.Premium)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO kimchi_premium (symbol, date, hour, krw_price, usd_price, fx_rate, fx_source, premium)
+		VALUES %s
+		ON DUPLICATE KEY UPDATE
+			krw_price = VALUES(krw_price),
+			usd_price = VALUES(usd_price),
+			fx_rate = VALUES(fx_rate),
+			fx_source = VALUES(fx_source),
+			premium = VALUES(premium)
+	`, strings.Join(valueStrings, ","))
+
+	// Context를 활용한 쿼리 실행 (타임아웃: 10초)
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(queryCtx, query, valueArgs...); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	util.AddRows(ctx, int64(len(premiums)))
+
+	return nil
+}
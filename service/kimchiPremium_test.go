@@ -0,0 +1,59 @@
+package service
+
+import (
+	"Bitground-go/config"
+	"Bitground-go/model"
+	"testing"
+)
+
+func TestComputeKimchiPremiums(t *testing.T) {
+	tickers := []model.UpbitCoinPrice{
+		{Market: "KRW-USDT", TradePrice: 1400},
+		{Market: "KRW-ETH", TradePrice: 2_856_000},
+		{Market: "KRW-BTC", TradePrice: 70_000_000},
+		{Market: "BTC-ETH", TradePrice: 0.05},
+		{Market: "KRW-SOL", TradePrice: 0},
+		{Market: "KRW-XRP", TradePrice: 1000},
+		{Market: "KRW-NEW", TradePrice: 100},
+	}
+	globalCoins := []model.GeckoCoin{
+		{Symbol: "btc", CurrentPrice: 50_000, MarketCap: 1000},
+		{Symbol: "ETH", CurrentPrice: 2_000, MarketCap: 500},
+		// 같은 심볼의 다른 코인은 시가총액이 가장 큰 종목만 사용
+		{Symbol: "eth", CurrentPrice: 3, MarketCap: 1},
+		{Symbol: "sol", CurrentPrice: 100, MarketCap: 300},
+		// 상한(50%)을 넘게 벌어지면 다른 코인으로 보고 제외
+		{Symbol: "xrp", CurrentPrice: 0.3, MarketCap: 200},
+		{Symbol: "usdt", CurrentPrice: 1, MarketCap: 800},
+	}
+
+	premiums := computeKimchiPremiums(tickers, globalCoins, 1400, "KRW-USDT")
+
+	want := []kimchiPremium{
+		{Symbol: "KRW-BTC", KRWPrice: 70_000_000, USDPrice: 50_000, Premium: 0},
+		{Symbol: "KRW-ETH", KRWPrice: 2_856_000, USDPrice: 2_000, Premium: 2},
+	}
+	if len(premiums) != len(want) {
+		t.Fatalf("premiums = %+v, want %+v", premiums, want)
+	}
+	for i, p := range premiums {
+		w := want[i]
+		if p.Symbol != w.Symbol || p.KRWPrice != w.KRWPrice || p.USDPrice != w.USDPrice || !almostEqual(p.Premium, w.Premium) {
+			t.Errorf("premiums[%d] = %+v, want %+v", i, p, w)
+		}
+	}
+}
+
+func TestKimchiFXRate(t *testing.T) {
+	tickers := []model.UpbitCoinPrice{{Market: "KRW-USDT", TradePrice: 1390}}
+
+	if rate, source, err := kimchiFXRate(tickers, config.KimchiConfig{FXRate: 1380}); err != nil || rate != 1380 || source != "FIXED" {
+		t.Errorf("고정 환율 = %v, %s, %v", rate, source, err)
+	}
+	if rate, source, err := kimchiFXRate(tickers, config.KimchiConfig{FXMarket: "KRW-USDT"}); err != nil || rate != 1390 || source != "KRW-USDT" {
+		t.Errorf("시세 환율 = %v, %s, %v", rate, source, err)
+	}
+	if _, _, err := kimchiFXRate(tickers, config.KimchiConfig{FXMarket: "KRW-USDC"}); err == nil {
+		t.Error("환율 마켓 시세가 없으면 에러가 나야 함")
+	}
+}
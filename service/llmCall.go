@@ -0,0 +1,198 @@
+package service
+
+import (
+	"Bitground-go/llm"
+	"Bitground-go/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// 요청 용도 접두사 (llm_calls.purpose, 재생 시 검증 방식 결정에 사용)
+const (
+	llmPurposeInsight   = "insight:"   // insight:<심볼> (일괄 요청은 insight:ALL)
+	llmPurposeTranslate = "translate:" // translate:<언어>
+)
+
+// ensureLLMCallTable llm_calls 테이블이 없으면 생성
+// 모든 LLM 호출의 프롬프트와 원문 응답을 날짜별로 기록하며, 검증을 통과한(valid = 1) 응답만 같은 날 재실행 시 재사용합니다.
+func ensureLLMCallTable(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS llm_calls (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			call_date DATE NOT NULL,
+			prompt_hash CHAR(64) NOT NULL,
+			purpose VARCHAR(50) NOT NULL DEFAULT '',
+			provider VARCHAR(30) NOT NULL DEFAULT '',
+			model VARCHAR(100) NOT NULL DEFAULT '',
+			prompt MEDIUMTEXT NOT NULL,
+			response MEDIUMTEXT NULL,
+			error TEXT NULL,
+			valid TINYINT(1) NOT NULL DEFAULT 0,
+			duration_ms BIGINT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_llm_calls_prompt (prompt_hash, call_date),
+			INDEX idx_llm_calls_date (call_date)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// LLMCallStore llm_calls 테이블을 사용하는 llm.Cache 구현체
+// 같은 날(call_date) 같은 프롬프트의 확인된 응답만 재사용하므로, 날짜가 바뀌면 다시 호출합니다.
+type LLMCallStore struct {
+	db   *sql.DB
+	date string
+}
+
+// NewLLMCallStore 실행 날짜(runTime, util.RunTime) 기준 LLMCallStore 생성 함수, llm_calls 테이블이 없으면 생성합니다.
+func NewLLMCallStore(ctx context.Context, db *sql.DB, runTime time.Time) (*LLMCallStore, error) {
+	if err := ensureLLMCallTable(ctx, db); err != nil {
+		return nil, fmt.Errorf("llm_calls 테이블 생성 실패: %w", err)
+	}
+	return &LLMCallStore{db: db, date: runTime.Format("2006-01-02")}, nil
+}
+
+// Lookup 실행 날짜에 같은 키(제공자 경로와 프롬프트)로 호출해 검증을 통과한 최신 응답 조회
+func (s *LLMCallStore) Lookup(ctx context.Context, key string) (llm.Response, bool, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, provider, model, response
+		FROM llm_calls
+		WHERE prompt_hash = ? AND call_date = ? AND valid = 1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var resp llm.Response
+	err := s.db.QueryRowContext(queryCtx, query, key, s.date).Scan(&resp.CallID, &resp.Provider, &resp.Model, &resp.Raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return resp, false, nil
+	}
+	if err != nil {
+		return resp, false, fmt.Errorf("LLM 호출 기록 조회 실패: %w", err)
+	}
+	return resp, true, nil
+}
+
+// Save 호출 결과 기록
+func (s *LLMCallStore) Save(ctx context.Context, key string, req llm.Request, resp llm.Response, callErr error, elapsed time.Duration) (int64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var response, errMessage sql.NullString
+	if callErr != nil {
+		errMessage = sql.NullString{String: callErr.Error(), Valid: true}
+	} else {
+		response = sql.NullString{String: resp.Raw, Valid: true}
+	}
+
+	insertQuery := `
+		INSERT INTO llm_calls (call_date, prompt_hash, purpose, provider, model, prompt, response, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.ExecContext(queryCtx, insertQuery,
+		s.date, key, req.Purpose, resp.Provider, resp.Model, req.Prompt, response, errMessage, elapsed.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("LLM 호출 기록 삽입 실패: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// Confirm 검증을 통과한 호출 표시
+func (s *LLMCallStore) Confirm(ctx context.Context, callID int64) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(queryCtx, `UPDATE llm_calls SET valid = 1 WHERE id = ?`, callID); err != nil {
+		return fmt.Errorf("LLM 호출 검증 표시 실패: %w", err)
+	}
+	return nil
+}
+
+// 재생 시 용도에 맞는 검증 함수를 반환합니다.
+func replayValidator(purpose string) insightValidator {
+	switch {
+	case purpose == llmPurposeInsight+insightBatchSymbol:
+		return func(insights []Insight) []string {
+			return validateInsights(insights, nil)
+		}
+	case strings.HasPrefix(purpose, llmPurposeInsight):
+		symbol := strings.TrimPrefix(purpose, llmPurposeInsight)
+		return func(insights []Insight) []string {
+			return validateTargetInsight(symbol, insights)
+		}
+	case strings.HasPrefix(purpose, llmPurposeTranslate):
+		// 원문은 기록되지 않으므로 본문 길이만 검증
+		return func(insights []Insight) []string {
+			var problems []string
+			for i, insight := range insights {
+				problems = append(problems, validateInsightText(fmt.Sprintf("%d번째 항목(%s)", i+1, insight.Symbol), insight.Insight)...)
+			}
+			return problems
+		}
+	default:
+		return func([]Insight) []string { return nil }
+	}
+}
+
+// ReplayLLMCalls 기록된 응답을 현재 파서와 검증 규칙으로 다시 파싱합니다. 제공자는 호출하지 않습니다.
+// callID가 0보다 크면 해당 호출만, 아니면 date(YYYY-MM-DD)의 응답이 있는 모든 호출을 재생합니다.
+func ReplayLLMCalls(ctx context.Context, db *sql.DB, callID int64, date string) ([]model.LLMReplayResult, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, purpose, provider, model, response
+		FROM llm_calls
+		WHERE call_date = ? AND response IS NOT NULL
+		ORDER BY id
+	`
+	args := []interface{}{date}
+	if callID > 0 {
+		query = `
+			SELECT id, purpose, provider, model, COALESCE(response, '')
+			FROM llm_calls
+			WHERE id = ?
+		`
+		args = []interface{}{callID}
+	}
+
+	rows, err := db.QueryContext(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	results := []model.LLMReplayResult{}
+	for rows.Next() {
+		var result model.LLMReplayResult
+		var raw string
+		if err := rows.Scan(&result.CallID, &result.Purpose, &result.Provider, &result.Model, &raw); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+
+		insights, problems := parseInsights(llm.ExtractJSON(raw), replayValidator(result.Purpose))
+		result.Insights = len(insights)
+		result.Problems = problems
+		result.Valid = len(problems) == 0
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
@@ -1,85 +1,183 @@
 package service
 
 import (
+	"Bitground-go/model"
+	"Bitground-go/util"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 )
 
-// GeckoCoin API에서 사용하는 코인 마켓 캡 정보를 나타내는 구조체
-type GeckoCoin struct {
-	Symbol    string `json:"symbol"`
-	MarketCap int64  `json:"market_cap"`
+// CoinGecko coins/markets API 한 페이지 최대 종목 수
+const geckoMaxPerPage = 250
+
+// geckoCoinsBySymbol 소문자 심볼별 종목 목록을 만듭니다.
+// CoinGecko 심볼은 유일하지 않으므로 같은 심볼이 여러 개면 시가총액이 가장 큰 종목을 사용합니다.
+func geckoCoinsBySymbol(coins []model.GeckoCoin) map[string]model.GeckoCoin {
+	bySymbol := make(map[string]model.GeckoCoin, len(coins))
+	for _, coin := range coins {
+		symbol := strings.ToLower(coin.Symbol)
+		if prev, ok := bySymbol[symbol]; !ok || coin.MarketCap > prev.MarketCap {
+			bySymbol[symbol] = coin
+		}
+	}
+	return bySymbol
 }
 
-// UpdateMarketIndex 함수는 상위 10개 코인의 마켓 캡 정보를 가져와서 마켓 인덱스와 알트 인덱스를 계산하고, 이를 데이터베이스에 삽입합니다.
-func UpdateMarketIndex(ctx context.Context, db *sql.DB) error {
-	// 1. CoinGecko API를 사용하여 상위 10개 코인의 마켓 캡 정보를 가져옵니다.
-	coinCaps, err := getMarketCap(ctx)
+// UpdateMarketIndex 함수는 활성 마켓 인덱스 정의에 따라 구성 종목의 마켓 캡 정보를 가져와서 마켓 인덱스와 알트 인덱스를 계산하고,
+// 사용한 정의 버전과 함께 데이터베이스에 삽입합니다.
+// 구성 종목이 바뀌어도 값이 끊기지 않도록 divisor를 조정하며, 시간별 구성 종목은 market_index_constituents에 기록합니다.
+// 섹터 인덱스(sector_indices)도 같은 데이터와 계산 방식으로 함께 갱신합니다.
+// 조회한 CoinGecko 시세는 김치 프리미엄 계산에도 쓰이도록 인덱스 계산에 실패해도 함께 반환합니다.
+func UpdateMarketIndex(ctx context.Context, db *sql.DB, geckoBaseURL string) ([]model.GeckoCoin, error) {
+	// 1. 인덱스 정의를 불러옵니다.
+	if err := ensureIndexDefinitionTable(ctx, db); err != nil {
+		return nil, fmt.Errorf("인덱스 정의 테이블 생성 실패: %w", err)
+	}
+	if err := ensureIndexDefinitionColumns(ctx, db); err != nil {
+		return nil, err
+	}
+	def, err := loadIndexDefinition(ctx, db, marketIndexCode, defaultMarketIndexDefinition())
 	if err != nil {
-		return fmt.Errorf("getMarketCap 에러: %w", err)
+		return nil, err
 	}
 
-	// 2. 마켓 인덱스와 알트 인덱스를 계산합니다.
-	marketIndex, altIndex := calcMarketIndex(coinCaps)
+	if err := ensureSectorTables(ctx, db); err != nil {
+		return nil, fmt.Errorf("섹터 테이블 생성 실패: %w", err)
+	}
+	sectors, err := loadSectors(ctx, db)
+	if err != nil {
+		return nil, err
+	}
 
-	// 3. 데이터베이스에 마켓 인덱스와 알트 인덱스를 삽입합니다.
-	if err := insertMarketIndex(ctx, db, marketIndex, altIndex); err != nil {
-		return fmt.Errorf("insertMarketIndex 에러: %w", err)
+	// 2. CoinGecko API를 사용하여 시가총액 상위 코인의 마켓 캡 정보를 가져옵니다.
+	// (섹터 종목과 김치 프리미엄 비교 대상도 찾도록 한 페이지 최대 종목 수만큼)
+	coinCaps, err := getMarketCap(ctx, geckoBaseURL, geckoMaxPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("getMarketCap 에러: %w", err)
 	}
 
-	return nil
+	// 3. 마켓 인덱스와 알트 인덱스(1위 종목 제외)를 divisor를 조정하며 계산합니다.
+	constituents := selectConstituents(def, coinCaps)
+	if len(constituents) < 2 {
+		return coinCaps, fmt.Errorf("인덱스 구성 종목이 부족합니다 (%d개)", len(constituents))
+	}
+	altDef := def
+	altDef.Code = altIndexCode
+
+	if err := ensureIndexLevelTables(ctx, db); err != nil {
+		return coinCaps, fmt.Errorf("인덱스 기록 테이블 생성 실패: %w", err)
+	}
+	date, hour := indexHour(time.Now())
+	levels, err := computeIndexLevels(ctx, db, date, hour, []indexTarget{
+		{def, constituents},
+		{altDef, constituents[1:]},
+	}, coinCaps)
+	if err != nil {
+		return coinCaps, err
+	}
+
+	// 4. 데이터베이스에 인덱스 값과 구성 종목, 마켓 인덱스와 알트 인덱스를 삽입합니다.
+	if err := saveIndexLevels(ctx, db, date, hour, levels); err != nil {
+		return coinCaps, fmt.Errorf("saveIndexLevels 에러: %w", err)
+	}
+	if err := ensureColumn(ctx, db, "market_indices", "definition_version", "INT NOT NULL DEFAULT 1"); err != nil {
+		return coinCaps, err
+	}
+	if err := insertMarketIndex(ctx, db, date, hour, levels[0].Value, levels[1].Value, def.Version); err != nil {
+		return coinCaps, fmt.Errorf("insertMarketIndex 에러: %w", err)
+	}
+
+	// 5. 섹터 인덱스를 같은 방식으로 계산하여 삽입합니다. (상위 목록 밖의 섹터 종목은 CoinGecko id로 추가 조회)
+	sectorCoins, err := sectorUniverse(ctx, db, geckoBaseURL, sectors, coinCaps)
+	if err != nil {
+		return coinCaps, fmt.Errorf("섹터 종목 시가총액 조회 실패: %w", err)
+	}
+	if err := updateSectorIndices(ctx, db, sectors, sectorCoins, date, hour); err != nil {
+		return coinCaps, fmt.Errorf("섹터 인덱스 에러: %w", err)
+	}
+
+	return coinCaps, nil
 }
 
-// getMarketCap 함수는 CoinGecko API를 사용하여 상위 10개 코인의 마켓 캡 정보를 가져옵니다.
-func getMarketCap(ctx context.Context) ([]GeckoCoin, error) {
-	apiURL := "https://api.coingecko.com/api/v3/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=50"
-
-	// 제외할 종목 심볼 목록 (string 배열)
-	excludedSymbols := []string{
-		"usdt",
-		"bnb",
-		"usdc",
-		"steth",
-		"wbtc",
-		"wsteth",
-		"leo",
-		"usds",
-		"weth",
-		"weeth",
-		"bsc-usd",
-		"bgb",
-		"usde",
-		"cbbtc",
-		"wbt",
-		"okb",
-		"jitosol",
-		"susde",
-		"tkx",
-		"buidl",
-		"ondo",
-		"cro",
+// getMarketCap 함수는 CoinGecko API를 사용하여 시가총액 상위 코인의 마켓 캡 정보를 가져옵니다.
+// 제외 종목을 걸러낸 뒤에도 편입 종목 수를 채울 수 있도록 minCount개 이상(최소 50개, 최대 250개)을 요청합니다.
+func getMarketCap(ctx context.Context, baseURL string, minCount int) ([]model.GeckoCoin, error) {
+	perPage := minCount
+	if perPage < 50 {
+		perPage = 50
+	} else if perPage > geckoMaxPerPage {
+		perPage = geckoMaxPerPage
 	}
+	apiURL := fmt.Sprintf("%s/api/v3/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=%d", baseURL, perPage)
+
+	var coins []model.GeckoCoin
+	if err := getGeckoJSON(ctx, apiURL, &coins); err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
+// getMarketCapByIDs 함수는 CoinGecko 코인 id로 시가총액 상위 목록 밖의 코인 마켓 캡 정보를 가져옵니다. (한 번에 최대 250개씩 요청)
+func getMarketCapByIDs(ctx context.Context, baseURL string, ids []string) ([]model.GeckoCoin, error) {
+	var coins []model.GeckoCoin
+	for start := 0; start < len(ids); start += geckoMaxPerPage {
+		end := start + geckoMaxPerPage
+		if end > len(ids) {
+			end = len(ids)
+		}
+		apiURL := fmt.Sprintf("%s/api/v3/coins/markets?vs_currency=usd&ids=%s&per_page=%d",
+			baseURL, url.QueryEscape(strings.Join(ids[start:end], ",")), geckoMaxPerPage)
+
+		var page []model.GeckoCoin
+		if err := getGeckoJSON(ctx, apiURL, &page); err != nil {
+			return nil, err
+		}
+		coins = append(coins, page...)
+	}
+	return coins, nil
+}
 
+// geckoListCoin CoinGecko coins/list API의 코인 id와 심볼
+type geckoListCoin struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+}
+
+// getGeckoCoinList 함수는 CoinGecko에 등록된 전체 코인의 id와 심볼을 가져옵니다.
+func getGeckoCoinList(ctx context.Context, baseURL string) ([]geckoListCoin, error) {
+	var coins []geckoListCoin
+	if err := getGeckoJSON(ctx, baseURL+"/api/v3/coins/list", &coins); err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
+// getGeckoJSON 함수는 CoinGecko API를 호출하여 JSON 응답을 v에 디코딩합니다.
+func getGeckoJSON(ctx context.Context, apiURL string, v interface{}) error {
 	// Context를 활용한 HTTP 요청 (타임아웃: 30초)
 	reqCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(reqCtx, "GET", apiURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP 요청 생성 에러: %w", err)
+		return fmt.Errorf("HTTP 요청 생성 에러: %w", err)
 	}
 
 	// API 요청 보내기
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("API 요청 에러: %w", err)
+		return fmt.Errorf("API 요청 에러: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		// 응답 본문을 닫아 리소스 누수 방지
@@ -88,79 +186,138 @@ func getMarketCap(ctx context.Context) ([]GeckoCoin, error) {
 		}
 	}(resp.Body)
 
-	// JSON 응답을 Coin 슬라이스로 디코딩
-	var coins []GeckoCoin
-	err = json.NewDecoder(resp.Body).Decode(&coins)
-	if err != nil {
-		return nil, fmt.Errorf("JSON 디코딩 에러: %w", err)
+	// JSON 응답 디코딩
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("JSON 디코딩 에러: %w", err)
 	}
+	return nil
+}
 
-	// 제외할 종목 필터링
-	var filteredCoins []GeckoCoin
+// selectConstituents 제외 종목을 걸러낸 뒤 시가총액 상위 def.ConstituentCount개를 시가총액 순으로 반환합니다.
+func selectConstituents(def model.IndexDefinition, coins []model.GeckoCoin) []model.GeckoCoin {
+	excluded := make(map[string]bool, len(def.Excluded))
+	for _, symbol := range def.Excluded {
+		excluded[strings.ToLower(symbol)] = true
+	}
+
+	constituents := make([]model.GeckoCoin, 0, def.ConstituentCount)
 	for _, coin := range coins {
-		isExcluded := false
-		for _, excludedSymbol := range excludedSymbols {
-			if coin.Symbol == excludedSymbol {
-				isExcluded = true
-				break
-			}
-		}
-		if !isExcluded {
-			filteredCoins = append(filteredCoins, coin)
+		if excluded[strings.ToLower(coin.Symbol)] || coin.MarketCap <= 0 {
+			continue
 		}
+		constituents = append(constituents, coin)
+	}
+	sort.SliceStable(constituents, func(i, j int) bool {
+		return constituents[i].MarketCap > constituents[j].MarketCap
+	})
+
+	if len(constituents) > def.ConstituentCount {
+		constituents = constituents[:def.ConstituentCount]
+	}
+	return constituents
+}
+
+// indexMarketValue 함수는 정의의 가중 방식에 따라 구성 종목의 가중 시가총액을 계산합니다. (divisor 적용 전)
+//   - 시가총액 가중: 시가총액 합
+//   - 동일/상한 가중: exp(Σ wᵢ·ln(capᵢ/wᵢ)) (가중 기하평균)
+//
+// 가중 기하평균은 wᵢ가 시가총액 비중이면 시가총액 합과 같아지므로, 가중 방식을 바꿔도 값의 규모가 비슷하게 유지됩니다.
+func indexMarketValue(def model.IndexDefinition, constituents []model.GeckoCoin) float64 {
+	if len(constituents) == 0 {
+		return 0
 	}
 
-	// 상위 10개 종목 저장
-	top10Coins := make([]GeckoCoin, 0, 10)
-	for i, coin := range filteredCoins {
-		if i >= 10 {
-			break
+	if def.Weighting == model.WeightingCap {
+		var total int64
+		for _, coin := range constituents {
+			total += coin.MarketCap
 		}
-		top10Coins = append(top10Coins, coin)
+		return float64(total)
 	}
 
-	// 결과 반환
-	return top10Coins, nil
+	weights := indexWeights(def, constituents)
+	var logValue float64
+	for i, coin := range constituents {
+		logValue += weights[i] * math.Log(float64(coin.MarketCap)/weights[i])
+	}
+	return math.Exp(logValue)
 }
 
-// calcMarketIndex 함수는 상위 10개 코인의 마켓 캡 정보를 기반으로 마켓 인덱스와 알트 인덱스를 계산합니다.
-func calcMarketIndex(coinCaps []GeckoCoin) (int, int) {
-	marketIndex := 0
-	altIndex := 0
-	for i, coinCap := range coinCaps {
-		if i > 0 {
-			altIndex += int(coinCap.MarketCap)
+// indexWeights 구성 종목별 비중 (합계 1)
+func indexWeights(def model.IndexDefinition, constituents []model.GeckoCoin) []float64 {
+	n := len(constituents)
+	weights := make([]float64, n)
+
+	// 동일 가중이거나, 상한 가중인데 상한이 너무 낮아 비중 합을 1로 맞출 수 없으면 동일 비중
+	if def.Weighting == model.WeightingEqual || (def.Weighting == model.WeightingCapped && def.MaxWeight*float64(n) < 1) {
+		for i := range weights {
+			weights[i] = 1 / float64(n)
 		}
-		marketIndex += int(coinCap.MarketCap)
+		return weights
 	}
-	marketIndex /= 100000000
-	altIndex /= 100000000
-	return marketIndex, altIndex
-}
 
-// insertMarketIndex 함수는 계산된 마켓 인덱스와 알트 인덱스를 데이터베이스에 삽입합니다.
-func insertMarketIndex(ctx context.Context, db *sql.DB, marketIndex, altIndex int) error {
-	// 현재 날짜와 시간을 가져옵니다.
-	now := time.Now().Round(time.Hour)
-	date := now.Format("2006-01-02")
-	hour := now.Hour()
+	var total float64
+	for _, coin := range constituents {
+		total += float64(coin.MarketCap)
+	}
+	for i, coin := range constituents {
+		weights[i] = float64(coin.MarketCap) / total
+	}
+	if def.Weighting != model.WeightingCapped {
+		return weights
+	}
+
+	// 상한을 넘는 종목을 상한으로 고정하고, 남는 비중을 나머지 종목에 시가총액 비율로 재분배 (넘는 종목이 없을 때까지 반복)
+	capped := make([]bool, n)
+	for {
+		var cappedWeight, freeCap float64
+		for i, coin := range constituents {
+			if capped[i] {
+				cappedWeight += def.MaxWeight
+			} else {
+				freeCap += float64(coin.MarketCap)
+			}
+		}
+
+		changed := false
+		for i, coin := range constituents {
+			if capped[i] {
+				weights[i] = def.MaxWeight
+				continue
+			}
+			weights[i] = (1 - cappedWeight) * float64(coin.MarketCap) / freeCap
+			if weights[i] > def.MaxWeight {
+				capped[i] = true
+				changed = true
+			}
+		}
+		if !changed {
+			return weights
+		}
+	}
+}
 
+// insertMarketIndex 함수는 계산된 마켓 인덱스와 알트 인덱스를 정의 버전과 함께 데이터베이스에 삽입합니다.
+func insertMarketIndex(ctx context.Context, db *sql.DB, date string, hour int, marketIndex, altIndex float64, definitionVersion int) error {
 	// 데이터베이스에 마켓 인덱스와 알트 인덱스를 업데이트하는 쿼리
 	query := `
-		INSERT INTO market_indices (date, hour, market_index, alt_index)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO market_indices (date, hour, market_index, alt_index, definition_version)
+		VALUES (?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
     		market_index = VALUES(market_index),
-    		alt_index = VALUES(alt_index);
+    		alt_index = VALUES(alt_index),
+    		definition_version = VALUES(definition_version);
 	`
 	// Context를 활용한 쿼리 실행 (타임아웃: 10초)
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	_, err := db.ExecContext(queryCtx, query, date, hour, marketIndex, altIndex)
+	_, err := db.ExecContext(queryCtx, query, date, hour,
+		int64(math.Round(marketIndex)), int64(math.Round(altIndex)), definitionVersion)
 	if err != nil {
 		return fmt.Errorf("쿼리 실행 에러: %w", err)
 	}
+	util.AddRows(ctx, 1)
 
 	return nil
 }
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"math"
+	"testing"
+)
+
+func testCoins(caps ...int64) []model.GeckoCoin {
+	symbols := []string{"btc", "eth", "sol", "xrp", "ada", "doge"}
+	coins := make([]model.GeckoCoin, len(caps))
+	for i, c := range caps {
+		coins[i] = model.GeckoCoin{ID: symbols[i], Symbol: symbols[i], MarketCap: c}
+	}
+	return coins
+}
+
+func TestIndexWeights(t *testing.T) {
+	coins := testCoins(600, 300, 100)
+	tests := []struct {
+		name string
+		def  model.IndexDefinition
+		want []float64
+	}{
+		{"시가총액 가중", model.IndexDefinition{Weighting: model.WeightingCap}, []float64{0.6, 0.3, 0.1}},
+		{"동일 가중", model.IndexDefinition{Weighting: model.WeightingEqual}, []float64{1. / 3, 1. / 3, 1. / 3}},
+		// 0.6 → 0.5로 고정, 남은 0.5를 300:100으로 재분배
+		{"상한 가중", model.IndexDefinition{Weighting: model.WeightingCapped, MaxWeight: 0.5}, []float64{0.5, 0.375, 0.125}},
+		// 0.6 → 0.4 고정 후 0.45가 다시 상한을 넘어 0.4 고정, 나머지 0.2
+		{"상한 가중 반복", model.IndexDefinition{Weighting: model.WeightingCapped, MaxWeight: 0.4}, []float64{0.4, 0.4, 0.2}},
+		{"상한이 너무 낮으면 동일 비중", model.IndexDefinition{Weighting: model.WeightingCapped, MaxWeight: 0.2}, []float64{1. / 3, 1. / 3, 1. / 3}},
+		{"상한을 넘는 종목 없음", model.IndexDefinition{Weighting: model.WeightingCapped, MaxWeight: 0.7}, []float64{0.6, 0.3, 0.1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights := indexWeights(tt.def, coins)
+			var total float64
+			for i, w := range weights {
+				total += w
+				if !almostEqual(w, tt.want[i]) {
+					t.Errorf("weights[%d] = %v, want %v", i, w, tt.want[i])
+				}
+			}
+			if !almostEqual(total, 1) {
+				t.Errorf("비중 합 = %v, want 1", total)
+			}
+		})
+	}
+}
+
+func TestIndexMarketValue(t *testing.T) {
+	coins := testCoins(600, 300, 100)
+
+	if got := indexMarketValue(model.IndexDefinition{Weighting: model.WeightingCap}, coins); got != 1000 {
+		t.Errorf("시가총액 가중 = %v, want 1000", got)
+	}
+	// 동일 가중: exp(Σ ln(capᵢ·3)/3) = 3·(600·300·100)^(1/3)
+	want := 3 * math.Cbrt(600*300*100)
+	if got := indexMarketValue(model.IndexDefinition{Weighting: model.WeightingEqual}, coins); !almostEqual(got, want) {
+		t.Errorf("동일 가중 = %v, want %v", got, want)
+	}
+	// 비중이 시가총액 비중과 같으면 가중 기하평균은 시가총액 합과 같음
+	if got := indexMarketValue(model.IndexDefinition{Weighting: model.WeightingCapped, MaxWeight: 1}, coins); !almostEqual(got, 1000) {
+		t.Errorf("상한 없는 상한 가중 = %v, want 1000", got)
+	}
+	if got := indexMarketValue(model.IndexDefinition{Weighting: model.WeightingCap}, nil); got != 0 {
+		t.Errorf("구성 종목이 없으면 0이어야 함: %v", got)
+	}
+}
+
+func TestSelectConstituents(t *testing.T) {
+	coins := []model.GeckoCoin{
+		{Symbol: "eth", MarketCap: 300},
+		{Symbol: "USDT", MarketCap: 500},
+		{Symbol: "btc", MarketCap: 600},
+		{Symbol: "dead", MarketCap: 0},
+		{Symbol: "sol", MarketCap: 100},
+	}
+	def := model.IndexDefinition{ConstituentCount: 2, Excluded: []string{"usdt"}}
+
+	got := selectConstituents(def, coins)
+	if len(got) != 2 || got[0].Symbol != "btc" || got[1].Symbol != "eth" {
+		t.Errorf("selectConstituents = %+v, want [btc eth]", got)
+	}
+}
+
+func TestGeckoCoinsBySymbol(t *testing.T) {
+	coins := []model.GeckoCoin{
+		{ID: "bitcoin", Symbol: "BTC", MarketCap: 600},
+		{ID: "fake-btc", Symbol: "btc", MarketCap: 5},
+		{ID: "ethereum", Symbol: "eth", MarketCap: 300},
+		{ID: "eth-peg", Symbol: "ETH", MarketCap: 400},
+	}
+
+	bySymbol := geckoCoinsBySymbol(coins)
+	if len(bySymbol) != 2 {
+		t.Fatalf("심볼 수 = %d, want 2", len(bySymbol))
+	}
+	// 같은 심볼은 순서와 관계없이 시가총액이 가장 큰 종목
+	if got := bySymbol["btc"].ID; got != "bitcoin" {
+		t.Errorf("btc = %s, want bitcoin", got)
+	}
+	if got := bySymbol["eth"].ID; got != "eth-peg" {
+		t.Errorf("eth = %s, want eth-peg", got)
+	}
+}
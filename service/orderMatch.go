@@ -0,0 +1,423 @@
+package service
+
+import (
+	"Bitground-go/exchange"
+	"Bitground-go/model"
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// 잔고 부족으로 체결할 수 없는 주문을 나타내는 에러
+var errInsufficientBalance = errors.New("잔고 부족")
+
+// 이미 다른 곳(스프링 서버 등)에서 처리되어 더 이상 대기중이 아닌 주문을 나타내는 에러
+var errOrderNotPending = errors.New("대기중인 주문이 아님")
+
+// orderPriceRange 예약 주문 체결 판단에 쓰는 가격 구간
+type orderPriceRange struct {
+	Low, High float64
+}
+
+// orderMatchMark 직전 예약 주문 체결 실행 시각과 그때까지 있던 주문 id의 최댓값 (시즌별)
+// orders에는 주문 시각이 없어, 이 값으로 직전 실행 이후에 들어온 주문을 구분합니다.
+type orderMatchMark struct {
+	RunAt      time.Time
+	MaxOrderID int64
+}
+
+// MatchPendingOrders 지정가에 도달한 예약 주문들을 체결합니다.
+// 직전 실행 때 이미 있던 주문은 직전 실행 이후의 시간봉과 현재가로 만든 가격 구간으로,
+// 그 뒤에 들어온 주문은 주문 전 가격으로 체결되지 않도록 현재가로만 체결 여부를 판단합니다.
+// 시세의 일중 고가/저가는 주문 전 가격까지 포함하므로 사용하지 않습니다.
+// 체결된 주문 수를 반환합니다.
+func MatchPendingOrders(ctx context.Context, db *sql.DB, source exchange.MarketDataSource, seasonID int,
+	coinPriceHistory map[int]model.UpbitCoinPrice, obj map[string]interface{}) (int, error) {
+	now, err := util.RunTime(obj)
+	if err != nil {
+		return 0, err
+	}
+
+	// 1. 직전 실행 기록 조회
+	if err := ensureOrderMatchMarkTable(ctx, db); err != nil {
+		return 0, fmt.Errorf("order_match_marks 테이블 생성 실패: %w", err)
+	}
+	mark, err := getOrderMatchMark(ctx, db, seasonID)
+	if err != nil {
+		return 0, fmt.Errorf("직전 체결 기록 조회 실패: %w", err)
+	}
+
+	// 2. 현 시즌의 예약 주문 조회
+	orders, err := getPendingOrders(ctx, db, seasonID)
+	if err != nil {
+		return 0, fmt.Errorf("예약 주문 조회 실패: %w", err)
+	}
+
+	// 3. 직전 실행 때 있던 주문의 코인은 그 이후의 가격 구간, 새 주문은 현재가만 사용
+	var existing []model.PendingOrder
+	maxOrderID := mark.MaxOrderID
+	for _, order := range orders {
+		if order.ID <= mark.MaxOrderID {
+			existing = append(existing, order)
+		} else if order.ID > maxOrderID {
+			maxOrderID = order.ID
+		}
+	}
+	ranges := loadOrderPriceRanges(ctx, source, existing, coinPriceHistory, mark.RunAt, now)
+
+	// 4. 체결 조건을 만족하는 주문을 하나씩 트랜잭션으로 체결
+	filled := 0
+	for _, order := range orders {
+		r, exists := ranges[order.SymbolID]
+		if order.ID > mark.MaxOrderID {
+			r, exists = currentPriceRange(coinPriceHistory, order.SymbolID)
+		}
+		if !exists {
+			continue
+		}
+
+		price, ok := matchOrderPrice(order, r)
+		if !ok {
+			continue
+		}
+
+		err := fillOrder(ctx, db, order, price)
+		if errors.Is(err, errInsufficientBalance) || errors.Is(err, errOrderNotPending) {
+			log.Printf("주문 체결 생략 (order_id: %d): %v\n", order.ID, err)
+			continue
+		} else if err != nil {
+			return filled, fmt.Errorf("주문 체결 실패 (order_id: %d): %w", order.ID, err)
+		}
+		filled++
+		util.AddRows(ctx, 1)
+	}
+
+	// 5. 다음 실행의 기준 기록 (체결 중 실패하면 남기지 않아 다음 실행이 같은 구간부터 다시 확인)
+	if err := saveOrderMatchMark(ctx, db, seasonID, orderMatchMark{RunAt: now, MaxOrderID: maxOrderID}); err != nil {
+		return filled, fmt.Errorf("체결 기록 저장 실패: %w", err)
+	}
+
+	log.Printf("예약 주문 체결 완료: %d/%d건 (새 주문 %d건)\n", filled, len(orders), len(orders)-len(existing))
+	return filled, nil
+}
+
+// ensureOrderMatchMarkTable order_match_marks 테이블이 없으면 생성
+func ensureOrderMatchMarkTable(ctx context.Context, db *sql.DB) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS order_match_marks (
+			season_id INT PRIMARY KEY,
+			run_at DATETIME NOT NULL,
+			max_order_id BIGINT NOT NULL DEFAULT 0
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// 시즌의 직전 체결 기록을 조회합니다. 기록이 없으면 빈 기록(모든 주문이 새 주문)을 반환합니다.
+func getOrderMatchMark(ctx context.Context, db *sql.DB, seasonID int) (orderMatchMark, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var mark orderMatchMark
+	err := db.QueryRowContext(queryCtx, `SELECT run_at, max_order_id FROM order_match_marks WHERE season_id = ?`, seasonID).
+		Scan(&mark.RunAt, &mark.MaxOrderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return orderMatchMark{}, nil
+	} else if err != nil {
+		return mark, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+
+	return mark, nil
+}
+
+// 체결 기록을 저장합니다. 과거 시각으로 다시 실행해도 기록이 뒤로 돌아가지 않습니다.
+func saveOrderMatchMark(ctx context.Context, db *sql.DB, seasonID int, mark orderMatchMark) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO order_match_marks (season_id, run_at, max_order_id)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			run_at = GREATEST(run_at, VALUES(run_at)),
+			max_order_id = GREATEST(max_order_id, VALUES(max_order_id))
+	`
+
+	if _, err := db.ExecContext(queryCtx, query, seasonID, mark.RunAt, mark.MaxOrderID); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+
+	return nil
+}
+
+// 현재가만으로 만든 가격 구간
+func currentPriceRange(tickers map[int]model.UpbitCoinPrice, symbolID int) (orderPriceRange, bool) {
+	ticker, exists := tickers[symbolID]
+	if !exists || ticker.TradePrice <= 0 {
+		return orderPriceRange{}, false
+	}
+	return orderPriceRange{Low: ticker.TradePrice, High: ticker.TradePrice}, true
+}
+
+// loadOrderPriceRanges 예약 주문이 있는 코인별로 since부터 now가 속한 시간 직전까지의 완성 시간봉 저가~고가에
+// 현재가를 더한 구간을 반환합니다. 조회할 시간봉이 없거나(since가 비었거나 같은 시간),
+// 시간봉 조회에 실패했거나, 거래가 없어 시간봉이 없으면 현재가만 사용합니다.
+// 한 번에 조회할 수 있는 시간봉(upbitMaxCandleCount개)보다 오래된 구간은 보지 않습니다.
+func loadOrderPriceRanges(ctx context.Context, source exchange.MarketDataSource, orders []model.PendingOrder,
+	tickers map[int]model.UpbitCoinPrice, since, now time.Time) map[int]orderPriceRange {
+	to := now.Truncate(time.Hour)
+	count := 0
+	if !since.IsZero() && since.Before(to) {
+		count = int(to.Sub(since.Truncate(time.Hour)) / time.Hour)
+		if count > upbitMaxCandleCount {
+			count = upbitMaxCandleCount
+		}
+	}
+	ranges := make(map[int]orderPriceRange)
+
+	throttle := time.NewTicker(upbitCandleInterval)
+	defer throttle.Stop()
+
+	fetched := false
+	for _, order := range orders {
+		if _, done := ranges[order.SymbolID]; done {
+			continue
+		}
+		r, exists := currentPriceRange(tickers, order.SymbolID)
+		if !exists {
+			continue
+		}
+		ranges[order.SymbolID] = r
+		if count == 0 {
+			continue
+		}
+
+		if fetched {
+			select {
+			case <-ctx.Done():
+				return ranges
+			case <-throttle.C:
+			}
+		}
+		fetched = true
+
+		market := tickers[order.SymbolID].Market
+		candles, err := source.FetchCandles(ctx, market, upbitHourUnit, to, count)
+		if err != nil {
+			log.Printf("%s 직전 실행 이후 시간봉 조회 실패, 현재가로 체결 판단: %v\n", market, err)
+			continue
+		}
+		for _, candle := range candles {
+			r = r.extend(candle.LowPrice, candle.HighPrice)
+		}
+		ranges[order.SymbolID] = r
+	}
+
+	return ranges
+}
+
+// 가격 구간에 저가~고가를 합칩니다. (0 이하 가격은 무시)
+func (r orderPriceRange) extend(low, high float64) orderPriceRange {
+	if low > 0 && low < r.Low {
+		r.Low = low
+	}
+	if high > r.High {
+		r.High = high
+	}
+	return r
+}
+
+// 현 시즌의 예약 주문 목록을 조회합니다.
+func getPendingOrders(ctx context.Context, db *sql.DB, seasonID int) ([]model.PendingOrder, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, symbol_id, amount, trade_price, order_type
+		FROM orders
+		WHERE season_id = ? AND status = 'PENDING'
+		ORDER BY id
+	`
+
+	rows, err := db.QueryContext(queryCtx, query, seasonID)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	var orders []model.PendingOrder
+	for rows.Next() {
+		var order model.PendingOrder
+		if err := rows.Scan(&order.ID, &order.UserID, &order.SymbolID, &order.Amount, &order.TradePrice, &order.OrderType); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("행 반복 에러: %w", err)
+	}
+
+	return orders, nil
+}
+
+// matchOrderPrice 가격 구간(저가~고가)이 지정가를 지나갔는지 확인하고 체결가를 반환합니다.
+// 매수는 저가가 지정가 이하, 매도는 고가가 지정가 이상일 때 지정가로 체결됩니다.
+func matchOrderPrice(order model.PendingOrder, r orderPriceRange) (float64, bool) {
+	switch order.OrderType {
+	case "BUY":
+		if r.Low > 0 && r.Low <= order.TradePrice {
+			return order.TradePrice, true
+		}
+	case "SELL":
+		if r.High > 0 && r.High >= order.TradePrice {
+			return order.TradePrice, true
+		}
+	}
+	return 0, false
+}
+
+// 주문 하나를 체결합니다. 현금, 보유 자산, 주문 상태 변경은 하나의 트랜잭션으로 처리됩니다.
+func fillOrder(ctx context.Context, db *sql.DB, order model.PendingOrder, price float64) (err error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 트랜잭션 시작
+	tx, err := db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 에러: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// 주문이 아직 대기중인지 잠금과 함께 확인
+	var status string
+	err = tx.QueryRowContext(queryCtx, `SELECT status FROM orders WHERE id = ? FOR UPDATE`, order.ID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errOrderNotPending
+	} else if err != nil {
+		return fmt.Errorf("주문 상태 조회 실패: %w", err)
+	}
+	if status != "PENDING" {
+		return errOrderNotPending
+	}
+
+	total := int(math.Round(order.Amount * price))
+
+	switch order.OrderType {
+	case "BUY":
+		err = applyBuyFill(queryCtx, tx, order, total)
+	case "SELL":
+		err = applySellFill(queryCtx, tx, order, total)
+	default:
+		err = fmt.Errorf("알 수 없는 주문 유형: %s", order.OrderType)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 주문 상태를 체결 완료로 변경하고 체결가 기록
+	updateQuery := `
+		UPDATE orders
+		SET status = 'COMPLETED', trade_price = ?
+		WHERE id = ?
+	`
+	if _, err = tx.ExecContext(queryCtx, updateQuery, price, order.ID); err != nil {
+		return fmt.Errorf("주문 상태 업데이트 실패: %w", err)
+	}
+
+	return tx.Commit() // 트랜잭션 커밋
+}
+
+// 매수 체결: 현금을 차감하고 보유 자산을 늘립니다.
+func applyBuyFill(ctx context.Context, tx *sql.Tx, order model.PendingOrder, total int) error {
+	var cash int
+	err := tx.QueryRowContext(ctx, `SELECT cash FROM users WHERE id = ? FOR UPDATE`, order.UserID).Scan(&cash)
+	if err != nil {
+		return fmt.Errorf("유저 현금 조회 실패: %w", err)
+	}
+	if cash < total {
+		return errInsufficientBalance
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET cash = cash - ? WHERE id = ?`, total, order.UserID); err != nil {
+		return fmt.Errorf("유저 현금 차감 실패: %w", err)
+	}
+
+	// 보유중인 코인이면 수량 증가, 아니면 새로 추가
+	result, err := tx.ExecContext(ctx,
+		`UPDATE user_assets SET amount = amount + ? WHERE user_id = ? AND symbol_id = ?`,
+		order.Amount, order.UserID, order.SymbolID)
+	if err != nil {
+		return fmt.Errorf("유저 자산 증가 실패: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("유저 자산 증가 결과 확인 실패: %w", err)
+	}
+	if affected == 0 {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO user_assets (user_id, symbol_id, amount) VALUES (?, ?, ?)`,
+			order.UserID, order.SymbolID, order.Amount); err != nil {
+			return fmt.Errorf("유저 자산 추가 실패: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// 매도 체결: 보유 자산을 줄이고 현금을 늘립니다.
+func applySellFill(ctx context.Context, tx *sql.Tx, order model.PendingOrder, total int) error {
+	var amount float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT amount FROM user_assets WHERE user_id = ? AND symbol_id = ? FOR UPDATE`,
+		order.UserID, order.SymbolID).Scan(&amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errInsufficientBalance
+	} else if err != nil {
+		return fmt.Errorf("유저 자산 조회 실패: %w", err)
+	}
+	if amount < order.Amount {
+		return errInsufficientBalance
+	}
+
+	// 전량 매도라면 자산 행을 삭제, 아니면 수량 차감
+	if amount-order.Amount <= 0 {
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM user_assets WHERE user_id = ? AND symbol_id = ?`,
+			order.UserID, order.SymbolID)
+	} else {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE user_assets SET amount = amount - ? WHERE user_id = ? AND symbol_id = ?`,
+			order.Amount, order.UserID, order.SymbolID)
+	}
+	if err != nil {
+		return fmt.Errorf("유저 자산 차감 실패: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET cash = cash + ? WHERE id = ?`, total, order.UserID); err != nil {
+		return fmt.Errorf("유저 현금 증가 실패: %w", err)
+	}
+
+	return nil
+}
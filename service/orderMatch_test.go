@@ -0,0 +1,182 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeCandleSource 마켓별로 정해진 캔들을 돌려주는 테스트용 시세 조회
+type fakeCandleSource struct {
+	candles map[string][]model.UpbitCandle
+	err     error
+	calls   []time.Time // FetchCandles에 전달된 to
+}
+
+func (s *fakeCandleSource) ListMarkets(ctx context.Context) ([]model.UpbitCoinList, error) {
+	return nil, nil
+}
+
+func (s *fakeCandleSource) FetchTickers(ctx context.Context) ([]model.UpbitCoinPrice, error) {
+	return nil, nil
+}
+
+func (s *fakeCandleSource) FetchCandles(ctx context.Context, market string, unit int, to time.Time, count int) ([]model.UpbitCandle, error) {
+	s.calls = append(s.calls, to)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.candles[market], nil
+}
+
+func TestMatchOrderPrice(t *testing.T) {
+	r := orderPriceRange{Low: 95, High: 110}
+	tests := []struct {
+		name      string
+		orderType string
+		price     float64
+		r         orderPriceRange
+		want      bool
+	}{
+		{"매수 지정가가 저가 이상", "BUY", 100, r, true},
+		{"매수 지정가가 저가와 같음", "BUY", 95, r, true},
+		{"매수 지정가가 저가 미만", "BUY", 90, r, false},
+		{"매도 지정가가 고가 이하", "SELL", 105, r, true},
+		{"매도 지정가가 고가와 같음", "SELL", 110, r, true},
+		{"매도 지정가가 고가 초과", "SELL", 120, r, false},
+		{"빈 구간", "BUY", 100, orderPriceRange{}, false},
+		{"알 수 없는 주문 종류", "HOLD", 100, r, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := model.PendingOrder{OrderType: tt.orderType, TradePrice: tt.price}
+			price, ok := matchOrderPrice(order, tt.r)
+			if ok != tt.want {
+				t.Fatalf("matchOrderPrice ok = %v, want %v", ok, tt.want)
+			}
+			if ok && price != tt.price {
+				t.Errorf("체결가 = %v, want 지정가 %v", price, tt.price)
+			}
+		})
+	}
+}
+
+func TestOrderPriceRangeExtend(t *testing.T) {
+	r := orderPriceRange{Low: 100, High: 100}
+	if got := r.extend(90, 120); got != (orderPriceRange{Low: 90, High: 120}) {
+		t.Errorf("extend(90, 120) = %+v", got)
+	}
+	if got := r.extend(0, 0); got != r {
+		t.Errorf("0 가격은 무시해야 함: %+v", got)
+	}
+	if got := r.extend(105, 99); got != r {
+		t.Errorf("현재가를 포함하는 구간은 줄어들지 않아야 함: %+v", got)
+	}
+}
+
+func TestLoadOrderPriceRanges(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	orders := []model.PendingOrder{
+		{ID: 1, SymbolID: 1, OrderType: "BUY", TradePrice: 95},
+		{ID: 2, SymbolID: 1, OrderType: "SELL", TradePrice: 120},
+		{ID: 3, SymbolID: 2, OrderType: "BUY", TradePrice: 10},
+		{ID: 4, SymbolID: 3, OrderType: "BUY", TradePrice: 10},
+	}
+	tickers := map[int]model.UpbitCoinPrice{
+		// 일중 고가/저가는 주문 전 가격까지 포함하므로 구간에 쓰지 않음
+		1: {Market: "KRW-BTC", TradePrice: 100, HighPrice: 200, LowPrice: 50},
+		2: {Market: "KRW-ETH", TradePrice: 12, HighPrice: 20, LowPrice: 5},
+	}
+	source := &fakeCandleSource{candles: map[string][]model.UpbitCandle{
+		"KRW-BTC": {
+			{Market: "KRW-BTC", HighPrice: 115, LowPrice: 97},
+			{Market: "KRW-BTC", HighPrice: 104, LowPrice: 93},
+		},
+	}}
+
+	// 직전 실행이 3시간 전
+	ranges := loadOrderPriceRanges(context.Background(), source, orders, tickers, now.Add(-3*time.Hour), now)
+
+	if got, want := ranges[1], (orderPriceRange{Low: 93, High: 115}); got != want {
+		t.Errorf("KRW-BTC 구간 = %+v, want %+v", got, want)
+	}
+	if got, want := ranges[2], (orderPriceRange{Low: 12, High: 12}); got != want {
+		t.Errorf("시간봉이 없으면 현재가만 사용해야 함: %+v, want %+v", got, want)
+	}
+	if _, ok := ranges[3]; ok {
+		t.Error("시세가 없는 코인은 구간이 없어야 함")
+	}
+	if len(source.calls) != 2 {
+		t.Fatalf("코인별로 한 번씩 조회해야 함: %d회", len(source.calls))
+	}
+	if want := now.Truncate(time.Hour); !source.calls[0].Equal(want) {
+		t.Errorf("직전 완성 시간봉 기준 시각 = %s, want %s", source.calls[0], want)
+	}
+
+	if _, ok := matchOrderPrice(orders[1], ranges[1]); ok {
+		t.Error("직전 실행 이후 고가보다 높은 매도 주문은 체결되지 않아야 함")
+	}
+}
+
+func TestLoadOrderPriceRangesCandleCount(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		name  string
+		since time.Time
+		want  int // 조회할 시간봉 수 (0이면 조회하지 않음)
+	}{
+		{"직전 실행 기록 없음", time.Time{}, 0},
+		{"같은 시각에 다시 실행", now, 0},
+		{"과거 시각으로 다시 실행", now.Add(time.Hour), 0},
+		{"한 시간 전", now.Add(-time.Hour), 1},
+		{"정시가 아닌 직전 실행", now.Add(-90 * time.Minute), 2},
+		{"조회 한도 초과", now.Add(-500 * time.Hour), upbitMaxCandleCount},
+	}
+
+	orders := []model.PendingOrder{{ID: 1, SymbolID: 1, OrderType: "BUY", TradePrice: 95}}
+	tickers := map[int]model.UpbitCoinPrice{1: {Market: "KRW-BTC", TradePrice: 100}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &countingCandleSource{}
+			ranges := loadOrderPriceRanges(context.Background(), source, orders, tickers, tt.since, now)
+			if got, want := ranges[1], (orderPriceRange{Low: 100, High: 100}); got != want {
+				t.Errorf("시간봉이 없으면 현재가만 사용해야 함: %+v, want %+v", got, want)
+			}
+			if tt.want == 0 {
+				if len(source.counts) != 0 {
+					t.Errorf("시간봉을 조회하지 않아야 함: %v", source.counts)
+				}
+				return
+			}
+			if len(source.counts) != 1 || source.counts[0] != tt.want {
+				t.Errorf("조회한 시간봉 수 = %v, want %d", source.counts, tt.want)
+			}
+		})
+	}
+}
+
+// countingCandleSource 요청한 캔들 수만 기록하는 테스트용 시세 조회
+type countingCandleSource struct {
+	fakeCandleSource
+	counts []int
+}
+
+func (s *countingCandleSource) FetchCandles(ctx context.Context, market string, unit int, to time.Time, count int) ([]model.UpbitCandle, error) {
+	s.counts = append(s.counts, count)
+	return nil, nil
+}
+
+func TestLoadOrderPriceRangesFetchError(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	orders := []model.PendingOrder{{ID: 1, SymbolID: 1, OrderType: "BUY", TradePrice: 95}}
+	tickers := map[int]model.UpbitCoinPrice{1: {Market: "KRW-BTC", TradePrice: 100, LowPrice: 50}}
+	source := &fakeCandleSource{err: errors.New("timeout")}
+
+	ranges := loadOrderPriceRanges(context.Background(), source, orders, tickers, now.Add(-time.Hour), now)
+	if got, want := ranges[1], (orderPriceRange{Low: 100, High: 100}); got != want {
+		t.Errorf("조회 실패 시 현재가만 사용해야 함: %+v, want %+v", got, want)
+	}
+}
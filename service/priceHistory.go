@@ -1,29 +1,149 @@
 package service
 
 import (
+	"Bitground-go/exchange"
 	"Bitground-go/model"
+	"Bitground-go/util"
 	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 )
 
+// Upbit 캔들 조회 설정
+const (
+	upbitHourUnit        = 60                     // 시간봉 (분 단위 캔들의 unit)
+	upbitCandleInterval  = 110 * time.Millisecond // 캔들 요청 간격 (Upbit 시세 API 초당 10회 제한)
+	hourlyCandleLookback = 2                      // 매 실행마다 조회하는 시간봉 수 (직전 완성 봉 + 진행 중인 봉)
+)
+
+// hourlyCandle coin_price_history 한 행
+// 다른 시간별 테이블(market_indices 등)과 같이 서버 로컬 시간 기준 날짜/시간으로 기록합니다.
+type hourlyCandle struct {
+	CoinID                 int
+	Start                  time.Time // 시간봉 시작 시각 (서버 로컬 시간)
+	Date                   string
+	Hour                   int
+	Open, High, Low, Close float64
+	Volume                 float64
+}
+
 // UpdateCoinPriceHistory 는 코인 가격 히스토리를 업데이트하는 함수입니다.
-func UpdateCoinPriceHistory(ctx context.Context, db *sql.DB, coinPriceHistory map[int]model.UpbitCoinPrice) error {
-	// 현재 날짜 및 시간
-	now := time.Now()
-	currentDate := now.Format("2006-01-02")
-	currentHour := now.Hour()
+// 활성 코인마다 Upbit 시간봉을 조회하여 coin_price_history에 직전 완성 봉과 진행 중인 봉을 기록하고,
+// 기록한 날짜가 속한 일/주/월봉을 다시 집계합니다.
+// 일부 코인의 캔들 조회에 실패해도 나머지는 저장하고, 실패한 심볼은 저장 후 에러로 반환합니다.
+func UpdateCoinPriceHistory(ctx context.Context, db *sql.DB, source exchange.MarketDataSource, symbolMap map[string]int) error {
+	// 1. 코인별 시간봉 조회
+	candles, failed := fetchHourlyCandles(ctx, source, symbolMap, time.Time{}, hourlyCandleLookback)
+	if len(candles) == 0 && len(failed) > 0 {
+		return fmt.Errorf("시간봉 조회 %d건 모두 실패", len(failed))
+	}
+
+	// 2. 시간봉 저장
+	if err := ensurePriceHistoryColumns(ctx, db); err != nil {
+		return err
+	}
+	dates, err := upsertHourlyCandles(ctx, db, candles)
+	if err != nil {
+		return err
+	}
+
+	// 3. 일/주/월봉 집계
+	if err := updateCandleRollups(ctx, db, 0, dates); err != nil {
+		return fmt.Errorf("캔들 집계 에러: %w", err)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("시간봉 일부 저장 (조회 실패 심볼: %s)", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// fetchHourlyCandles 마켓별로 to 시각 이전 시간봉 count개를 요청 간격을 지키며 조회합니다. (to가 0이면 현재)
+// 조회에 실패한 마켓은 failed로 반환합니다.
+func fetchHourlyCandles(ctx context.Context, source exchange.MarketDataSource, symbolMap map[string]int, to time.Time, count int) (candles []hourlyCandle, failed []string) {
+	markets := make([]string, 0, len(symbolMap))
+	for market := range symbolMap {
+		markets = append(markets, market)
+	}
+	sort.Strings(markets)
+
+	throttle := time.NewTicker(upbitCandleInterval)
+	defer throttle.Stop()
+
+	for i, market := range markets {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return candles, append(failed, markets[i:]...)
+			case <-throttle.C:
+			}
+		}
 
-	// 쿼리 타임아웃 설정 (10초)
+		upbitCandles, err := source.FetchCandles(ctx, market, upbitHourUnit, to, count)
+		if err != nil {
+			log.Printf("%s 시간봉 조회 실패: %v\n", market, err)
+			failed = append(failed, market)
+			continue
+		}
+
+		for _, c := range upbitCandles {
+			candle, err := newHourlyCandle(symbolMap[market], c)
+			if err != nil {
+				log.Printf("%s 시간봉 시각 파싱 실패: %v\n", market, err)
+				continue
+			}
+			candles = append(candles, candle)
+		}
+	}
+
+	return candles, failed
+}
+
+// Upbit 캔들을 서버 로컬 날짜/시간 기준 시간봉으로 변환합니다.
+// KST 시각을 그대로 쓰면 서버 시간대가 KST가 아닐 때 기존 기록과 어긋나므로 UTC 시각을 변환해 사용합니다.
+func newHourlyCandle(coinID int, c model.UpbitCandle) (hourlyCandle, error) {
+	start, err := time.ParseInLocation("2006-01-02T15:04:05", c.CandleDateTimeUTC, time.UTC)
+	if err != nil {
+		return hourlyCandle{}, err
+	}
+	start = start.In(time.Local)
+	return hourlyCandle{
+		CoinID: coinID,
+		Start:  start,
+		Date:   start.Format("2006-01-02"),
+		Hour:   start.Hour(),
+		Open:   c.OpeningPrice,
+		High:   c.HighPrice,
+		Low:    c.LowPrice,
+		Close:  c.TradePrice,
+		Volume: c.CandleAccTradeVolume,
+	}, nil
+}
+
+// ensurePriceHistoryColumns coin_price_history에 시간봉 기록 여부 컬럼이 없으면 추가합니다.
+// 시간봉 이전 기록은 24시간 시세의 일중 고가/저가를 담고 있어, 시간별 변동폭 비교에서 구분해야 합니다.
+func ensurePriceHistoryColumns(ctx context.Context, db *sql.DB) error {
+	return ensureColumn(ctx, db, "coin_price_history", "is_hourly_candle", "TINYINT(1) NOT NULL DEFAULT 0")
+}
+
+// upsertHourlyCandles 시간봉을 coin_price_history에 저장하고 기록한 날짜 목록을 반환합니다.
+func upsertHourlyCandles(ctx context.Context, db *sql.DB, candles []hourlyCandle) (dates []string, err error) {
+	if len(candles) == 0 {
+		return nil, nil
+	}
+
+	// 쿼리 타임아웃 설정 (20초)
 	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
 	// 트랜잭션 시작
 	tx, err := db.BeginTx(queryCtx, nil)
 	if err != nil {
-		return fmt.Errorf("트랜잭션 시작 에러: %w", err)
+		return nil, fmt.Errorf("트랜잭션 시작 에러: %w", err)
 	}
 	defer func() {
 		if p := recover(); p != nil {
@@ -36,18 +156,19 @@ func UpdateCoinPriceHistory(ctx context.Context, db *sql.DB, coinPriceHistory ma
 
 	// 쿼리 준비
 	query := `
-		INSERT INTO coin_price_history (coin_id, date, hour, open_price, close_price, high_price, low_price, volume)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO coin_price_history (coin_id, date, hour, open_price, close_price, high_price, low_price, volume, is_hourly_candle)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1)
 		ON DUPLICATE KEY UPDATE
 		                     			open_price = VALUES(open_price),
 		                     			close_price = VALUES(close_price),
 		                     			high_price = VALUES(high_price),
 		                     			low_price = VALUES(low_price),
-		                     			volume = VALUES(volume);
+		                     			volume = VALUES(volume),
+		                     			is_hourly_candle = 1;
 	`
 	stmt, err := tx.PrepareContext(queryCtx, query)
 	if err != nil {
-		return fmt.Errorf("쿼리 준비 에러: %w", err)
+		return nil, fmt.Errorf("쿼리 준비 에러: %w", err)
 	}
 	defer func(stmt *sql.Stmt) {
 		if err := stmt.Close(); err != nil {
@@ -55,18 +176,22 @@ func UpdateCoinPriceHistory(ctx context.Context, db *sql.DB, coinPriceHistory ma
 		}
 	}(stmt)
 
-	// 각 코인 가격 히스토리를 데이터베이스에 삽입
-	for coinID, coinPrice := range coinPriceHistory {
-		// 쿼리 실행
-		_, err := stmt.ExecContext(queryCtx,
-			coinID, currentDate, currentHour,
-			coinPrice.OpenPrice, coinPrice.TradePrice,
-			coinPrice.HighPrice, coinPrice.LowPrice,
-			coinPrice.TradeVolume)
-		if err != nil {
-			return fmt.Errorf("코인 가격 히스토리 삽입 에러: %w", err)
+	// 각 코인 시간봉을 데이터베이스에 삽입
+	seen := make(map[string]bool)
+	for _, c := range candles {
+		if _, err = stmt.ExecContext(queryCtx, c.CoinID, c.Date, c.Hour, c.Open, c.Close, c.High, c.Low, c.Volume); err != nil {
+			return nil, fmt.Errorf("코인 가격 히스토리 삽입 에러: %w", err)
+		}
+		if !seen[c.Date] {
+			seen[c.Date] = true
+			dates = append(dates, c.Date)
 		}
 	}
 
-	return tx.Commit() // 트랜잭션 커밋
+	if err = tx.Commit(); err != nil { // 트랜잭션 커밋
+		return nil, err
+	}
+	util.AddRows(ctx, int64(len(candles)))
+	sort.Strings(dates)
+	return dates, nil
 }
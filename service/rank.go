@@ -5,12 +5,9 @@ import (
 	"Bitground-go/util"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"golang.org/x/sync/errgroup"
-	"io"
 	"log"
-	"net/http"
 	"time"
 )
 
@@ -18,25 +15,20 @@ const BATCH_SIZE = 1000 // 배치 크기 설정
 var DailyFlag bool
 var SeasonID int // 전역 변수로 시즌 ID 저장
 
-// UpdateRank 랭킹 업데이트 함수, 코인 시세정보 반환과, 일일 유저 자산정보 스냅샷 기능이 이후 추가되었습니다.
-func UpdateRank(ctx context.Context, db *sql.DB, symbolMap map[string]int, currentSeasonID int, insightFlag bool) (error, map[int]model.UpbitCoinPrice, map[int]float64) {
+// UpdateRank 랭킹 업데이트 함수, 일일 유저 자산정보 스냅샷 기능이 이후 추가되었습니다.
+// coinPrices는 MapTickersBySymbolID로 만든 코인 id별 현재가입니다.
+func UpdateRank(ctx context.Context, db *sql.DB, coinPrices map[int]float64, currentSeasonID int, insightFlag bool) error {
 	DailyFlag = insightFlag    // DailyFlag 설정
 	SeasonID = currentSeasonID // 시즌 ID 설정
 
 	// 1. 총 참여 유저 수 확인
 	totalUsers, err := getParticipatingUserCount(ctx, db)
 	if err != nil {
-		return fmt.Errorf("유저 수 조회 실패: %w", err), nil, nil
+		return fmt.Errorf("유저 수 조회 실패: %w", err)
 	}
 
-	// 2. 코인 현재가 가져오기
-	coinPrices, coinPriceHistory, err := getAllCoinsCurrentPrice(ctx, symbolMap)
-	if err != nil {
-		return fmt.Errorf("코인 현재가 조회 실패: %w", err), nil, nil
-	}
-
-	// 3. 배치 처리로 랭킹 업데이트, 코인 가격 히스토리정보 반환
-	return updateRankWithBatching(ctx, db, coinPrices, totalUsers), coinPriceHistory, coinPrices
+	// 2. 배치 처리로 랭킹 업데이트
+	return updateRankWithBatching(ctx, db, coinPrices, totalUsers)
 }
 
 // 배치 처리 방식 (모든 경우에 사용)
@@ -51,18 +43,26 @@ func updateRankWithBatching(ctx context.Context, db *sql.DB, coinPrices map[int]
 	}
 	defer dropTempRankingTable(ctx, db) // 정리
 
-	// 2. 배치 단위로 유저 자산 계산 및 임시 테이블에 저장
-	offset := 0
-	for offset < totalUsers {
-		if err := processBatch(ctx, db, coinPrices, offset, BATCH_SIZE); err != nil {
-			return fmt.Errorf("배치 처리 실패 (offset: %d): %w", offset, err)
-		}
-		offset += BATCH_SIZE
+	// 2. 배치 단위로 유저 자산 계산 및 임시 테이블에 저장 (user_id keyset 커서)
+	processed := 0
+	_, err := util.KeysetBatches(ctx, BATCH_SIZE,
+		func(ctx context.Context, after int64, limit int) ([]int, error) {
+			return getParticipatingUserIDsBatch(ctx, db, int(after), limit)
+		},
+		func(userID int) int64 { return int64(userID) },
+		func(userIDs []int) error {
+			if err := processBatch(ctx, db, coinPrices, userIDs); err != nil {
+				return err
+			}
 
-		// 메모리 정리를 위한 가비지 컬렉션 힌트
-		if offset%(BATCH_SIZE*5) == 0 {
-			log.Printf("처리 진행률: %d/%d", offset, totalUsers)
-		}
+			processed += len(userIDs)
+			if processed%(BATCH_SIZE*5) == 0 {
+				log.Printf("처리 진행률: %d/%d", processed, totalUsers)
+			}
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("유저 배치 처리 실패: %w", err)
 	}
 
 	// 3. 임시 테이블에서 랭킹 계산 및 업데이트
@@ -119,18 +119,8 @@ func createTempRankingTable(ctx context.Context, db *sql.DB) error {
 }
 
 // 배치 단위로 유저 자산 계산
-func processBatch(ctx context.Context, db *sql.DB, coinPrices map[int]float64, offset, limit int) error {
-	// 1. 배치 단위로 유저 ID 가져오기
-	userIDs, err := getParticipatingUserIDsBatch(ctx, db, offset, limit)
-	if err != nil {
-		return err
-	}
-
-	if len(userIDs) == 0 {
-		return nil
-	}
-
-	// 2. 해당 유저들의 현금 및 자산 정보 가져오기
+func processBatch(ctx context.Context, db *sql.DB, coinPrices map[int]float64, userIDs []int) error {
+	// 1. 해당 유저들의 현금 및 자산 정보 가져오기
 	g, gCtx := errgroup.WithContext(ctx)
 	var userCashMap map[int]int
 	var userAssetsMap map[int][]model.UserAsset
@@ -151,24 +141,25 @@ func processBatch(ctx context.Context, db *sql.DB, coinPrices map[int]float64, o
 		return err
 	}
 
-	// 3. 총 자산 계산 후 임시 테이블에 저장
+	// 2. 총 자산 계산 후 임시 테이블에 저장
 	return insertBatchToTemp(ctx, db, userIDs, userCashMap, userAssetsMap, coinPrices)
 }
 
-// 배치 단위로 유저 ID 조회
-func getParticipatingUserIDsBatch(ctx context.Context, db *sql.DB, offset, limit int) ([]int, error) {
+// afterUserID보다 큰 참여 유저 ID를 배치 단위로 조회
+func getParticipatingUserIDsBatch(ctx context.Context, db *sql.DB, afterUserID, limit int) ([]int, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	query := `
-		SELECT DISTINCT user_id
+		SELECT user_id
 		FROM orders
-		WHERE season_id = ? AND status = 'COMPLETED'
+		WHERE season_id = ? AND status = 'COMPLETED' AND user_id > ?
+		GROUP BY user_id
 		ORDER BY user_id
-		LIMIT ? OFFSET ?
+		LIMIT ?
 	`
 
-	rows, err := db.QueryContext(queryCtx, query, SeasonID, limit, offset)
+	rows, err := db.QueryContext(queryCtx, query, SeasonID, afterUserID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
 	}
@@ -264,7 +255,11 @@ func insertBatchToTemp(ctx context.Context, db *sql.DB, userIDs []int, userCashM
 		}
 	}
 
-	return tx.Commit()
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	util.AddRows(ctx, int64(len(userIDs)))
+	return nil
 }
 
 // 임시 테이블에서 최종 랭킹 계산 및 업데이트
@@ -318,38 +313,12 @@ func dropTempRankingTable(ctx context.Context, db *sql.DB) {
 	}
 }
 
-func getAllCoinsCurrentPrice(ctx context.Context, symbolMap map[string]int) (map[int]float64, map[int]model.UpbitCoinPrice, error) {
-	apiURL := "https://api.upbit.com/v1/ticker/all?quote_currencies=KRW"
-
-	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(reqCtx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("HTTP 요청 생성 에러: %w", err)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, nil, fmt.Errorf("API 요청 에러: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		if err := Body.Close(); err != nil {
-			log.Printf("응답 본문 닫기 에러: %v\n", err)
-		}
-	}(resp.Body)
-
-	var upbitPrices []model.UpbitCoinPrice
-	err = json.NewDecoder(resp.Body).Decode(&upbitPrices)
-	if err != nil {
-		return nil, nil, fmt.Errorf("JSON 디코딩 에러: %w", err)
-	}
-
+// MapTickersBySymbolID 시세 목록을 활성 코인 id 기준 현재가 맵과 시세 맵으로 변환합니다.
+func MapTickersBySymbolID(tickers []model.UpbitCoinPrice, symbolMap map[string]int) (map[int]float64, map[int]model.UpbitCoinPrice) {
 	coinPrices := make(map[int]float64)
 	coinPriceHistory := make(map[int]model.UpbitCoinPrice)
 
-	for _, upbitCoin := range upbitPrices {
+	for _, upbitCoin := range tickers {
 		symbol := upbitCoin.Market
 		if symbolId, exists := symbolMap[symbol]; exists {
 			coinPrices[symbolId] = upbitCoin.TradePrice
@@ -357,7 +326,7 @@ func getAllCoinsCurrentPrice(ctx context.Context, symbolMap map[string]int) (map
 		}
 	}
 
-	return coinPrices, coinPriceHistory, nil
+	return coinPrices, coinPriceHistory
 }
 
 func getUserCashMap(ctx context.Context, db *sql.DB, userIDs []int) (map[int]int, error) {
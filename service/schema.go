@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"log"
+	"time"
+)
+
+// ensureColumn 기존 테이블에 컬럼이 없으면 추가합니다.
+// CREATE TABLE IF NOT EXISTS로는 이미 있는 테이블에 컬럼을 추가할 수 없어 information_schema로 확인합니다.
+func ensureColumn(ctx context.Context, db *sql.DB, table, column, definition string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int
+	checkQuery := `
+		SELECT COUNT(*)
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+	`
+	if err := db.QueryRowContext(queryCtx, checkQuery, table, column).Scan(&count); err != nil {
+		return fmt.Errorf("%s.%s 컬럼 확인 실패: %w", table, column, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	alterQuery := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := db.ExecContext(queryCtx, alterQuery); err != nil {
+		return fmt.Errorf("%s.%s 컬럼 추가 실패: %w", table, column, err)
+	}
+	log.Printf("%s.%s 컬럼 추가\n", table, column)
+
+	return nil
+}
+
+// isMissingTable 테이블이 아직 없어서 실패한 쿼리인지 확인합니다. (MySQL 1146)
+// 테이블을 만드는 쓰기 작업이 한 번도 실행되지 않았을 때 읽기 작업은 데이터가 없는 것으로 처리합니다.
+func isMissingTable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1146
+}
+
+// isMissingColumn 없는 컬럼을 조회해 난 에러인지 확인합니다. (MySQL 1054)
+func isMissingColumn(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1054
+}
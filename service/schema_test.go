@@ -0,0 +1,43 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"testing"
+)
+
+func TestIsMissingTable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&mysql.MySQLError{Number: 1146, Message: "Table 'bitground.season_economies' doesn't exist"}, true},
+		{fmt.Errorf("시즌 경제 설정 조회 실패: %w", &mysql.MySQLError{Number: 1146}), true},
+		{&mysql.MySQLError{Number: 1054}, false},
+		{sql.ErrNoRows, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isMissingTable(tt.err); got != tt.want {
+			t.Errorf("isMissingTable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsMissingColumn(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&mysql.MySQLError{Number: 1054, Message: "Unknown column 'is_hourly_candle' in 'where clause'"}, true},
+		{fmt.Errorf("쿼리 실행 에러: %w", &mysql.MySQLError{Number: 1054}), true},
+		{&mysql.MySQLError{Number: 1146}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isMissingColumn(tt.err); got != tt.want {
+			t.Errorf("isMissingColumn(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
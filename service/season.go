@@ -2,11 +2,11 @@ package service
 
 import (
 	"Bitground-go/model"
+	"Bitground-go/util"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"golang.org/x/sync/errgroup"
 	"io"
 	"log"
 	"net/http"
@@ -16,86 +16,18 @@ import (
 	"time"
 )
 
-func UpdateSeason(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64, obj map[string]interface{}) error {
-	seasonName := obj["SEASON_NAME"].(string)
-	chkType := obj["TYPE"].(string)
-	seasonUpdateKey := obj["SEASON_UPDATE_KEY"].(string)
-
-	// errgroup.WithContext는 컨텍스트와 함께 새로운 Group을 생성합니다.
-	// Group 내의 고루틴 중 하나라도 에러를 반환하면, Group의 Context는 취소되고
-	// Wait()는 첫 번째 에러를 반환합니다.
-	g, gCtx := errgroup.WithContext(ctx)
-
-	// 고루틴 시작 (gCtx를 사용)
-	g.Go(func() error {
-		return seasonClose(gCtx, db, seasonID, seasonName, chkType)
-	})
-	g.Go(func() error {
-		return updateUserTiers(gCtx, db, seasonID)
-	})
-	g.Go(func() error {
-		return resetUserCash(gCtx, db)
-	})
-	g.Go(func() error {
-		return resetUserAssetsWithProgress(gCtx, db, seasonID, coinPrices)
-	})
-	g.Go(func() error { return deletePendingOrders(gCtx, db, seasonID) })
-
-	// 모든 고루틴이 완료되거나, 첫 번째 에러가 발생할 때까지 대기
-	// 에러가 발생하면 해당 에러를 반환합니다.
-	if err := g.Wait(); err != nil {
-		return fmt.Errorf("고루틴 수행 중 에러 발생: %w", err)
-	}
-
-	// 기존 시즌의 reward_calculated 컬럼을 업데이트
-	if err := updateSeasonRewardCalculated(ctx, db, seasonID); err != nil {
-		return fmt.Errorf("reward_calculated 업데이트 실패: %w", err)
-	}
-
-	// NotifySeasonUpdate 함수를 호출하여 스프링 서버에 시즌 업데이트 요청
-	if err := NotifySeasonUpdate(ctx, seasonUpdateKey, "season"); err != nil {
-		return fmt.Errorf("NotifySeasonUpdate 에러: %w", err)
-	}
-
-	return nil
+// execer 쓰기 쿼리를 수행할 수 있는 *sql.DB, *sql.Tx 공통 인터페이스
+// 시즌 전환 단계들은 체크포인트 기록과 같은 트랜잭션에서 실행됩니다.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
-// 기존 시즌 종료, 새 시즌 시작
-func seasonClose(ctx context.Context, db *sql.DB, seasonID int, seasonName, chkType string) error {
+// 기존 시즌 종료, 새 시즌 시작. 생성된 새 시즌 ID를 반환합니다.
+func closeSeason(ctx context.Context, tx *sql.Tx, seasonID int, newSeasonName, startDate, endDate string) (int64, error) {
 	// 쿼리 타임아웃 설정 (10초)
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// 트랜잭션 시작
-	tx, err := db.BeginTx(queryCtx, nil)
-	if err != nil {
-		return fmt.Errorf("트랜잭션 시작 에러: %w", err)
-	}
-	defer func() {
-		if p := recover(); p != nil {
-			_ = tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			_ = tx.Rollback()
-		}
-	}()
-
-	selectQuery := `SELECT name FROM seasons WHERE id = ?;`
-
-	var ogSeasonName string
-	err = tx.QueryRowContext(queryCtx, selectQuery, seasonID).Scan(&ogSeasonName)
-	if errors.Is(err, sql.ErrNoRows) {
-		ogSeasonName = "초기화 시즌 1"
-	} else if err != nil {
-		return fmt.Errorf("시즌 이름 조회 실패: %w", err)
-	}
-
-	// processSeasonStrings 함수를 호출하여 새로운 시즌 이름을 생성
-	newSeasonName, err := processSeasonStrings(seasonName, ogSeasonName)
-	if err != nil {
-		return fmt.Errorf("시즌 이름 처리 실패: %w", err)
-	}
-
 	// 기존 시즌 종료 처리 쿼리
 	updateQuery := `
 		UPDATE seasons
@@ -103,27 +35,8 @@ func seasonClose(ctx context.Context, db *sql.DB, seasonID int, seasonName, chkT
 		WHERE id = ?;
 	`
 
-	_, err = tx.ExecContext(queryCtx, updateQuery, seasonID)
-	if err != nil {
-		return fmt.Errorf("시즌 상태 업데이트 실패: %w", err)
-	}
-
-	// 오늘 날짜 가져오기
-	startAt := time.Now()
-	var endAt time.Time
-	if chkType == "dev" {
-		endAt = startAt
-	} else {
-		if startAt.Day() == 1 {
-			// 이번 달 15일로 종료일 설정
-			endAt = time.Now().AddDate(0, 0, 14)
-		} else if startAt.Day() == 16 {
-			// 이번 달 마지막 날로 종료일 설정
-			firstOfNextMonth := time.Date(startAt.Year(), startAt.Month()+1, 1, 0, 0, 0, 0, startAt.Location())
-			endAt = firstOfNextMonth.AddDate(0, 0, -1)
-		} else {
-			return fmt.Errorf("시즌 종료일 설정 실패: 현재 날짜가 시즌 종료일이 아님")
-		}
+	if _, err := tx.ExecContext(queryCtx, updateQuery, seasonID); err != nil {
+		return 0, fmt.Errorf("시즌 상태 업데이트 실패: %w", err)
 	}
 
 	// 새 시즌 시작 처리 쿼리
@@ -132,12 +45,43 @@ func seasonClose(ctx context.Context, db *sql.DB, seasonID int, seasonName, chkT
 		VALUES (?, ?, ?);
 	`
 
-	_, err = tx.ExecContext(queryCtx, insertQuery, newSeasonName, startAt.Format("2006-01-02"), endAt.Format("2006-01-02"))
+	result, err := tx.ExecContext(queryCtx, insertQuery, newSeasonName, startDate, endDate)
 	if err != nil {
-		return fmt.Errorf("새 시즌 생성 실패: %w", err)
+		return 0, fmt.Errorf("새 시즌 생성 실패: %w", err)
 	}
 
-	return tx.Commit() // 트랜잭션 커밋
+	newSeasonID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("새 시즌 ID 조회 실패: %w", err)
+	}
+
+	return newSeasonID, nil
+}
+
+// rowQuerier 단건 조회 쿼리를 수행할 수 있는 *sql.DB, *sql.Tx 공통 인터페이스
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// 기존 시즌 이름을 조회하여 새로운 시즌 이름을 생성합니다. (기존 이름, 새 이름) 반환
+func nextSeasonName(ctx context.Context, q rowQuerier, seasonID int, seasonName string) (string, string, error) {
+	selectQuery := `SELECT name FROM seasons WHERE id = ?;`
+
+	var ogSeasonName string
+	err := q.QueryRowContext(ctx, selectQuery, seasonID).Scan(&ogSeasonName)
+	if errors.Is(err, sql.ErrNoRows) {
+		ogSeasonName = "초기화 시즌 1"
+	} else if err != nil {
+		return "", "", fmt.Errorf("시즌 이름 조회 실패: %w", err)
+	}
+
+	// processSeasonStrings 함수를 호출하여 새로운 시즌 이름을 생성
+	newSeasonName, err := processSeasonStrings(seasonName, ogSeasonName)
+	if err != nil {
+		return "", "", fmt.Errorf("시즌 이름 처리 실패: %w", err)
+	}
+
+	return ogSeasonName, newSeasonName, nil
 }
 
 // processSeasonStrings 함수는 문자열 a와 b를 비교하여 새로운 문자열 c를 반환합니다.
@@ -196,7 +140,7 @@ func processSeasonStrings(seasonName, ogSeasonName string) (string, error) {
 }
 
 // 티어 users에 반영
-func updateUserTiers(ctx context.Context, db *sql.DB, seasonID int) error {
+func updateUserTiers(ctx context.Context, db execer, seasonID int) error {
 	// 쿼리 타임아웃 설정 (20초)
 	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
@@ -217,53 +161,22 @@ func updateUserTiers(ctx context.Context, db *sql.DB, seasonID int) error {
 	return nil
 }
 
-// 캐시 초기화
-func resetUserCash(ctx context.Context, db *sql.DB) error {
+// 캐시를 다음 시즌 시작 금액으로 초기화
+// 시작 금액과의 차액을 cash_ledger에 기록하여 반영하므로 다시 실행해도 같은 시즌에서는 한 번만 초기화됩니다.
+func resetUserCash(ctx context.Context, db execer, seasonID int, startingCash int64) error {
 	// 쿼리 타임아웃 설정 (10초)
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// 캐시 초기화 쿼리
-	updateQuery := `
-		UPDATE users
-		SET cash = 10000000
-		WHERE is_deleted = 0;
-	`
-	_, err := db.ExecContext(queryCtx, updateQuery)
-	if err != nil {
+	if _, err := recordSeasonReset(queryCtx, db, seasonID, startingCash); err != nil {
 		return fmt.Errorf("캐시 초기화 실패: %w", err)
 	}
 
 	return nil
 }
 
-// user_assets 테이블 초기화
-func resetUserAssets(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64) error {
-	// 1. user_assets 데이터를 orders로 이동
-	err := migrateUserAssetsToOrders(ctx, db, seasonID, coinPrices)
-	if err != nil {
-		return fmt.Errorf("user_assets 데이터 이동 실패: %w", err)
-	}
-
-	// 2. user_assets 테이블 초기화
-	// 쿼리 타임아웃 설정 (5초)
-	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	// user_assets 테이블 초기화 쿼리
-	deleteQuery := `
-		DELETE FROM user_assets;
-	`
-	_, err = db.ExecContext(queryCtx, deleteQuery)
-	if err != nil {
-		return fmt.Errorf("user_assets 테이블 초기화 실패: %w", err)
-	}
-
-	return nil
-}
-
 // 기존 시즌 거래내역의 예약주문 내역들을 삭제합니다.
-func deletePendingOrders(ctx context.Context, db *sql.DB, seasonID int) error {
+func deletePendingOrders(ctx context.Context, db execer, seasonID int) error {
 	// 쿼리 타임아웃 설정 (10초)
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -284,7 +197,7 @@ func deletePendingOrders(ctx context.Context, db *sql.DB, seasonID int) error {
 }
 
 // 기존 시즌 reward_calculated 컬럼 수정
-func updateSeasonRewardCalculated(ctx context.Context, db *sql.DB, seasonID int) error {
+func updateSeasonRewardCalculated(ctx context.Context, db execer, seasonID int) error {
 	// 쿼리 타임아웃 설정 (10초)
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -305,8 +218,8 @@ func updateSeasonRewardCalculated(ctx context.Context, db *sql.DB, seasonID int)
 }
 
 // NotifySeasonUpdate 내 스프링 서버에 시즌/스플릿 업데이트 요청을 보냅니다.
-func NotifySeasonUpdate(ctx context.Context, seasonUpdateKey string, seasonFlag string) error {
-	apiURL := "https://api.bitground.kr/seasons/update"
+func NotifySeasonUpdate(ctx context.Context, baseURL, seasonUpdateKey string, seasonFlag string) error {
+	apiURL := baseURL + "/seasons/update"
 
 	// Context를 활용한 HTTP 요청 (타임아웃: 10초)
 	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -345,53 +258,58 @@ func NotifySeasonUpdate(ctx context.Context, seasonUpdateKey string, seasonFlag
 }
 
 // user_assets 데이터를 orders 테이블로 배치 이동
-func migrateUserAssetsToOrders(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64) error {
+// 배치마다 청산 주문 삽입과 해당 user_assets 행 삭제를 한 트랜잭션으로 처리하므로,
+// 중간에 실패해도 다시 실행하면 남은 자산부터 이어서 처리합니다.
+func liquidateUserAssets(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64) error {
 	const batchSize = 1000
-	offset := 0
+	processed := 0
 
-	for {
-		// 배치 단위로 user_assets 데이터 조회
-		userAssets, err := fetchUserAssetsBatch(ctx, db, batchSize, offset)
-		if err != nil {
-			return fmt.Errorf("user_assets 배치 조회 실패 (offset: %d): %w", offset, err)
-		}
-
-		// 더 이상 데이터가 없으면 종료
-		if len(userAssets) == 0 {
-			break
-		}
+	_, err := util.KeysetBatches(ctx, batchSize, userAssetsFetcher(db), userAssetID,
+		func(userAssets []model.UserAsset) error {
+			if err := liquidateAssetsBatch(ctx, db, userAssets, seasonID, coinPrices); err != nil {
+				return fmt.Errorf("청산 배치 처리 실패: %w", err)
+			}
 
-		// 배치 단위로 orders 테이블에 삽입
-		err = insertOrdersBatch(ctx, db, userAssets, seasonID, coinPrices)
-		if err != nil {
-			return fmt.Errorf("orders 배치 삽입 실패 (offset: %d): %w", offset, err)
-		}
+			processed += len(userAssets)
+			log.Printf("배치 처리 완료: %d개 레코드 처리됨 (누적: %d)\n", len(userAssets), processed)
 
-		log.Printf("배치 처리 완료: %d개 레코드 처리됨 (offset: %d)\n", len(userAssets), offset)
+			// 배치 처리 간 잠시 대기 (DB 부하 방지)
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("user_assets 청산 실패: %w", err)
+	}
 
-		// 다음 배치로 이동
-		offset += batchSize
+	log.Println("user_assets 테이블 초기화가 완료되었습니다")
+	return nil
+}
 
-		// 배치 처리 간 잠시 대기 (DB 부하 방지)
-		time.Sleep(10 * time.Millisecond)
+// util.KeysetBatches에 넘길 user_assets 배치 조회 함수
+func userAssetsFetcher(db *sql.DB) util.BatchFetcher[model.UserAsset] {
+	return func(ctx context.Context, after int64, limit int) ([]model.UserAsset, error) {
+		return fetchUserAssetsBatch(ctx, db, after, limit)
 	}
+}
 
-	return nil
+func userAssetID(asset model.UserAsset) int64 {
+	return asset.ID
 }
 
-// user_assets에서 배치 단위로 데이터 조회
-func fetchUserAssetsBatch(ctx context.Context, db *sql.DB, limit, offset int) ([]model.UserAsset, error) {
+// user_assets에서 afterID보다 큰 id의 데이터를 배치 단위로 조회
+func fetchUserAssetsBatch(ctx context.Context, db *sql.DB, afterID int64, limit int) ([]model.UserAsset, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	query := `
-		SELECT amount, symbol_id, user_id 
-		FROM user_assets 
-		ORDER BY id 
-		LIMIT ? OFFSET ?
+		SELECT id, amount, symbol_id, user_id
+		FROM user_assets
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
 	`
 
-	rows, err := db.QueryContext(queryCtx, query, limit, offset)
+	rows, err := db.QueryContext(queryCtx, query, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -404,7 +322,7 @@ func fetchUserAssetsBatch(ctx context.Context, db *sql.DB, limit, offset int) ([
 	var userAssets []model.UserAsset
 	for rows.Next() {
 		var asset model.UserAsset
-		err := rows.Scan(&asset.Amount, &asset.SymbolID, &asset.UserID)
+		err := rows.Scan(&asset.ID, &asset.Amount, &asset.SymbolID, &asset.UserID)
 		if err != nil {
 			return nil, err
 		}
@@ -418,12 +336,9 @@ func fetchUserAssetsBatch(ctx context.Context, db *sql.DB, limit, offset int) ([
 	return userAssets, nil
 }
 
-// orders 테이블에 배치 삽입
-func insertOrdersBatch(ctx context.Context, db *sql.DB, userAssets []model.UserAsset, seasonID int, coinPrices map[int]float64) error {
-	if len(userAssets) == 0 {
-		return nil
-	}
-
+// 자산 배치 하나를 청산 주문으로 옮기고 매도 대금을 cash_ledger를 통해 지급한 뒤 user_assets에서 삭제합니다.
+// 가격 정보가 없는 자산은 주문 없이 삭제됩니다.
+func liquidateAssetsBatch(ctx context.Context, db *sql.DB, userAssets []model.UserAsset, seasonID int, coinPrices map[int]float64) (err error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
@@ -441,76 +356,85 @@ func insertOrdersBatch(ctx context.Context, db *sql.DB, userAssets []model.UserA
 		}
 	}()
 
-	// bulk insert 쿼리 생성을 위한 준비
-	valueStrings := make([]string, 0, len(userAssets))
-	valueArgs := make([]interface{}, 0, len(userAssets)*5)
+	orders, _ := buildLiquidationOrders(userAssets, coinPrices)
+	if err = insertLiquidationOrders(queryCtx, tx, orders, seasonID); err != nil {
+		return err
+	}
+	if err = recordLiquidationProceeds(queryCtx, tx, seasonID, orders); err != nil {
+		return err
+	}
 
-	for _, asset := range userAssets {
-		// coinPrices 맵에서 trade_price 조회
-		tradePrice, exists := coinPrices[asset.SymbolID]
-		if !exists {
-			// 가격 정보가 없는 경우 기본값 설정
-			log.Printf("경고: symbol_id %d에 대한 가격 정보가 없습니다\n", asset.SymbolID)
-			continue
-		}
+	ids := make([]interface{}, len(userAssets))
+	for i, asset := range userAssets {
+		ids[i] = asset.ID
+	}
+	deleteQuery := fmt.Sprintf(`DELETE FROM user_assets WHERE id IN (%s)`, util.GeneratePlaceholders(len(ids)))
+	if _, err = tx.ExecContext(queryCtx, deleteQuery, ids...); err != nil {
+		return fmt.Errorf("user_assets 삭제 실패: %w", err)
+	}
 
-		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, 'SELL', 'COMPLETED')")
-		valueArgs = append(valueArgs,
-			asset.Amount,
-			asset.SymbolID,
-			asset.UserID,
-			seasonID,
-			tradePrice)
+	if err = tx.Commit(); err != nil {
+		return err
 	}
+	util.AddRows(ctx, int64(len(userAssets)))
+	return nil
+}
 
-	if len(valueStrings) == 0 {
+// orders 테이블에 청산 주문 배치 삽입
+func insertLiquidationOrders(ctx context.Context, tx *sql.Tx, orders []model.LiquidationOrder, seasonID int) error {
+	if len(orders) == 0 {
 		return nil // 삽입할 데이터가 없음
 	}
 
+	// bulk insert 쿼리 생성을 위한 준비
+	valueStrings := make([]string, 0, len(orders))
+	valueArgs := make([]interface{}, 0, len(orders)*5)
+
+	for _, order := range orders {
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, 'SELL', 'COMPLETED')")
+		valueArgs = append(valueArgs,
+			order.Amount,
+			order.SymbolID,
+			order.UserID,
+			seasonID,
+			order.TradePrice)
+	}
+
 	// bulk insert 쿼리 완성
 	stmt := fmt.Sprintf(`
 		INSERT INTO orders (amount, symbol_id, user_id, season_id, trade_price, order_type, status) 
 		VALUES %s`, strings.Join(valueStrings, ","))
 
-	_, err = tx.ExecContext(queryCtx, stmt, valueArgs...)
-	if err != nil {
+	if _, err := tx.ExecContext(ctx, stmt, valueArgs...); err != nil {
 		return fmt.Errorf("orders 배치 삽입 실패: %w", err)
 	}
 
-	return tx.Commit()
-}
-
-// 전체 user_assets 개수 조회 (진행률 표시용)
-func getUserAssetsCount(ctx context.Context, db *sql.DB) (int, error) {
-	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	var count int
-	query := `SELECT COUNT(*) FROM user_assets`
-	err := db.QueryRowContext(queryCtx, query).Scan(&count)
-	if err != nil {
-		return 0, err
-	}
-
-	return count, nil
+	return nil
 }
 
-// 진행률과 함께 실행하는 래퍼 함수
-func resetUserAssetsWithProgress(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64) error {
-	// 전체 개수 조회
-	totalCount, err := getUserAssetsCount(ctx, db)
-	if err != nil {
-		return fmt.Errorf("user_assets 개수 조회 실패: %w", err)
-	}
+// 보유 자산을 현재가 기준 청산 매도 주문으로 변환합니다. 가격 정보가 없어 제외된 자산 수도 함께 반환합니다.
+func buildLiquidationOrders(userAssets []model.UserAsset, coinPrices map[int]float64) ([]model.LiquidationOrder, int) {
+	orders := make([]model.LiquidationOrder, 0, len(userAssets))
+	skipped := 0
 
-	log.Printf("총 %d개의 user_assets 레코드를 처리합니다\n", totalCount)
+	for _, asset := range userAssets {
+		// coinPrices 맵에서 trade_price 조회
+		tradePrice, exists := coinPrices[asset.SymbolID]
+		if !exists {
+			// 가격 정보가 없는 경우 청산 대상에서 제외
+			log.Printf("경고: symbol_id %d에 대한 가격 정보가 없습니다\n", asset.SymbolID)
+			skipped++
+			continue
+		}
 
-	// 초기화 실행
-	err = resetUserAssets(ctx, db, seasonID, coinPrices)
-	if err != nil {
-		return err
+		orders = append(orders, model.LiquidationOrder{
+			AssetID:    asset.ID,
+			UserID:     asset.UserID,
+			SymbolID:   asset.SymbolID,
+			Amount:     asset.Amount,
+			TradePrice: tradePrice,
+		})
 	}
 
-	log.Println("user_assets 테이블 초기화가 완료되었습니다")
-	return nil
+	return orders, skipped
 }
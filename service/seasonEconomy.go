@@ -0,0 +1,126 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 시즌 경제 설정이 없을 때 사용하는 기본값
+const (
+	defaultStartingCash = 10000000
+	defaultSplitBonus   = 10000000
+)
+
+// 다음 시즌의 기본 경제 설정을 담는 season_economies 행의 season_id
+// 시즌 전환 시 이 행을 새 시즌 설정으로 복사합니다.
+const economyTemplateSeasonID = 0
+
+// ensureSeasonEconomyTable season_economies 테이블이 없으면 생성
+// 시즌별 시작 현금, 스플릿 지급액, 스플릿 횟수(0이면 제한 없음), 티어별 지급 배율(JSON, 예: {"1": 1.1})을 저장합니다.
+func ensureSeasonEconomyTable(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS season_economies (
+			season_id INT PRIMARY KEY,
+			starting_cash BIGINT NOT NULL,
+			split_bonus BIGINT NOT NULL,
+			split_count INT NOT NULL DEFAULT 0,
+			tier_multipliers JSON NULL,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// getSeasonEconomy 시즌 경제 설정 조회
+// 해당 시즌 행이 없으면 기본 설정 행(season_id = 0)을, 그것도 없으면 기본값을 사용합니다.
+// season_economies 테이블이 아직 없어도 기본값을 사용합니다. (드라이런처럼 테이블을 만들지 않는 읽기 전용 경로)
+func getSeasonEconomy(ctx context.Context, q rowQuerier, seasonID int) (model.SeasonEconomy, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT starting_cash, split_bonus, split_count, tier_multipliers
+		FROM season_economies
+		WHERE season_id IN (?, ?)
+		ORDER BY season_id = ? DESC
+		LIMIT 1
+	`
+
+	economy := model.SeasonEconomy{SeasonID: seasonID}
+	var multipliers sql.NullString
+	err := q.QueryRowContext(queryCtx, query, seasonID, economyTemplateSeasonID, seasonID).
+		Scan(&economy.StartingCash, &economy.SplitBonus, &economy.SplitCount, &multipliers)
+	if errors.Is(err, sql.ErrNoRows) || isMissingTable(err) {
+		economy.StartingCash = defaultStartingCash
+		economy.SplitBonus = defaultSplitBonus
+		return economy, nil
+	}
+	if err != nil {
+		return economy, fmt.Errorf("시즌 경제 설정 조회 실패: %w", err)
+	}
+
+	if multipliers.Valid && multipliers.String != "" {
+		if economy.TierMultipliers, err = parseTierMultipliers(multipliers.String); err != nil {
+			return economy, fmt.Errorf("시즌 %d 티어 배율 설정 오류: %w", seasonID, err)
+		}
+	}
+
+	return economy, nil
+}
+
+// 다음 시즌에 적용될 경제 설정 조회 (새 시즌 행이 만들어지기 전에 사용)
+func getNextSeasonEconomy(ctx context.Context, q rowQuerier) (model.SeasonEconomy, error) {
+	return getSeasonEconomy(ctx, q, economyTemplateSeasonID)
+}
+
+// 기본 설정 행을 새 시즌의 경제 설정으로 복사합니다. 기본 설정 행이 없으면 기본값이 그대로 적용됩니다.
+func copySeasonEconomy(ctx context.Context, db execer, newSeasonID int64) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	insertQuery := `
+		INSERT IGNORE INTO season_economies (season_id, starting_cash, split_bonus, split_count, tier_multipliers)
+		SELECT ?, starting_cash, split_bonus, split_count, tier_multipliers
+		FROM season_economies
+		WHERE season_id = ?
+	`
+
+	if _, err := db.ExecContext(queryCtx, insertQuery, newSeasonID, economyTemplateSeasonID); err != nil {
+		return fmt.Errorf("시즌 경제 설정 복사 실패: %w", err)
+	}
+
+	return nil
+}
+
+// {"티어": 배율} 형태의 JSON을 파싱합니다.
+func parseTierMultipliers(raw string) (map[int]float64, error) {
+	var byKey map[string]float64
+	if err := json.Unmarshal([]byte(raw), &byKey); err != nil {
+		return nil, err
+	}
+
+	multipliers := make(map[int]float64, len(byKey))
+	for key, multiplier := range byKey {
+		tier, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("잘못된 티어 '%s'", key)
+		}
+		if multiplier < 0 {
+			return nil, fmt.Errorf("티어 %d 배율이 음수", tier)
+		}
+		multipliers[tier] = multiplier
+	}
+
+	return multipliers, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// IsSeasonDryRun obj의 SEASON_DRY_RUN 설정으로 시즌 종료 드라이런 여부를 확인합니다.
+func IsSeasonDryRun(obj map[string]interface{}) bool {
+	return util.GetString(obj, "SEASON_DRY_RUN", "false") == "true"
+}
+
+// ErrSeasonPendingApproval 시즌 전환 시각에 드라이런이 켜져 있어 실제 전환을 하지 않았음을 나타냅니다.
+// 다음 정기 실행은 시즌 전환 시각이 아니므로 저절로 전환되지 않습니다.
+var ErrSeasonPendingApproval = errors.New("시즌 전환 시각이지만 SEASON_DRY_RUN이 켜져 있어 운영자 승인 대기 중 " +
+	"(보고서 확인 후 SEASON_DRY_RUN 없이 같은 시각의 TEST_TIME으로 다시 실행해야 함)")
+
+// PreviewSeason 시즌 종료 시 일어날 변경 사항을 DB에 쓰지 않고 계산하여 보고서로 반환합니다.
+// 테이블 생성도 하지 않으며, 아직 없는 설정 테이블은 기본값으로 계산합니다.
+// UpdateSeason과 같은 계산 함수(nextSeasonName, buildLiquidationOrders, SeasonEnd)를 사용합니다.
+func PreviewSeason(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64, obj map[string]interface{}) (model.SeasonReport, error) {
+	seasonName := obj["SEASON_NAME"].(string)
+	report := model.SeasonReport{SeasonID: seasonID}
+
+	// 1. 새 시즌 이름 및 기간
+	startAt, err := util.RunTime(obj)
+	if err != nil {
+		return report, err
+	}
+	schedule, err := util.LoadSchedule(obj)
+	if err != nil {
+		return report, fmt.Errorf("실행 일정 설정 실패: %w", err)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	report.SeasonName, report.NewSeasonName, err = nextSeasonName(queryCtx, db, seasonID, seasonName)
+	if err != nil {
+		return report, err
+	}
+
+	// 드라이런은 시즌 시작 시각이 아니어도 미리 볼 수 있도록 다음 시즌 시작 일정 기준으로 계산
+	if !schedule.Season.Matches(startAt) {
+		if startAt, err = schedule.Season.Next(startAt); err != nil {
+			return report, fmt.Errorf("다음 시즌 시작일 계산 실패: %w", err)
+		}
+	}
+	endAt, err := schedule.SeasonEnd(startAt)
+	if err != nil {
+		return report, fmt.Errorf("시즌 종료일 설정 실패: %w", err)
+	}
+	report.NewSeasonStartAt = startAt.Format("2006-01-02")
+	report.NewSeasonEndAt = endAt.Format("2006-01-02")
+
+	// 2. 티어 변경 내역
+	if report.TierChanges, err = previewTierChanges(ctx, db, seasonID); err != nil {
+		return report, fmt.Errorf("티어 변경 내역 조회 실패: %w", err)
+	}
+
+	// 3. 보유 자산 청산 주문
+	if err := previewLiquidation(ctx, db, coinPrices, &report); err != nil {
+		return report, fmt.Errorf("청산 주문 계산 실패: %w", err)
+	}
+
+	// 4. 삭제될 예약 주문
+	if report.PendingOrders, err = getPendingOrders(ctx, db, seasonID); err != nil {
+		return report, fmt.Errorf("예약 주문 조회 실패: %w", err)
+	}
+
+	// 5. 현금이 초기화될 유저 수와 새 시즌 시작 현금
+	if report.CashResetUsers, err = countActiveUsers(ctx, db); err != nil {
+		return report, fmt.Errorf("유저 수 조회 실패: %w", err)
+	}
+	economy, err := getNextSeasonEconomy(ctx, db)
+	if err != nil {
+		return report, err
+	}
+	report.StartingCash = economy.StartingCash
+
+	log.Printf("시즌 종료 드라이런: %s → %s, 티어 변경 %d명, 청산 주문 %d건, 예약 주문 삭제 %d건\n",
+		report.SeasonName, report.NewSeasonName, len(report.TierChanges),
+		len(report.LiquidationOrders), len(report.PendingOrders))
+
+	return report, nil
+}
+
+// updateUserTiers가 변경할 유저 티어 목록을 조회합니다.
+func previewTierChanges(ctx context.Context, db *sql.DB, seasonID int) ([]model.TierChange, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT u.id, u.tier, IFNULL(ur.tier, 0)
+		FROM users u
+		LEFT JOIN user_rankings ur ON u.id = ur.user_id AND ur.season_id = ?
+		WHERE u.is_deleted = 0 AND u.tier <> IFNULL(ur.tier, 0)
+		ORDER BY u.id
+	`
+
+	rows, err := db.QueryContext(queryCtx, query, seasonID)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	changes := []model.TierChange{}
+	for rows.Next() {
+		var change model.TierChange
+		if err := rows.Scan(&change.UserID, &change.OldTier, &change.NewTier); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// liquidateUserAssets가 삽입할 청산 주문을 계산하여 보고서에 기록합니다.
+func previewLiquidation(ctx context.Context, db *sql.DB, coinPrices map[int]float64, report *model.SeasonReport) error {
+	const batchSize = 1000
+	report.LiquidationOrders = []model.LiquidationOrder{}
+
+	_, err := util.KeysetBatches(ctx, batchSize, userAssetsFetcher(db), userAssetID,
+		func(userAssets []model.UserAsset) error {
+			orders, skipped := buildLiquidationOrders(userAssets, coinPrices)
+			for _, order := range orders {
+				report.LiquidationValue += int64(math.Round(order.Amount * order.TradePrice))
+			}
+			report.LiquidationOrders = append(report.LiquidationOrders, orders...)
+			report.LiquidationSkipped += skipped
+			return nil
+		})
+	return err
+}
+
+// 탈퇴하지 않은 유저 수 조회
+func countActiveUsers(ctx context.Context, db *sql.DB) (int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int
+	err := db.QueryRowContext(queryCtx, `SELECT COUNT(*) FROM users WHERE is_deleted = 0`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"Bitground-go/config"
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// 시즌 전환 진행 상태
+const (
+	rolloverRunning   = "RUNNING"
+	rolloverFailed    = "FAILED"
+	rolloverCompleted = "COMPLETED"
+)
+
+// seasonRolloverRun season_rollover_runs 테이블의 시즌 전환 실행 기록
+type seasonRolloverRun struct {
+	ID            int64
+	SeasonID      int
+	NewSeasonID   int64
+	NewSeasonName string
+	ScheduledAt   string // 시즌 전환 일정 시각 (재실행 판별용)
+	StartDate     string
+	EndDate       string
+	LastStep      string
+	Status        string
+	// 청산 가격 (전환 기록을 만들 때의 시세, 재개해도 같은 가격으로 청산)
+	LiquidationPrices map[int]float64
+}
+
+// rolloverEnv 시즌 전환 단계 실행에 필요한 외부 값
+type rolloverEnv struct {
+	coinPrices map[int]float64 // 이번 실행의 시세 (청산 가격 기록이 없는 전환에만 사용)
+}
+
+// rolloverStep 시즌 전환 단계
+// tx가 있으면 단계 작업과 완료 체크포인트를 한 트랜잭션으로 실행하고,
+// 없으면 run을 실행한 뒤 체크포인트를 기록합니다. (run은 다시 실행해도 안전해야 합니다)
+type rolloverStep struct {
+	name string
+	tx   func(ctx context.Context, tx *sql.Tx, run *seasonRolloverRun, env rolloverEnv) error
+	run  func(ctx context.Context, db *sql.DB, run *seasonRolloverRun, env rolloverEnv) error
+}
+
+// 시즌 전환 단계 (실행 순서대로)
+// 기존 시즌을 종료하는 CLOSE_SEASON 전까지는 기존 시즌 기준으로 데이터를 정리합니다.
+var rolloverSteps = []rolloverStep{
+	{name: "UPDATE_TIERS", tx: func(ctx context.Context, tx *sql.Tx, run *seasonRolloverRun, _ rolloverEnv) error {
+		return updateUserTiers(ctx, tx, run.SeasonID)
+	}},
+	{name: "DELETE_PENDING_ORDERS", tx: func(ctx context.Context, tx *sql.Tx, run *seasonRolloverRun, _ rolloverEnv) error {
+		return deletePendingOrders(ctx, tx, run.SeasonID)
+	}},
+	{name: "LIQUIDATE_ASSETS", run: func(ctx context.Context, db *sql.DB, run *seasonRolloverRun, env rolloverEnv) error {
+		// 배치 단위로 커밋되며, 재실행 시 남은 자산부터 전환 기록의 청산 가격으로 이어서 처리
+		return liquidateUserAssets(ctx, db, run.SeasonID, run.LiquidationPrices)
+	}},
+	{name: "RESET_CASH", tx: func(ctx context.Context, tx *sql.Tx, run *seasonRolloverRun, _ rolloverEnv) error {
+		economy, err := getNextSeasonEconomy(ctx, tx)
+		if err != nil {
+			return err
+		}
+		return resetUserCash(ctx, tx, run.SeasonID, economy.StartingCash)
+	}},
+	{name: "CLOSE_SEASON", tx: func(ctx context.Context, tx *sql.Tx, run *seasonRolloverRun, _ rolloverEnv) error {
+		newSeasonID, err := closeSeason(ctx, tx, run.SeasonID, run.NewSeasonName, run.StartDate, run.EndDate)
+		if err != nil {
+			return err
+		}
+		run.NewSeasonID = newSeasonID
+		if err := copySeasonEconomy(ctx, tx, newSeasonID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE season_rollover_runs SET new_season_id = ? WHERE id = ?`, newSeasonID, run.ID); err != nil {
+			return fmt.Errorf("새 시즌 ID 기록 실패: %w", err)
+		}
+		return nil
+	}},
+	{name: "MARK_REWARD_CALCULATED", tx: func(ctx context.Context, tx *sql.Tx, run *seasonRolloverRun, _ rolloverEnv) error {
+		return updateSeasonRewardCalculated(ctx, tx, run.SeasonID)
+	}},
+	// 스프링 서버 알림은 전환 단계에 넣지 않고 완료 후 NotifySeasonRollovers에서 따로 재시도합니다.
+	// (외부 API 장애로 전환이 끝나지 않으면 체결/랭킹/시즌 업데이트가 계속 생략되므로)
+}
+
+// UpdateSeason 기존 시즌을 종료하고 새 시즌을 시작합니다.
+// 전환은 season_rollover_runs에 단계별로 기록되며, 중단된 전환이 있으면 새로 시작하지 않고 이어서 진행합니다.
+func UpdateSeason(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64, obj map[string]interface{}) error {
+	if err := ensureSeasonRolloverTable(ctx, db); err != nil {
+		return fmt.Errorf("시즌 전환 기록 테이블 생성 실패: %w", err)
+	}
+	env := newRolloverEnv(coinPrices)
+
+	// 1. 중단된 시즌 전환이 있으면 이어서 진행
+	run, err := getUnfinishedRollover(ctx, db)
+	if err != nil {
+		return fmt.Errorf("진행중인 시즌 전환 조회 실패: %w", err)
+	}
+
+	// 2. 없다면 새 시즌 전환 기록 생성
+	if run == nil {
+		run, err = createRolloverRun(ctx, db, seasonID, coinPrices, obj)
+		if err != nil {
+			return err
+		}
+		if run == nil {
+			log.Println("이번 일정의 시즌 전환이 이미 완료되어 생략")
+			return nil
+		}
+	}
+
+	// 3. 남은 단계 실행
+	return executeRollover(ctx, db, run, env)
+}
+
+// ResumeSeasonRollover 중단된 시즌 전환이 있으면 이어서 완료합니다. 이어서 진행했는지 여부를 반환합니다.
+// 시즌이 반쯤 종료된 상태에서 랭킹 등이 계산되지 않도록 Main에서 다른 작업보다 먼저 호출합니다.
+func ResumeSeasonRollover(ctx context.Context, db *sql.DB, coinPrices map[int]float64) (bool, error) {
+	if err := ensureSeasonRolloverTable(ctx, db); err != nil {
+		return false, fmt.Errorf("시즌 전환 기록 테이블 생성 실패: %w", err)
+	}
+
+	run, err := getUnfinishedRollover(ctx, db)
+	if err != nil {
+		return false, fmt.Errorf("진행중인 시즌 전환 조회 실패: %w", err)
+	}
+	if run == nil {
+		return false, nil
+	}
+
+	log.Printf("중단된 시즌 전환 재개 (season_id: %d, 마지막 완료 단계: %q)\n", run.SeasonID, run.LastStep)
+	return true, executeRollover(ctx, db, run, newRolloverEnv(coinPrices))
+}
+
+func newRolloverEnv(coinPrices map[int]float64) rolloverEnv {
+	return rolloverEnv{coinPrices: coinPrices}
+}
+
+// season_rollover_runs 테이블 생성
+func ensureSeasonRolloverTable(ctx context.Context, db *sql.DB) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS season_rollover_runs (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			season_id INT NOT NULL,
+			new_season_id BIGINT NULL,
+			new_season_name VARCHAR(255) NOT NULL,
+			scheduled_at VARCHAR(19) NOT NULL,
+			start_date VARCHAR(10) NOT NULL,
+			end_date VARCHAR(10) NOT NULL,
+			last_step VARCHAR(50) NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL,
+			liquidation_prices JSON NULL,
+			notified TINYINT(1) NOT NULL DEFAULT 1,
+			error TEXT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			UNIQUE KEY uk_season_rollover_runs_season (season_id),
+			UNIQUE KEY uk_season_rollover_runs_scheduled (scheduled_at)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// 완료되지 않은 가장 최근 시즌 전환 기록 조회, 없으면 nil
+func getUnfinishedRollover(ctx context.Context, db *sql.DB) (*seasonRolloverRun, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, season_id, IFNULL(new_season_id, 0), new_season_name, scheduled_at, start_date, end_date, last_step, status,
+			liquidation_prices
+		FROM season_rollover_runs
+		WHERE status <> ?
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var run seasonRolloverRun
+	var prices sql.NullString
+	err := db.QueryRowContext(queryCtx, query, rolloverCompleted).Scan(
+		&run.ID, &run.SeasonID, &run.NewSeasonID, &run.NewSeasonName,
+		&run.ScheduledAt, &run.StartDate, &run.EndDate, &run.LastStep, &run.Status, &prices)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+
+	if prices.Valid {
+		if err := json.Unmarshal([]byte(prices.String), &run.LiquidationPrices); err != nil {
+			return nil, fmt.Errorf("시즌 전환 %d 청산 가격 파싱 실패: %w", run.ID, err)
+		}
+	}
+
+	return &run, nil
+}
+
+// 새 시즌 이름과 기간을 계산하여 시즌 전환 기록을 생성합니다. 청산 가격으로 쓸 coinPrices도 함께 기록합니다.
+// 같은 시작 시각의 전환이 이미 있으면(재실행) nil을 반환합니다.
+func createRolloverRun(ctx context.Context, db *sql.DB, seasonID int, coinPrices map[int]float64, obj map[string]interface{}) (*seasonRolloverRun, error) {
+	seasonName := obj["SEASON_NAME"].(string)
+
+	// 시즌 시작/종료일 계산 기준 (플래그 계산과 같은 일정 사용)
+	startAt, err := util.RunTime(obj)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := util.LoadSchedule(obj)
+	if err != nil {
+		return nil, fmt.Errorf("실행 일정 설정 실패: %w", err)
+	}
+	endAt, err := schedule.SeasonEnd(startAt)
+	if err != nil {
+		return nil, fmt.Errorf("시즌 종료일 설정 실패: %w", err)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	scheduledAt := startAt.Format("2006-01-02 15:04:05")
+
+	var existing int
+	err = db.QueryRowContext(queryCtx,
+		`SELECT COUNT(*) FROM season_rollover_runs WHERE scheduled_at = ? OR season_id = ?`,
+		scheduledAt, seasonID).Scan(&existing)
+	if err != nil {
+		return nil, fmt.Errorf("시즌 전환 기록 조회 실패: %w", err)
+	}
+	if existing > 0 {
+		return nil, nil
+	}
+
+	_, newSeasonName, err := nextSeasonName(queryCtx, db, seasonID, seasonName)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := json.Marshal(coinPrices)
+	if err != nil {
+		return nil, fmt.Errorf("청산 가격 직렬화 실패: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO season_rollover_runs (season_id, new_season_name, scheduled_at, start_date, end_date, status, liquidation_prices)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	startDate, endDate := startAt.Format("2006-01-02"), endAt.Format("2006-01-02")
+	result, err := db.ExecContext(queryCtx, insertQuery, seasonID, newSeasonName, scheduledAt, startDate, endDate, rolloverRunning, string(prices))
+	if err != nil {
+		return nil, fmt.Errorf("시즌 전환 기록 생성 실패: %w", err)
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("시즌 전환 기록 ID 조회 실패: %w", err)
+	}
+
+	return &seasonRolloverRun{
+		ID:            runID,
+		SeasonID:      seasonID,
+		NewSeasonName: newSeasonName,
+		ScheduledAt:   scheduledAt,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		Status:        rolloverRunning,
+
+		LiquidationPrices: coinPrices,
+	}, nil
+}
+
+// 마지막 완료 단계 다음부터 남은 단계를 순서대로 실행합니다.
+func executeRollover(ctx context.Context, db *sql.DB, run *seasonRolloverRun, env rolloverEnv) error {
+	if err := ensureCashLedgerTable(ctx, db); err != nil {
+		return fmt.Errorf("cash_ledger 테이블 생성 실패: %w", err)
+	}
+	if err := ensureSeasonEconomyTable(ctx, db); err != nil {
+		return fmt.Errorf("season_economies 테이블 생성 실패: %w", err)
+	}
+
+	// 청산 가격이 기록되지 않은 전환은 이번 실행의 시세를 기록해 이후 재개에도 같은 가격을 사용
+	if run.LiquidationPrices == nil {
+		if err := saveLiquidationPrices(ctx, db, run, env.coinPrices); err != nil {
+			return err
+		}
+	}
+
+	started := run.LastStep == ""
+	for _, step := range rolloverSteps {
+		if !started {
+			started = step.name == run.LastStep
+			continue
+		}
+
+		log.Printf("시즌 전환 단계 시작: %s\n", step.name)
+		if err := runRolloverStep(ctx, db, run, env, step); err != nil {
+			if markErr := markRolloverFailed(ctx, db, run, err); markErr != nil {
+				log.Printf("시즌 전환 실패 기록 에러: %v\n", markErr)
+			}
+			return fmt.Errorf("시즌 전환 단계 %s 실패: %w", step.name, err)
+		}
+		run.LastStep = step.name
+	}
+
+	if !started {
+		return fmt.Errorf("알 수 없는 시즌 전환 단계: %s", run.LastStep)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := db.ExecContext(queryCtx,
+		`UPDATE season_rollover_runs SET status = ?, notified = 0, error = NULL WHERE id = ?`, rolloverCompleted, run.ID)
+	if err != nil {
+		return fmt.Errorf("시즌 전환 완료 기록 실패: %w", err)
+	}
+
+	log.Printf("시즌 전환 완료: %s (new_season_id: %d)\n", run.NewSeasonName, run.NewSeasonID)
+	return nil
+}
+
+// NotifySeasonRollovers 완료되었지만 스프링 서버에 알리지 못한 시즌 전환이 있으면 시즌 업데이트를 요청합니다.
+// 알림에 실패하면 다음 실행에서 다시 시도하며, 시즌 전환 완료 여부나 다른 작업에는 영향을 주지 않습니다.
+// 알림을 보낸 전환 수를 반환합니다.
+func NotifySeasonRollovers(ctx context.Context, db *sql.DB, obj map[string]interface{}) (int, error) {
+	if err := ensureSeasonRolloverTable(ctx, db); err != nil {
+		return 0, fmt.Errorf("시즌 전환 기록 테이블 생성 실패: %w", err)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var runIDs []int64
+	rows, err := db.QueryContext(queryCtx,
+		`SELECT id FROM season_rollover_runs WHERE status = ? AND notified = 0 ORDER BY id`, rolloverCompleted)
+	if err != nil {
+		return 0, fmt.Errorf("알림 대기 시즌 전환 조회 실패: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		runIDs = append(runIDs, id)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, fmt.Errorf("행 닫기 에러: %w", err)
+	}
+	if len(runIDs) == 0 {
+		return 0, nil
+	}
+
+	// 스프링 서버는 현재 시즌 기준으로 갱신하므로 대기 중인 전환이 여러 개여도 한 번만 요청
+	apiCfg := config.NewAPIConfig(obj)
+	if err := NotifySeasonUpdate(ctx, apiCfg.BitgroundBaseURL, obj["SEASON_UPDATE_KEY"].(string), "season"); err != nil {
+		return 0, fmt.Errorf("NotifySeasonUpdate 에러: %w", err)
+	}
+
+	for _, id := range runIDs {
+		if _, err := db.ExecContext(queryCtx, `UPDATE season_rollover_runs SET notified = 1 WHERE id = ?`, id); err != nil {
+			return 0, fmt.Errorf("시즌 전환 알림 기록 실패: %w", err)
+		}
+	}
+	log.Printf("시즌 전환 알림 완료: %d건\n", len(runIDs))
+	return len(runIDs), nil
+}
+
+// 시즌 전환 기록에 청산 가격을 저장합니다.
+func saveLiquidationPrices(ctx context.Context, db *sql.DB, run *seasonRolloverRun, coinPrices map[int]float64) error {
+	prices, err := json.Marshal(coinPrices)
+	if err != nil {
+		return fmt.Errorf("청산 가격 직렬화 실패: %w", err)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(queryCtx,
+		`UPDATE season_rollover_runs SET liquidation_prices = ? WHERE id = ?`, string(prices), run.ID); err != nil {
+		return fmt.Errorf("청산 가격 기록 실패: %w", err)
+	}
+	run.LiquidationPrices = coinPrices
+	return nil
+}
+
+// 단계 하나를 실행하고 완료 체크포인트를 기록합니다.
+func runRolloverStep(ctx context.Context, db *sql.DB, run *seasonRolloverRun, env rolloverEnv, step rolloverStep) error {
+	if step.tx == nil {
+		if err := step.run(ctx, db, run, env); err != nil {
+			return err
+		}
+		return saveRolloverCheckpoint(ctx, db, run, step.name)
+	}
+
+	return runRolloverTxStep(ctx, db, run, step.name, func(ctx context.Context, tx *sql.Tx) error {
+		return step.tx(ctx, tx, run, env)
+	})
+}
+
+// 단계 작업과 완료 체크포인트를 하나의 트랜잭션으로 실행합니다.
+func runRolloverTxStep(ctx context.Context, db *sql.DB, run *seasonRolloverRun, step string, fn func(ctx context.Context, tx *sql.Tx) error) (err error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// 트랜잭션 시작
+	tx, err := db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 에러: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(queryCtx, tx); err != nil {
+		return err
+	}
+
+	if err = saveRolloverCheckpoint(queryCtx, tx, run, step); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// 단계 완료 체크포인트 기록
+func saveRolloverCheckpoint(ctx context.Context, db execer, run *seasonRolloverRun, step string) error {
+	_, err := db.ExecContext(ctx,
+		`UPDATE season_rollover_runs SET last_step = ?, status = ?, error = NULL WHERE id = ?`,
+		step, rolloverRunning, run.ID)
+	if err != nil {
+		return fmt.Errorf("시즌 전환 체크포인트 기록 실패: %w", err)
+	}
+	return nil
+}
+
+// 시즌 전환 실패 기록, 다음 실행에서 마지막 완료 단계부터 재개됩니다.
+func markRolloverFailed(ctx context.Context, db *sql.DB, run *seasonRolloverRun, cause error) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := db.ExecContext(queryCtx,
+		`UPDATE season_rollover_runs SET status = ?, error = ? WHERE id = ?`,
+		rolloverFailed, cause.Error(), run.ID)
+	return err
+}
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"Bitground-go/model"
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 섹터 종목 출처
+const (
+	sectorSourceManual   = "MANUAL"    // coin_sectors에 등록한 종목
+	sectorSourceUpbitKRW = "UPBIT_KRW" // 업비트 원화 마켓 상장 종목 (coins 테이블)
+)
+
+// 섹터 분류가 비어 있을 때 넣는 초기 섹터와 종목 (CoinGecko 심볼)
+// 이후 섹터와 종목은 index_sectors, coin_sectors 테이블에서 관리합니다.
+// 김치 섹터는 종목을 따로 등록하지 않고 업비트 원화 마켓 상장 종목을 그대로 사용합니다.
+var defaultSectors = []sector{
+	{Code: "DEFI", Name: "디파이", Symbols: []string{"uni", "aave", "mkr", "crv", "comp", "snx", "ldo", "1inch", "sushi"}},
+	{Code: "L1", Name: "레이어1", Symbols: []string{"eth", "sol", "ada", "avax", "dot", "near", "atom", "trx", "apt", "sui"}},
+	{Code: "L2", Name: "레이어2", Symbols: []string{"arb", "op", "pol", "imx", "strk", "mnt"}},
+	{Code: "MEME", Name: "밈코인", Symbols: []string{"doge", "shib", "pepe", "bonk", "wif", "floki"}},
+	{Code: "AI", Name: "AI", Symbols: []string{"fet", "render", "tao", "wld", "grt"}},
+	{Code: "KIMCHI", Name: "김치 코인", Source: sectorSourceUpbitKRW},
+}
+
+// sector 인덱스를 계산할 섹터와 소속 종목
+type sector struct {
+	Code    string
+	Name    string
+	Source  string   // sectorSourceManual, sectorSourceUpbitKRW
+	Symbols []string // CoinGecko 심볼 (소문자)
+}
+
+// 섹터 인덱스의 인덱스 정의/기록 코드
+func sectorIndexCode(sectorCode string) string {
+	return "SECTOR_" + sectorCode
+}
+
+// 섹터 인덱스 정의가 없을 때 사용하는 기본 정의 (버전 1)
+// 한 종목이 섹터 전체를 좌우하지 않도록 종목별 비중을 30%로 제한하고, 1000에서 시작합니다.
+// 상장 종목 전체를 쓰는 김치 섹터에 스테이블 코인이 편입되지 않도록 제외합니다.
+func defaultSectorIndexDefinition(sectorCode string) model.IndexDefinition {
+	return model.IndexDefinition{
+		Code:             sectorIndexCode(sectorCode),
+		Version:          1,
+		ConstituentCount: 20,
+		Excluded:         []string{"usdt", "usdc", "usds", "usde", "dai"},
+		Weighting:        model.WeightingCapped,
+		MaxWeight:        0.3,
+		Divisor:          1,
+		BaseValue:        1000,
+	}
+}
+
+// ensureSectorTables index_sectors, coin_sectors, sector_indices, coin_gecko_ids 테이블이 없으면 생성
+// index_sectors가 비어 있으면 초기 섹터 분류(defaultSectors)를 넣습니다.
+func ensureSectorTables(ctx context.Context, db *sql.DB) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQueries := []string{`
+		CREATE TABLE IF NOT EXISTS index_sectors (
+			code VARCHAR(20) PRIMARY KEY,
+			name VARCHAR(50) NOT NULL,
+			source VARCHAR(20) NOT NULL DEFAULT 'MANUAL',
+			is_active TINYINT(1) NOT NULL DEFAULT 1
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS coin_sectors (
+			sector_code VARCHAR(20) NOT NULL,
+			symbol VARCHAR(30) NOT NULL,
+			PRIMARY KEY (sector_code, symbol)
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS sector_indices (
+			sector_code VARCHAR(20) NOT NULL,
+			date DATE NOT NULL,
+			hour INT NOT NULL,
+			value DOUBLE NOT NULL,
+			constituent_count INT NOT NULL,
+			definition_version INT NOT NULL,
+			PRIMARY KEY (sector_code, date, hour)
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS coin_gecko_ids (
+			symbol VARCHAR(30) PRIMARY KEY,
+			gecko_id VARCHAR(100) NOT NULL
+		)
+	`}
+	for _, query := range createQueries {
+		if _, err := db.ExecContext(queryCtx, query); err != nil {
+			return err
+		}
+	}
+
+	var count int
+	if err := db.QueryRowContext(queryCtx, `SELECT COUNT(*) FROM index_sectors`).Scan(&count); err != nil {
+		return fmt.Errorf("섹터 수 조회 실패: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	sectorValues := make([]string, 0, len(defaultSectors))
+	sectorArgs := make([]interface{}, 0, len(defaultSectors)*3)
+	var coinValues []string
+	var coinArgs []interface{}
+	for _, s := range defaultSectors {
+		source := s.Source
+		if source == "" {
+			source = sectorSourceManual
+		}
+		sectorValues = append(sectorValues, "(?, ?, ?)")
+		sectorArgs = append(sectorArgs, s.Code, s.Name, source)
+		for _, symbol := range s.Symbols {
+			coinValues = append(coinValues, "(?, ?)")
+			coinArgs = append(coinArgs, s.Code, symbol)
+		}
+	}
+
+	if _, err := db.ExecContext(queryCtx, fmt.Sprintf(
+		`INSERT IGNORE INTO index_sectors (code, name, source) VALUES %s`, strings.Join(sectorValues, ",")),
+		sectorArgs...); err != nil {
+		return fmt.Errorf("초기 섹터 저장 실패: %w", err)
+	}
+	if _, err := db.ExecContext(queryCtx, fmt.Sprintf(
+		`INSERT IGNORE INTO coin_sectors (sector_code, symbol) VALUES %s`, strings.Join(coinValues, ",")),
+		coinArgs...); err != nil {
+		return fmt.Errorf("초기 섹터 종목 저장 실패: %w", err)
+	}
+	log.Printf("섹터 분류가 없어 초기 섹터 %d개 저장\n", len(defaultSectors))
+
+	return nil
+}
+
+// loadSectors 활성 섹터와 소속 종목을 조회합니다. (종목이 없는 섹터는 제외)
+// 업비트 원화 마켓 섹터는 coins 테이블의 상장 종목을 소속 종목으로 사용합니다.
+func loadSectors(ctx context.Context, db *sql.DB) ([]sector, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT s.code, s.name, s.source, cs.symbol
+		FROM index_sectors s
+		LEFT JOIN coin_sectors cs ON cs.sector_code = s.code
+		WHERE s.is_active = 1
+		ORDER BY s.code, cs.symbol
+	`
+
+	rows, err := db.QueryContext(queryCtx, query)
+	if err != nil {
+		return nil, fmt.Errorf("섹터 조회 실패: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	var sectors []sector
+	for rows.Next() {
+		var code, name, source string
+		var symbol sql.NullString
+		if err := rows.Scan(&code, &name, &source, &symbol); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		if len(sectors) == 0 || sectors[len(sectors)-1].Code != code {
+			sectors = append(sectors, sector{Code: code, Name: name, Source: source})
+		}
+		if last := &sectors[len(sectors)-1]; symbol.Valid && source == sectorSourceManual {
+			last.Symbols = append(last.Symbols, strings.ToLower(symbol.String))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("행 반복 에러: %w", err)
+	}
+
+	var listed []string
+	for i := range sectors {
+		if sectors[i].Source != sectorSourceUpbitKRW {
+			continue
+		}
+		if listed == nil {
+			if listed, err = listedKRWSymbols(ctx, db); err != nil {
+				return nil, err
+			}
+		}
+		sectors[i].Symbols = listed
+	}
+
+	filtered := sectors[:0]
+	for _, s := range sectors {
+		if len(s.Symbols) > 0 {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered, nil
+}
+
+// listedKRWSymbols 업비트 원화 마켓에 상장된 코인의 심볼을 CoinGecko 형식(소문자, 마켓 접두사 제외)으로 조회합니다.
+func listedKRWSymbols(ctx context.Context, db *sql.DB) ([]string, error) {
+	symbolMap, err := GetActiveCoinsSymbols(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("상장 코인 조회 실패: %w", err)
+	}
+
+	symbols := make([]string, 0, len(symbolMap))
+	for market := range symbolMap {
+		if strings.HasPrefix(market, "KRW-") {
+			symbols = append(symbols, strings.ToLower(strings.TrimPrefix(market, "KRW-")))
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// sectorUniverse 섹터 종목 중 시가총액 상위 목록(universe)에 없는 종목을 CoinGecko id로 조회해 universe에 더합니다.
+// 심볼 → id는 coin_gecko_ids에 저장해 두고, 처음 보는 심볼만 CoinGecko 전체 코인 목록에서 찾습니다.
+// 같은 심볼의 코인이 여럿이거나 찾지 못한 심볼은 빈 id로 기록하고 편입하지 않습니다. (운영자가 gecko_id를 지정하면 사용)
+func sectorUniverse(ctx context.Context, db *sql.DB, geckoBaseURL string, sectors []sector, universe []model.GeckoCoin) ([]model.GeckoCoin, error) {
+	bySymbol := geckoCoinsBySymbol(universe)
+	var missing []string
+	seen := make(map[string]bool)
+	for _, s := range sectors {
+		for _, symbol := range s.Symbols {
+			if _, ok := bySymbol[symbol]; !ok && !seen[symbol] {
+				seen[symbol] = true
+				missing = append(missing, symbol)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return universe, nil
+	}
+
+	ids, err := loadGeckoIDs(ctx, db, missing)
+	if err != nil {
+		return nil, err
+	}
+	var unknown []string
+	for _, symbol := range missing {
+		if _, ok := ids[symbol]; !ok {
+			unknown = append(unknown, symbol)
+		}
+	}
+	if len(unknown) > 0 {
+		list, err := getGeckoCoinList(ctx, geckoBaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("CoinGecko 코인 목록 조회 실패: %w", err)
+		}
+		resolved := resolveGeckoIDs(unknown, list)
+		if err := saveGeckoIDs(ctx, db, resolved); err != nil {
+			return nil, err
+		}
+		for symbol, id := range resolved {
+			ids[symbol] = id
+		}
+	}
+
+	symbolsByID := make(map[string]string, len(ids))
+	for symbol, id := range ids {
+		if id != "" {
+			symbolsByID[id] = symbol
+		}
+	}
+	if len(symbolsByID) == 0 {
+		return universe, nil
+	}
+	idList := make([]string, 0, len(symbolsByID))
+	for id := range symbolsByID {
+		idList = append(idList, id)
+	}
+	sort.Strings(idList)
+
+	extra, err := getMarketCapByIDs(ctx, geckoBaseURL, idList)
+	if err != nil {
+		return nil, fmt.Errorf("getMarketCapByIDs 에러: %w", err)
+	}
+
+	extended := append(make([]model.GeckoCoin, 0, len(universe)+len(extra)), universe...)
+	for _, coin := range extra {
+		if symbol, ok := symbolsByID[coin.ID]; ok {
+			coin.Symbol = symbol
+			extended = append(extended, coin)
+		}
+	}
+	return extended, nil
+}
+
+// resolveGeckoIDs CoinGecko 전체 코인 목록에서 심볼이 하나의 코인에만 해당하면 그 id를, 아니면 빈 id를 돌려줍니다.
+func resolveGeckoIDs(symbols []string, list []geckoListCoin) map[string]string {
+	candidates := make(map[string][]string)
+	for _, coin := range list {
+		symbol := strings.ToLower(coin.Symbol)
+		candidates[symbol] = append(candidates[symbol], coin.ID)
+	}
+
+	resolved := make(map[string]string, len(symbols))
+	for _, symbol := range symbols {
+		if ids := candidates[symbol]; len(ids) == 1 {
+			resolved[symbol] = ids[0]
+		} else {
+			resolved[symbol] = ""
+			log.Printf("CoinGecko id를 정할 수 없는 심볼 %s (후보 %d개)\n", symbol, len(ids))
+		}
+	}
+	return resolved
+}
+
+// loadGeckoIDs coin_gecko_ids에 기록된 심볼별 CoinGecko id를 조회합니다. (찾지 못한 심볼은 빈 id)
+func loadGeckoIDs(ctx context.Context, db *sql.DB, symbols []string) (map[string]string, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	placeholders := make([]string, len(symbols))
+	args := make([]interface{}, len(symbols))
+	for i, symbol := range symbols {
+		placeholders[i] = "?"
+		args[i] = symbol
+	}
+	query := fmt.Sprintf(`SELECT symbol, gecko_id FROM coin_gecko_ids WHERE symbol IN (%s)`, strings.Join(placeholders, ","))
+
+	rows, err := db.QueryContext(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CoinGecko id 조회 실패: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Printf("행 닫기 에러: %v\n", err)
+		}
+	}(rows)
+
+	ids := make(map[string]string, len(symbols))
+	for rows.Next() {
+		var symbol, id string
+		if err := rows.Scan(&symbol, &id); err != nil {
+			return nil, fmt.Errorf("행 스캔 에러: %w", err)
+		}
+		ids[strings.ToLower(symbol)] = id
+	}
+	return ids, rows.Err()
+}
+
+// saveGeckoIDs 새로 찾은 심볼별 CoinGecko id를 coin_gecko_ids에 저장합니다. (운영자가 지정한 id는 덮어쓰지 않음)
+func saveGeckoIDs(ctx context.Context, db *sql.DB, ids map[string]string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	valueStrings := make([]string, 0, len(ids))
+	valueArgs := make([]interface{}, 0, len(ids)*2)
+	for symbol, id := range ids {
+		valueStrings = append(valueStrings, "(?, ?)")
+		valueArgs = append(valueArgs, symbol, id)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT IGNORE INTO coin_gecko_ids (symbol, gecko_id) VALUES %s`, strings.Join(valueStrings, ","))
+	if _, err := db.ExecContext(queryCtx, query, valueArgs...); err != nil {
+		return fmt.Errorf("CoinGecko id 저장 실패: %w", err)
+	}
+	return nil
+}
+
+// updateSectorIndices 섹터별 정의에 따라 universe(sectorUniverse로 섹터 종목을 더한 목록)에서 섹터 종목을 골라 마켓 인덱스와 같은 방식으로 값을 계산하고,
+// market_index_levels/market_index_constituents와 sector_indices에 저장합니다.
+// 이번 시간에 시가총액을 찾은 종목이 하나도 없는 섹터는 건너뜁니다.
+func updateSectorIndices(ctx context.Context, db *sql.DB, sectors []sector, universe []model.GeckoCoin, date string, hour int) error {
+	if len(sectors) == 0 {
+		return nil
+	}
+
+	bySymbol := geckoCoinsBySymbol(universe)
+
+	targets := make([]indexTarget, 0, len(sectors))
+	codes := make([]string, 0, len(sectors))
+	for _, s := range sectors {
+		def, err := loadIndexDefinition(ctx, db, sectorIndexCode(s.Code), defaultSectorIndexDefinition(s.Code))
+		if err != nil {
+			return err
+		}
+
+		members := make([]model.GeckoCoin, 0, len(s.Symbols))
+		for _, symbol := range s.Symbols {
+			if coin, ok := bySymbol[symbol]; ok {
+				members = append(members, coin)
+			}
+		}
+		constituents := selectConstituents(def, members)
+		if len(constituents) == 0 {
+			log.Printf("%s 섹터 구성 종목의 시가총액을 찾지 못해 건너뜀\n", s.Code)
+			continue
+		}
+
+		targets = append(targets, indexTarget{def, constituents})
+		codes = append(codes, s.Code)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	levels, err := computeIndexLevels(ctx, db, date, hour, targets, universe)
+	if err != nil {
+		return err
+	}
+	if err := saveIndexLevels(ctx, db, date, hour, levels); err != nil {
+		return fmt.Errorf("saveIndexLevels 에러: %w", err)
+	}
+
+	return insertSectorIndices(ctx, db, date, hour, codes, levels)
+}
+
+// insertSectorIndices 섹터 인덱스 값을 sector_indices에 삽입합니다. (codes와 levels는 같은 순서)
+func insertSectorIndices(ctx context.Context, db *sql.DB, date string, hour int, codes []string, levels []indexLevel) error {
+	valueStrings := make([]string, 0, len(levels))
+	valueArgs := make([]interface{}, 0, len(levels)*6)
+	for i, level := range levels {
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?)")
+		valueArgs = append(valueArgs, codes[i], date, hour, level.Value, len(level.Constituents), level.Version)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO sector_indices (sector_code, date, hour, value, constituent_count, definition_version)
+		VALUES %s
+		ON DUPLICATE KEY UPDATE
+			value = VALUES(value),
+			constituent_count = VALUES(constituent_count),
+			definition_version = VALUES(definition_version)
+	`, strings.Join(valueStrings, ","))
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(queryCtx, query, valueArgs...); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	util.AddRows(ctx, int64(len(levels)))
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"Bitground-go/internal/fakeapi"
+	"Bitground-go/model"
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestResolveGeckoIDs(t *testing.T) {
+	list := []geckoListCoin{
+		{ID: "bora", Symbol: "BORA"},
+		{ID: "medibloc", Symbol: "med"},
+		{ID: "gas", Symbol: "gas"},
+		{ID: "gas-dao", Symbol: "gas"},
+	}
+
+	got := resolveGeckoIDs([]string{"bora", "med", "gas", "unknown"}, list)
+	want := map[string]string{"bora": "bora", "med": "medibloc", "gas": "", "unknown": ""}
+	if len(got) != len(want) {
+		t.Fatalf("resolveGeckoIDs = %v, want %v", got, want)
+	}
+	for symbol, id := range want {
+		if got[symbol] != id {
+			t.Errorf("%s id = %q, want %q", symbol, got[symbol], id)
+		}
+	}
+}
+
+func TestGeckoMarketCapOutsideTop(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+
+	// 상위 250개 밖의 국내 상장 코인
+	coins := make([]model.GeckoCoin, 0, geckoMaxPerPage+2)
+	for i := 0; i < geckoMaxPerPage; i++ {
+		coins = append(coins, model.GeckoCoin{ID: fmt.Sprintf("coin-%d", i), Symbol: fmt.Sprintf("c%d", i), MarketCap: int64(1000000 + i)})
+	}
+	coins = append(coins,
+		model.GeckoCoin{ID: "bora", Symbol: "bora", MarketCap: 500},
+		model.GeckoCoin{ID: "medibloc", Symbol: "med", MarketCap: 400},
+	)
+	api.SetMarketCaps(coins)
+	ctx := context.Background()
+
+	top, err := getMarketCap(ctx, api.URL(), geckoMaxPerPage)
+	if err != nil {
+		t.Fatalf("getMarketCap 에러: %v", err)
+	}
+	if _, ok := geckoCoinsBySymbol(top)["bora"]; ok || len(top) != geckoMaxPerPage {
+		t.Fatalf("상위 %d개에 bora가 없어야 함: %d개", geckoMaxPerPage, len(top))
+	}
+
+	list, err := getGeckoCoinList(ctx, api.URL())
+	if err != nil {
+		t.Fatalf("getGeckoCoinList 에러: %v", err)
+	}
+	ids := resolveGeckoIDs([]string{"bora", "med"}, list)
+
+	extra, err := getMarketCapByIDs(ctx, api.URL(), []string{ids["bora"], ids["med"]})
+	if err != nil {
+		t.Fatalf("getMarketCapByIDs 에러: %v", err)
+	}
+	if len(extra) != 2 || extra[0].ID != "bora" || extra[0].MarketCap != 500 || extra[1].ID != "medibloc" {
+		t.Errorf("id로 조회한 시가총액 = %+v", extra)
+	}
+}
+
+func TestSelectKimchiSector(t *testing.T) {
+	// 업비트 상장 종목 전체가 섹터 종목이어도 스테이블 코인은 편입되지 않음
+	def := defaultSectorIndexDefinition("KIMCHI")
+	members := []model.GeckoCoin{
+		{Symbol: "btc", MarketCap: 1000},
+		{Symbol: "usdt", MarketCap: 900},
+		{Symbol: "bora", MarketCap: 5},
+	}
+	constituents := selectConstituents(def, members)
+	if len(constituents) != 2 || constituents[0].Symbol != "btc" || constituents[1].Symbol != "bora" {
+		t.Errorf("constituents = %+v", constituents)
+	}
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"Bitground-go/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// 시장 심리 지수 구성 요소 (각 0-100, 50이 중립, 높을수록 탐욕)
+const (
+	sentimentBreadth    = "breadth"    // 상승 코인 비율
+	sentimentCaution    = "caution"    // 유의/경고 지정 코인 비율 (높을수록 공포)
+	sentimentVolatility = "volatility" // 최근 24시간 가격 변동폭 / 30일 평균 (클수록 공포)
+	sentimentMomentum   = "momentum"   // 마켓 인덱스 / 7일 평균
+	sentimentBehavior   = "behavior"   // 최근 24시간 체결 주문의 순매수 비율
+)
+
+// 지수 계산 순서 및 저장 컬럼 순서
+var sentimentComponents = []string{sentimentBreadth, sentimentCaution, sentimentVolatility, sentimentMomentum, sentimentBehavior}
+
+// 프롬프트에 표시할 구성 요소 이름
+var sentimentComponentNames = map[string]string{
+	sentimentBreadth:    "상승 코인 비율",
+	sentimentCaution:    "유의/경고 지정 비율 (높을수록 지정 코인 적음)",
+	sentimentVolatility: "가격 변동폭 (높을수록 30일 평균보다 안정)",
+	sentimentMomentum:   "마켓 인덱스 추세 (7일 평균 대비)",
+	sentimentBehavior:   "유저 순매수 (최근 24시간 체결)",
+}
+
+// 구성 요소 점수 환산 기준
+const (
+	sentimentCautionFearShare  = 0.2  // 유의/경고 코인 비율이 이 이상이면 0점
+	sentimentMomentumFullRate  = 10.0 // 7일 평균 대비 ±10%면 100점/0점
+	sentimentVolatilityDays    = 30
+	sentimentVolatilityMinDays = 7 // 시간봉 기록이 이 기간 이상 쌓여야 변동폭 점수 산출
+	sentimentMomentumDays      = 7
+)
+
+// sentimentIndex 한 시간의 시장 심리 지수와 구성 요소
+type sentimentIndex struct {
+	Value      float64
+	Label      string
+	Components map[string]float64 // 데이터가 있는 구성 요소별 점수
+	Details    map[string]float64 // 점수 산출에 쓴 원자료
+	MaxOrderID int64              // 순매수 비율 계산에 반영한 마지막 주문 id
+}
+
+// UpdateSentimentIndex 코인 등락, 유의/경고 지정, 가격 변동폭, 마켓 인덱스 추세, 유저 매수/매도 체결로
+// 공포-탐욕 형태의 시장 심리 지수(0-100)를 계산하여 구성 요소별 점수와 함께 sentiment_index에 기록합니다.
+// 데이터가 없는 구성 요소는 제외하고 나머지 점수의 평균을 지수로 사용합니다.
+func UpdateSentimentIndex(ctx context.Context, db *sql.DB, seasonID int) error {
+	if err := ensureSentimentIndexTable(ctx, db); err != nil {
+		return fmt.Errorf("시장 심리 지수 테이블 생성 실패: %w", err)
+	}
+
+	now := time.Now()
+	date, hour := indexHour(now)
+	index := sentimentIndex{
+		Components: make(map[string]float64),
+		Details:    make(map[string]float64),
+	}
+
+	// 1. 구성 요소별 점수 계산
+	if err := loadSentimentBreadth(ctx, db, &index); err != nil {
+		return fmt.Errorf("코인 등락 조회 실패: %w", err)
+	}
+	if err := loadSentimentVolatility(ctx, db, now, &index); err != nil {
+		return fmt.Errorf("가격 변동폭 조회 실패: %w", err)
+	}
+	if err := loadSentimentMomentum(ctx, db, now, &index); err != nil {
+		return fmt.Errorf("마켓 인덱스 추세 조회 실패: %w", err)
+	}
+	if err := loadSentimentBehavior(ctx, db, seasonID, now, &index); err != nil {
+		return fmt.Errorf("주문 체결 조회 실패: %w", err)
+	}
+	if len(index.Components) == 0 {
+		return errors.New("시장 심리 지수를 계산할 데이터가 없습니다")
+	}
+
+	// 2. 지수 산출 및 저장
+	var total float64
+	for _, score := range index.Components {
+		total += score
+	}
+	index.Value = total / float64(len(index.Components))
+	index.Label = sentimentLabel(index.Value)
+
+	if err := insertSentimentIndex(ctx, db, date, hour, index); err != nil {
+		return fmt.Errorf("insertSentimentIndex 에러: %w", err)
+	}
+
+	log.Printf("시장 심리 지수 %.1f (%s), 구성 요소 %d개\n", index.Value, index.Label, len(index.Components))
+	return nil
+}
+
+// ensureSentimentIndexTable sentiment_index 테이블이 없으면 생성
+// 구성 요소 점수는 데이터가 없어 계산하지 못했으면 NULL입니다.
+func ensureSentimentIndexTable(ctx context.Context, db execer) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS sentiment_index (
+			date DATE NOT NULL,
+			hour INT NOT NULL,
+			value DOUBLE NOT NULL,
+			label VARCHAR(20) NOT NULL,
+			breadth_score DOUBLE NULL,
+			caution_score DOUBLE NULL,
+			volatility_score DOUBLE NULL,
+			momentum_score DOUBLE NULL,
+			behavior_score DOUBLE NULL,
+			details JSON NULL,
+			max_order_id BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (date, hour)
+		)
+	`
+
+	_, err := db.ExecContext(queryCtx, createQuery)
+	return err
+}
+
+// 상장중인 코인의 상승/하락 비율과 유의/경고 지정 비율
+func loadSentimentBreadth(ctx context.Context, db *sql.DB, index *sentimentIndex) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			COALESCE(SUM(change_rate > 0), 0),
+			COALESCE(SUM(change_rate < 0), 0),
+			COALESCE(SUM(is_caution = 1 OR is_warning = 1), 0),
+			COUNT(*)
+		FROM coins
+		WHERE is_deleted = 0
+	`
+
+	var advancers, decliners, flagged, total int
+	if err := db.QueryRowContext(queryCtx, query).Scan(&advancers, &decliners, &flagged, &total); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	index.Details["advancers"] = float64(advancers)
+	index.Details["decliners"] = float64(decliners)
+	index.Details["flagged"] = float64(flagged)
+	index.Details["coins"] = float64(total)
+
+	if advancers+decliners > 0 {
+		index.Components[sentimentBreadth] = breadthScore(advancers, decliners)
+	}
+	index.Components[sentimentCaution] = cautionScore(flagged, total)
+	return nil
+}
+
+// 최근 24시간 가격 기록의 평균 변동폭((고가-저가)/저가)을 30일 평균과 비교합니다.
+// 일중 고가/저가를 담은 시간봉 이전 기록은 제외하고, 시간봉 기록이 7일 이상 쌓이기 전에는 점수를 내지 않습니다.
+// is_hourly_candle 컬럼이 아직 없으면 시간봉 기록이 없는 것으로 봅니다. (컬럼 추가는 가격 히스토리 업데이트에서 수행)
+func loadSentimentVolatility(ctx context.Context, db *sql.DB, now time.Time, index *sentimentIndex) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	recentDate, recentHour := indexHour(now.Add(-24 * time.Hour))
+	baseDate := now.AddDate(0, 0, -sentimentVolatilityDays).Format("2006-01-02")
+
+	query := `
+		SELECT
+			AVG(CASE WHEN date > ? OR (date = ? AND hour >= ?) THEN (high_price - low_price) / low_price END),
+			AVG((high_price - low_price) / low_price),
+			MIN(date)
+		FROM coin_price_history
+		WHERE date >= ? AND low_price > 0 AND is_hourly_candle = 1
+	`
+
+	var recent, base sql.NullFloat64
+	var first sql.NullTime
+	err := db.QueryRowContext(queryCtx, query, recentDate, recentDate, recentHour, baseDate).Scan(&recent, &base, &first)
+	if isMissingColumn(err) || isMissingTable(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	if !recent.Valid || !base.Valid || base.Float64 <= 0 || !first.Valid {
+		return nil
+	}
+	if first.Time.After(now.AddDate(0, 0, -sentimentVolatilityMinDays)) {
+		return nil // 비교할 시간봉 기록이 아직 부족함
+	}
+
+	index.Details["volatility_24h"] = recent.Float64
+	index.Details["volatility_30d"] = base.Float64
+	index.Components[sentimentVolatility] = volatilityScore(recent.Float64, base.Float64)
+	return nil
+}
+
+// 최신 마켓 인덱스를 7일 평균과 비교합니다.
+func loadSentimentMomentum(ctx context.Context, db *sql.DB, now time.Time, index *sentimentIndex) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			(SELECT market_index FROM market_indices ORDER BY date DESC, hour DESC LIMIT 1),
+			AVG(market_index)
+		FROM market_indices
+		WHERE date >= ?
+	`
+
+	var latest, average sql.NullFloat64
+	baseDate := now.AddDate(0, 0, -sentimentMomentumDays).Format("2006-01-02")
+	if err := db.QueryRowContext(queryCtx, query, baseDate).Scan(&latest, &average); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	if !latest.Valid || !average.Valid || average.Float64 <= 0 {
+		return nil
+	}
+
+	index.Details["market_index"] = latest.Float64
+	index.Details["market_index_7d"] = average.Float64
+	index.Components[sentimentMomentum] = momentumScore(latest.Float64, average.Float64)
+	return nil
+}
+
+// 현 시즌에서 최근 24시간 동안 새로 들어온 주문 중 체결된 주문의 순매수 비율 ((매수 - 매도) / 전체 체결 대금)
+// orders에는 주문 시각이 없어, 24시간 전 기록의 max_order_id 이후 주문을 최근 주문으로 봅니다.
+// 24시간 전 기록이 없으면 최근 주문을 가릴 수 없어 점수를 내지 않고, 다음 계산의 기준이 되도록 현재 마지막 주문 id만 기록합니다.
+func loadSentimentBehavior(ctx context.Context, db *sql.DB, seasonID int, now time.Time, index *sentimentIndex) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 1. 24시간 전 기준 주문 id
+	prevDate, prevHour := indexHour(now.Add(-24 * time.Hour))
+	var fromID int64
+	err := db.QueryRowContext(queryCtx, `
+		SELECT max_order_id
+		FROM sentiment_index
+		WHERE date < ? OR (date = ? AND hour <= ?)
+		ORDER BY date DESC, hour DESC
+		LIMIT 1
+	`, prevDate, prevDate, prevHour).Scan(&fromID)
+	if errors.Is(err, sql.ErrNoRows) {
+		maxQuery := `SELECT COALESCE(MAX(id), 0) FROM orders WHERE season_id = ?`
+		if err := db.QueryRowContext(queryCtx, maxQuery, seasonID).Scan(&index.MaxOrderID); err != nil {
+			return fmt.Errorf("쿼리 실행 에러: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("기준 주문 id 조회 실패: %w", err)
+	}
+	index.MaxOrderID = fromID
+
+	// 2. 기준 이후 체결된 매수/매도 대금
+	query := `
+		SELECT
+			COALESCE(SUM(CASE WHEN status = 'COMPLETED' AND order_type = 'BUY' THEN amount * trade_price END), 0),
+			COALESCE(SUM(CASE WHEN status = 'COMPLETED' AND order_type = 'SELL' THEN amount * trade_price END), 0),
+			COALESCE(MAX(id), 0)
+		FROM orders
+		WHERE season_id = ? AND id > ?
+	`
+	var buy, sell float64
+	var maxID int64
+	if err := db.QueryRowContext(queryCtx, query, seasonID, fromID).Scan(&buy, &sell, &maxID); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	if maxID > index.MaxOrderID {
+		index.MaxOrderID = maxID
+	}
+	if buy+sell <= 0 {
+		return nil
+	}
+
+	index.Details["buy_amount"] = buy
+	index.Details["sell_amount"] = sell
+	index.Components[sentimentBehavior] = behaviorScore(buy, sell)
+	return nil
+}
+
+// 상승 코인 비율 점수 (상승 또는 하락 코인이 하나 이상일 때)
+func breadthScore(advancers, decliners int) float64 {
+	return float64(advancers) / float64(advancers+decliners) * 100
+}
+
+// 유의/경고 지정 비율 점수 (지정 코인이 없으면 100점, 20% 이상이면 0점)
+func cautionScore(flagged, total int) float64 {
+	share := float64(flagged) / float64(total)
+	return clampScore(100 * (1 - share/sentimentCautionFearShare))
+}
+
+// 가격 변동폭 점수 (30일 평균과 같으면 50점, 두 배 이상이면 0점)
+func volatilityScore(recent, base float64) float64 {
+	return clampScore(50 - (recent/base-1)*50)
+}
+
+// 마켓 인덱스 추세 점수 (7일 평균과 같으면 50점, ±10%면 100점/0점)
+func momentumScore(latest, average float64) float64 {
+	rate := (latest - average) / average * 100
+	return clampScore(50 + rate/sentimentMomentumFullRate*50)
+}
+
+// 순매수 점수 (매수만 있으면 100점, 매도만 있으면 0점)
+func behaviorScore(buy, sell float64) float64 {
+	return clampScore(50 + (buy-sell)/(buy+sell)*50)
+}
+
+// 지수 구간별 라벨
+func sentimentLabel(value float64) string {
+	switch {
+	case value < 25:
+		return "EXTREME_FEAR"
+	case value < 45:
+		return "FEAR"
+	case value <= 55:
+		return "NEUTRAL"
+	case value <= 75:
+		return "GREED"
+	default:
+		return "EXTREME_GREED"
+	}
+}
+
+// 0-100 범위로 자릅니다.
+func clampScore(score float64) float64 {
+	return math.Max(0, math.Min(100, score))
+}
+
+// 시장 심리 지수 삽입 (같은 시간에 다시 실행하면 덮어씁니다)
+func insertSentimentIndex(ctx context.Context, db *sql.DB, date string, hour int, index sentimentIndex) error {
+	details, err := json.Marshal(index.Details)
+	if err != nil {
+		return fmt.Errorf("원자료 직렬화 실패: %w", err)
+	}
+
+	args := []interface{}{date, hour, index.Value, index.Label}
+	for _, name := range sentimentComponents {
+		score, ok := index.Components[name]
+		args = append(args, sql.NullFloat64{Float64: score, Valid: ok})
+	}
+	args = append(args, string(details), index.MaxOrderID)
+
+	query := `
+		INSERT INTO sentiment_index (date, hour, value, label,
+			breadth_score, caution_score, volatility_score, momentum_score, behavior_score, details, max_order_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			value = VALUES(value),
+			label = VALUES(label),
+			breadth_score = VALUES(breadth_score),
+			caution_score = VALUES(caution_score),
+			volatility_score = VALUES(volatility_score),
+			momentum_score = VALUES(momentum_score),
+			behavior_score = VALUES(behavior_score),
+			details = VALUES(details),
+			max_order_id = VALUES(max_order_id)
+	`
+
+	// Context를 활용한 쿼리 실행 (타임아웃: 10초)
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(queryCtx, query, args...); err != nil {
+		return fmt.Errorf("쿼리 실행 에러: %w", err)
+	}
+	util.AddRows(ctx, 1)
+
+	return nil
+}
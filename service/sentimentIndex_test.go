@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSentimentScores(t *testing.T) {
+	tests := []struct {
+		name  string
+		score float64
+		want  float64
+	}{
+		{"상승 비율 절반", breadthScore(5, 5), 50},
+		{"모두 상승", breadthScore(4, 0), 100},
+		{"모두 하락", breadthScore(0, 3), 0},
+		{"지정 코인 없음", cautionScore(0, 100), 100},
+		{"지정 10%", cautionScore(10, 100), 50},
+		{"지정 20%", cautionScore(20, 100), 0},
+		{"지정 50%는 0점으로 자름", cautionScore(50, 100), 0},
+		{"변동폭 평균과 같음", volatilityScore(0.02, 0.02), 50},
+		{"변동폭 절반", volatilityScore(0.01, 0.02), 75},
+		{"변동폭 두 배", volatilityScore(0.04, 0.02), 0},
+		{"변동폭 세 배는 0점으로 자름", volatilityScore(0.06, 0.02), 0},
+		{"변동폭 없음", volatilityScore(0, 0.02), 100},
+		{"인덱스 평균과 같음", momentumScore(1000, 1000), 50},
+		{"인덱스 +5%", momentumScore(1050, 1000), 75},
+		{"인덱스 -10%", momentumScore(900, 1000), 0},
+		{"인덱스 +20%는 100점으로 자름", momentumScore(1200, 1000), 100},
+		{"매수만", behaviorScore(100, 0), 100},
+		{"매도만", behaviorScore(0, 100), 0},
+		{"매수 3 : 매도 1", behaviorScore(300, 100), 75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !almostEqual(tt.score, tt.want) {
+				t.Errorf("점수 = %v, want %v", tt.score, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentimentLabel(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "EXTREME_FEAR"},
+		{24.9, "EXTREME_FEAR"},
+		{25, "FEAR"},
+		{44.9, "FEAR"},
+		{45, "NEUTRAL"},
+		{55, "NEUTRAL"},
+		{55.1, "GREED"},
+		{75, "GREED"},
+		{75.1, "EXTREME_GREED"},
+		{100, "EXTREME_GREED"},
+	}
+
+	for _, tt := range tests {
+		if got := sentimentLabel(tt.value); got != tt.want {
+			t.Errorf("sentimentLabel(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClampScore(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  float64
+	}{
+		{-10, 0},
+		{0, 0},
+		{42.5, 42.5},
+		{100, 100},
+		{150, 100},
+	}
+
+	for _, tt := range tests {
+		if got := clampScore(tt.score); got != tt.want {
+			t.Errorf("clampScore(%v) = %v, want %v", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSentimentBehavior(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	for _, query := range []string{
+		`DROP TABLE IF EXISTS sentiment_index`,
+		`DROP TABLE IF EXISTS orders`,
+		`CREATE TABLE orders (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			season_id INT NOT NULL,
+			amount DOUBLE NOT NULL,
+			trade_price DOUBLE NOT NULL,
+			order_type VARCHAR(10) NOT NULL,
+			status VARCHAR(20) NOT NULL
+		)`,
+		`INSERT INTO orders (season_id, amount, trade_price, order_type, status) VALUES
+			(1, 1, 100, 'BUY', 'COMPLETED'),
+			(1, 1, 100, 'SELL', 'COMPLETED'),
+			(2, 1, 100, 'BUY', 'COMPLETED'),
+			(1, 3, 100, 'BUY', 'COMPLETED'),
+			(1, 1, 100, 'SELL', 'COMPLETED'),
+			(1, 5, 100, 'SELL', 'PENDING')`,
+	} {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			t.Fatalf("테스트 테이블 준비 실패: %v", err)
+		}
+	}
+	if err := ensureSentimentIndexTable(ctx, db); err != nil {
+		t.Fatalf("ensureSentimentIndexTable 에러: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP TABLE IF EXISTS sentiment_index`)
+		_, _ = db.Exec(`DROP TABLE IF EXISTS orders`)
+	})
+
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	// 1. 24시간 전 기록이 없으면 시즌 전체를 보지 않고 기준 id만 기록
+	index := sentimentIndex{Components: make(map[string]float64), Details: make(map[string]float64)}
+	if err := loadSentimentBehavior(ctx, db, 1, now, &index); err != nil {
+		t.Fatalf("loadSentimentBehavior 에러: %v", err)
+	}
+	if _, ok := index.Components[sentimentBehavior]; ok || index.MaxOrderID != 6 {
+		t.Errorf("기록 없음: 점수 %v, 기준 id %d, want 점수 없음, 6", index.Components, index.MaxOrderID)
+	}
+
+	// 2. 24시간 전 기록 이후 체결된 주문만 반영 (id 4 매수 300, id 5 매도 100)
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO sentiment_index (date, hour, value, label, max_order_id)
+		VALUES ('2024-03-14', 11, 50, 'NEUTRAL', 3), ('2024-03-14', 13, 50, 'NEUTRAL', 4)
+	`); err != nil {
+		t.Fatalf("기준 기록 삽입 실패: %v", err)
+	}
+	index = sentimentIndex{Components: make(map[string]float64), Details: make(map[string]float64)}
+	if err := loadSentimentBehavior(ctx, db, 1, now, &index); err != nil {
+		t.Fatalf("loadSentimentBehavior 에러: %v", err)
+	}
+	if score := index.Components[sentimentBehavior]; !almostEqual(score, 75) || index.MaxOrderID != 6 {
+		t.Errorf("순매수 점수 %v, 기준 id %d, want 75, 6", score, index.MaxOrderID)
+	}
+}
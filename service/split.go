@@ -1,33 +1,75 @@
 package service
 
 import (
+	"Bitground-go/config"
+	"Bitground-go/util"
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"time"
 )
 
-func UpdateSplit(ctx context.Context, db *sql.DB, obj map[string]interface{}) error {
+// UpdateSplit 탈퇴하지 않은 모든 유저에게 시즌 경제 설정(season_economies)의 스플릿 지급액을 지급합니다.
+// 지급은 스플릿 일정 시각을 키로 cash_ledger에 기록되므로, 같은 일정으로 다시 실행해도 중복 지급되지 않습니다.
+func UpdateSplit(ctx context.Context, db *sql.DB, seasonID int, obj map[string]interface{}) (err error) {
 	seasonUpdateKey := obj["SEASON_UPDATE_KEY"].(string)
-	// db에서 모든 탈퇴하지 않은 유저의 자산을 천만 씩 추가
+	apiCfg := config.NewAPIConfig(obj)
 
-	// 쿼리 타임아웃 설정 (10초)
-	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	if seasonID == 0 {
+		return fmt.Errorf("현재 시즌 정보가 없어 스플릿을 지급할 수 없음")
+	}
+	runTime, err := util.RunTime(obj)
+	if err != nil {
+		return err
+	}
+	eventKey := runTime.Format("2006-01-02 15:04")
+
+	if err := ensureCashLedgerTable(ctx, db); err != nil {
+		return fmt.Errorf("cash_ledger 테이블 생성 실패: %w", err)
+	}
+	if err := ensureSeasonEconomyTable(ctx, db); err != nil {
+		return fmt.Errorf("season_economies 테이블 생성 실패: %w", err)
+	}
+
+	// 쿼리 타임아웃 설정 (30초)
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	query := `
-		UPDATE users
-		SET cash = cash + 10000000
-		WHERE is_deleted = 0;
-	`
+	// 트랜잭션 시작
+	tx, err := db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 에러: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
 
-	_, err := db.ExecContext(queryCtx, query)
+	// 시즌 경제 설정에 따라 모든 탈퇴하지 않은 유저에게 스플릿 지급 (이미 지급된 유저는 제외)
+	economy, err := getSeasonEconomy(queryCtx, tx, seasonID)
+	if err != nil {
+		return err
+	}
+	credited, err := creditSplitBonus(queryCtx, tx, economy, eventKey)
 	if err != nil {
-		return fmt.Errorf("쿼리 실행 에러: %w", err)
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("트랜잭션 커밋 에러: %w", err)
+	}
+	util.AddRows(ctx, credited)
+
+	if credited == 0 {
+		log.Printf("스플릿 %s 은 이미 지급되어 추가 지급 없음\n", eventKey)
 	}
 
 	// NotifySeasonUpdate 함수를 호출하여 스프링 서버에 시즌 업데이트 요청
-	if err := NotifySeasonUpdate(ctx, seasonUpdateKey, "split"); err != nil {
+	if err := NotifySeasonUpdate(ctx, apiCfg.BitgroundBaseURL, seasonUpdateKey, "split"); err != nil {
 		return fmt.Errorf("NotifySeasonUpdate 에러: %w", err)
 	}
 
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchFetcher after보다 큰 키를 가진 행을 키 오름차순으로 최대 limit개 조회하는 함수
+// 보통 `WHERE id > ? ORDER BY id LIMIT ?` 형태의 쿼리로 구현합니다.
+type BatchFetcher[T any] func(ctx context.Context, after int64, limit int) ([]T, error)
+
+// KeysetBatches keyset 커서 기반 배치 순회 함수
+// OFFSET 대신 직전 배치의 마지막 키를 커서로 사용하므로 테이블이 커져도 배치마다 일정한 비용으로 조회하고,
+// 순회 도중 행이 삭제/추가되어도 건너뛰거나 중복 처리하는 행이 없습니다.
+// key는 행의 정렬 키(양수 AUTO_INCREMENT id 등)를, handle은 배치 처리 함수를 지정하며 처리한 전체 행 수를 반환합니다.
+func KeysetBatches[T any](ctx context.Context, limit int, fetch BatchFetcher[T], key func(T) int64, handle func(batch []T) error) (int, error) {
+	if limit <= 0 {
+		return 0, fmt.Errorf("잘못된 배치 크기: %d", limit)
+	}
+
+	var cursor int64
+	processed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+
+		batch, err := fetch(ctx, cursor, limit)
+		if err != nil {
+			return processed, fmt.Errorf("배치 조회 실패 (커서: %d): %w", cursor, err)
+		}
+		if len(batch) == 0 {
+			return processed, nil
+		}
+
+		if err := handle(batch); err != nil {
+			return processed, fmt.Errorf("배치 처리 실패 (커서: %d): %w", cursor, err)
+		}
+		processed += len(batch)
+
+		// 마지막 배치면 추가 조회 없이 종료
+		if len(batch) < limit {
+			return processed, nil
+		}
+		cursor = key(batch[len(batch)-1])
+	}
+}
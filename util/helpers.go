@@ -3,7 +3,7 @@ package util
 import (
 	"Bitground-go/model"
 	"fmt"
-	"time"
+	"strconv"
 )
 
 // GeneratePlaceholders IN 절 placeholder 생성 헬퍼 함수
@@ -22,43 +22,38 @@ func GeneratePlaceholders(count int) string {
 // TimeCheck 시간 확인하여 수행할 업데이트 플래그를 반환하는 함수
 func TimeCheck(obj map[string]interface{}) (model.UpdateFlags, error) {
 	// 시간 설정
-	var now time.Time
-	var err error
-	if obj["TEST_TIME"] == "0000-00-00 00:00:00" {
-		now = time.Now().Round(time.Hour)
-	} else {
-		now, err = time.Parse("2006-01-02 15:04:05", obj["TEST_TIME"].(string))
-		if err != nil {
-			return model.UpdateFlags{}, fmt.Errorf("시간 파싱 실패: %w", err)
-		}
-		now = now.Round(time.Hour)
+	now, err := RunTime(obj)
+	if err != nil {
+		return model.UpdateFlags{}, err
 	}
 
-	chkType := obj["TYPE"].(string)
-	day := now.Day()
-	hour := now.Hour()
+	// 일정 설정
+	schedule, err := LoadSchedule(obj)
+	if err != nil {
+		return model.UpdateFlags{}, fmt.Errorf("실행 일정 설정 실패: %w", err)
+	}
 
-	var coinUpdate, seasonUpdate, splitUpdate, insightUpdate bool
+	return schedule.Flags(now), nil
+}
 
-	if chkType == "dev" {
-		seasonUpdate = hour%6 == 0
-		splitUpdate = hour%6 == 3
-	} else {
-		if hour == 0 {
-			seasonUpdate = day == 1 || day == 16
-			splitUpdate = day == 8 || day == 23
-		}
+// GetString obj에서 문자열 설정값을 읽고, 없거나 비어 있으면 기본값을 반환하는 함수
+func GetString(obj map[string]interface{}, key, defaultValue string) string {
+	if value, ok := obj[key].(string); ok && value != "" {
+		return value
 	}
+	return defaultValue
+}
 
-	if hour == 0 {
-		insightUpdate = true
-		coinUpdate = true
+// GetInt obj에서 정수 설정값을 읽고, 없거나 비어 있으면 기본값을 반환하는 함수
+func GetInt(obj map[string]interface{}, key string, defaultValue int) (int, error) {
+	raw := GetString(obj, key, "")
+	if raw == "" {
+		return defaultValue, nil
 	}
 
-	return model.UpdateFlags{
-		Season:  seasonUpdate,
-		Split:   splitUpdate,
-		Coin:    coinUpdate,
-		Insight: insightUpdate,
-	}, nil
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s 설정값 '%s'이 정수가 아님", key, raw)
+	}
+	return value, nil
 }
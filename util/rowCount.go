@@ -0,0 +1,23 @@
+package util
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type rowCounterKey struct{}
+
+// WithRowCounter 작업 단계에서 처리한 행 수를 집계할 카운터를 컨텍스트에 담아 반환합니다.
+// 서비스 함수는 AddRows로 처리한 행 수를 더하고, 호출자는 반환된 카운터로 합계를 읽습니다.
+func WithRowCounter(ctx context.Context) (context.Context, *int64) {
+	counter := new(int64)
+	return context.WithValue(ctx, rowCounterKey{}, counter), counter
+}
+
+// AddRows 컨텍스트의 행 카운터에 n을 더합니다. 카운터가 없으면 아무것도 하지 않습니다.
+// 여러 고루틴에서 동시에 호출해도 안전합니다.
+func AddRows(ctx context.Context, n int64) {
+	if counter, ok := ctx.Value(rowCounterKey{}).(*int64); ok {
+		atomic.AddInt64(counter, n)
+	}
+}
@@ -0,0 +1,235 @@
+package util
+
+import (
+	"Bitground-go/model"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 기본 일정 (cron 표현식: 분 시 일 월 요일)
+// 운영: 매월 1일/16일 0시 시즌 시작, 8일/23일 0시 스플릿
+// 개발: 6시간마다 시즌 시작, 시즌 시작 3시간 뒤 스플릿
+const (
+	defaultSeasonSchedule    = "0 0 1,16 * *"
+	defaultSplitSchedule     = "0 0 8,23 * *"
+	defaultDevSeasonSchedule = "0 */6 * * *"
+	defaultDevSplitSchedule  = "0 3-23/6 * * *"
+	defaultDailySchedule     = "0 0 * * *"
+)
+
+// Next 탐색 한도 (이 기간 안에 일치하는 시각이 없으면 실패로 처리)
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronExpr 분 시 일 월 요일 5개 필드로 이루어진 cron 표현식
+// 각 필드는 *, 숫자, 목록(a,b), 범위(a-b), 간격(*/n, a-b/n)을 지원합니다.
+type CronExpr struct {
+	raw        string
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+// Schedule 업데이트 종류별 실행 일정
+// 플래그 계산과 시즌 종료일 계산이 같은 일정을 사용하므로 둘이 어긋나지 않습니다.
+type Schedule struct {
+	Season  CronExpr
+	Split   CronExpr
+	Coin    CronExpr
+	Insight CronExpr
+}
+
+// ParseCron cron 표현식 파싱 함수
+func ParseCron(expr string) (CronExpr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return CronExpr{}, fmt.Errorf("cron 표현식 '%s'의 필드 수가 5개가 아님", expr)
+	}
+
+	c := CronExpr{raw: expr}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	targets := [5]*uint64{&c.minutes, &c.hours, &c.days, &c.months, &c.weekdays}
+	for i, field := range fields {
+		bits, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return CronExpr{}, fmt.Errorf("cron 표현식 '%s' 파싱 실패: %w", expr, err)
+		}
+		*targets[i] = bits
+	}
+	c.anyDay = fields[2] == "*"
+	c.anyWeekday = fields[4] == "*"
+
+	return c, nil
+}
+
+// cron 필드 하나를 허용 값 비트마스크로 변환합니다.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("잘못된 간격 '%s'", part)
+			}
+			rangePart, step = part[:idx], s
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("잘못된 값 '%s'", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("잘못된 값 '%s'", part)
+				}
+			} else if step > 1 {
+				hi = max // "a/n" 은 a부터 최댓값까지
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("허용 범위(%d-%d)를 벗어난 값 '%s'", min, max, part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// String 원본 cron 표현식
+func (c CronExpr) String() string {
+	return c.raw
+}
+
+// Matches 주어진 시각(분 단위)이 표현식과 일치하는지 확인합니다.
+func (c CronExpr) Matches(t time.Time) bool {
+	return c.minutes&(1<<uint(t.Minute())) != 0 &&
+		c.hours&(1<<uint(t.Hour())) != 0 &&
+		c.months&(1<<uint(t.Month())) != 0 &&
+		c.matchesDay(t)
+}
+
+// 일/요일 필드는 둘 다 지정된 경우 어느 하나만 일치해도 됩니다. (표준 cron 규칙)
+func (c CronExpr) matchesDay(t time.Time) bool {
+	dayMatch := c.days&(1<<uint(t.Day())) != 0
+	weekdayMatch := c.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatch
+	case c.anyWeekday:
+		return dayMatch
+	default:
+		return dayMatch || weekdayMatch
+	}
+}
+
+// Next after 이후(after 제외) 처음으로 표현식과 일치하는 시각을 반환합니다.
+func (c CronExpr) Next(after time.Time) (time.Time, error) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 || !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("cron 표현식 '%s'과 일치하는 다음 시각을 찾을 수 없음", c.raw)
+}
+
+// LoadSchedule obj 설정으로 실행 일정을 구성하는 함수
+// SEASON_SCHEDULE, SPLIT_SCHEDULE, COIN_SCHEDULE, INSIGHT_SCHEDULE 값이 없으면 TYPE에 맞는 기본 일정을 사용합니다.
+func LoadSchedule(obj map[string]interface{}) (Schedule, error) {
+	seasonDefault, splitDefault := defaultSeasonSchedule, defaultSplitSchedule
+	if GetString(obj, "TYPE", "") == "dev" {
+		seasonDefault, splitDefault = defaultDevSeasonSchedule, defaultDevSplitSchedule
+	}
+
+	var schedule Schedule
+	var err error
+	if schedule.Season, err = parseScheduleSetting(obj, "SEASON_SCHEDULE", seasonDefault); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.Split, err = parseScheduleSetting(obj, "SPLIT_SCHEDULE", splitDefault); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.Coin, err = parseScheduleSetting(obj, "COIN_SCHEDULE", defaultDailySchedule); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.Insight, err = parseScheduleSetting(obj, "INSIGHT_SCHEDULE", defaultDailySchedule); err != nil {
+		return Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// obj의 일정 설정값 하나를 파싱합니다.
+func parseScheduleSetting(obj map[string]interface{}, key, defaultExpr string) (CronExpr, error) {
+	expr, err := ParseCron(GetString(obj, key, defaultExpr))
+	if err != nil {
+		return CronExpr{}, fmt.Errorf("%s 설정 오류: %w", key, err)
+	}
+	return expr, nil
+}
+
+// Flags 주어진 시각에 수행할 업데이트 플래그를 계산합니다.
+func (s Schedule) Flags(now time.Time) model.UpdateFlags {
+	return model.UpdateFlags{
+		Season:  s.Season.Matches(now),
+		Split:   s.Split.Matches(now),
+		Coin:    s.Coin.Matches(now),
+		Insight: s.Insight.Matches(now),
+	}
+}
+
+// SeasonEnd startAt에 시작하는 시즌의 종료 시각(다음 시즌 시작 직전)을 계산합니다.
+func (s Schedule) SeasonEnd(startAt time.Time) (time.Time, error) {
+	if !s.Season.Matches(startAt) {
+		return time.Time{}, fmt.Errorf("%s 는 시즌 시작 일정(%s)이 아님", startAt.Format("2006-01-02 15:04"), s.Season)
+	}
+
+	next, err := s.Season.Next(startAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return next.Add(-time.Second), nil
+}
+
+// RunTime 이번 실행의 기준 시각(정시로 반올림)을 반환하는 함수
+// TEST_TIME이 "0000-00-00 00:00:00"이 아니면 해당 시각을 기준으로 합니다.
+func RunTime(obj map[string]interface{}) (time.Time, error) {
+	testTime := GetString(obj, "TEST_TIME", "0000-00-00 00:00:00")
+	if testTime == "0000-00-00 00:00:00" {
+		return time.Now().Round(time.Hour), nil
+	}
+
+	now, err := time.Parse("2006-01-02 15:04:05", testTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("시간 파싱 실패: %w", err)
+	}
+	return now.Round(time.Hour), nil
+}
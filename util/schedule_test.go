@@ -0,0 +1,189 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func cronTime(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestParseCronFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		min   int
+		max   int
+		want  []int
+	}{
+		{"별표", "*", 1, 12, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{"단일 값", "5", 0, 59, []int{5}},
+		{"목록", "1,16", 1, 31, []int{1, 16}},
+		{"범위", "3-6", 0, 23, []int{3, 4, 5, 6}},
+		{"별표 간격", "*/6", 0, 23, []int{0, 6, 12, 18}},
+		{"범위 간격", "3-23/6", 0, 23, []int{3, 9, 15, 21}},
+		{"시작값 간격", "10/20", 0, 59, []int{10, 30, 50}},
+		{"목록과 범위", "0,30-32,45", 0, 59, []int{0, 30, 31, 32, 45}},
+		{"요일 범위", "1-5", 0, 6, []int{1, 2, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bits, err := parseCronField(tt.field, tt.min, tt.max)
+			if err != nil {
+				t.Fatalf("parseCronField(%q) 에러: %v", tt.field, err)
+			}
+			var want uint64
+			for _, v := range tt.want {
+				want |= 1 << uint(v)
+			}
+			if bits != want {
+				t.Errorf("parseCronField(%q) = %b, want %b", tt.field, bits, want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"0 0 * *",
+		"0 0 * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-b * * * *",
+	}
+
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) 에러가 나야 함", expr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	tests := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"0 0 1,16 * *", cronTime(2024, 3, 16, 0, 0), true},
+		{"0 0 1,16 * *", cronTime(2024, 3, 16, 0, 1), false},
+		{"0 0 1,16 * *", cronTime(2024, 3, 15, 0, 0), false},
+		{"0 3-23/6 * * *", cronTime(2024, 3, 15, 21, 0), true},
+		{"0 3-23/6 * * *", cronTime(2024, 3, 15, 0, 0), false},
+		// 2024-03-18은 월요일
+		{"0 9 * * 1", cronTime(2024, 3, 18, 9, 0), true},
+		{"0 9 * * 1", cronTime(2024, 3, 19, 9, 0), false},
+		{"0 0 * 2 *", cronTime(2024, 2, 29, 0, 0), true},
+		{"0 0 * 2 *", cronTime(2024, 3, 1, 0, 0), false},
+		// 일과 요일이 둘 다 지정되면 어느 하나만 일치해도 됨
+		{"0 0 1 * 1", cronTime(2024, 3, 18, 0, 0), true},
+		{"0 0 1 * 1", cronTime(2024, 3, 1, 0, 0), true},
+		{"0 0 1 * 1", cronTime(2024, 3, 19, 0, 0), false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) 에러: %v", tt.expr, err)
+		}
+		if got := c.Matches(tt.at); got != tt.want {
+			t.Errorf("%q Matches(%s) = %v, want %v", tt.expr, tt.at.Format("2006-01-02 15:04 Mon"), got, tt.want)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"같은 시간 다음 분", "*/15 * * * *", cronTime(2024, 3, 15, 10, 7), cronTime(2024, 3, 15, 10, 15)},
+		{"일치 시각 자신은 제외", "0 */6 * * *", cronTime(2024, 3, 15, 6, 0), cronTime(2024, 3, 15, 12, 0)},
+		{"초 단위 버림", "0 */6 * * *", cronTime(2024, 3, 15, 5, 59).Add(30 * time.Second), cronTime(2024, 3, 15, 6, 0)},
+		{"다음 날로 넘김", "0 3-23/6 * * *", cronTime(2024, 3, 15, 21, 0), cronTime(2024, 3, 16, 3, 0)},
+		{"월말 넘김", "0 0 1,16 * *", cronTime(2024, 4, 16, 0, 0), cronTime(2024, 5, 1, 0, 0)},
+		{"31일 없는 달 건너뜀", "0 0 31 * *", cronTime(2024, 4, 1, 0, 0), cronTime(2024, 5, 31, 0, 0)},
+		{"윤년 2월 29일", "0 0 29 2 *", cronTime(2023, 3, 1, 0, 0), cronTime(2024, 2, 29, 0, 0)},
+		{"연말 넘김", "0 0 1,16 * *", cronTime(2024, 12, 16, 0, 0), cronTime(2025, 1, 1, 0, 0)},
+		{"연말 마지막 분", "* * * * *", cronTime(2024, 12, 31, 23, 59), cronTime(2025, 1, 1, 0, 0)},
+		{"요일 지정", "30 9 * * 1", cronTime(2024, 12, 31, 12, 0), cronTime(2025, 1, 6, 9, 30)},
+		{"일 또는 요일", "0 0 1 * 0", cronTime(2024, 3, 25, 0, 0), cronTime(2024, 3, 31, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) 에러: %v", tt.expr, err)
+			}
+			got, err := c.Next(tt.after)
+			if err != nil {
+				t.Fatalf("%q Next(%s) 에러: %v", tt.expr, tt.after, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("%q Next(%s) = %s, want %s", tt.expr, tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextNoMatch(t *testing.T) {
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron 에러: %v", err)
+	}
+	if _, err := c.Next(cronTime(2024, 1, 1, 0, 0)); err == nil {
+		t.Error("2월 30일은 없으므로 에러가 나야 함")
+	}
+}
+
+func TestScheduleSeasonEnd(t *testing.T) {
+	schedule, err := LoadSchedule(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("LoadSchedule 에러: %v", err)
+	}
+
+	end, err := schedule.SeasonEnd(cronTime(2024, 12, 16, 0, 0))
+	if err != nil {
+		t.Fatalf("SeasonEnd 에러: %v", err)
+	}
+	if want := cronTime(2024, 12, 31, 23, 59).Add(59 * time.Second); !end.Equal(want) {
+		t.Errorf("SeasonEnd = %s, want %s", end, want)
+	}
+
+	if _, err := schedule.SeasonEnd(cronTime(2024, 12, 17, 0, 0)); err == nil {
+		t.Error("시즌 시작 일정이 아닌 시각은 에러가 나야 함")
+	}
+}
+
+func TestLoadScheduleDev(t *testing.T) {
+	schedule, err := LoadSchedule(map[string]interface{}{"TYPE": "dev", "COIN_SCHEDULE": "0 12 * * *"})
+	if err != nil {
+		t.Fatalf("LoadSchedule 에러: %v", err)
+	}
+
+	flags := schedule.Flags(cronTime(2024, 3, 15, 12, 0))
+	if !flags.Season || flags.Split || !flags.Coin || flags.Insight {
+		t.Errorf("Flags(12:00) = %+v", flags)
+	}
+	flags = schedule.Flags(cronTime(2024, 3, 15, 15, 0))
+	if flags.Season || !flags.Split || flags.Coin {
+		t.Errorf("Flags(15:00) = %+v", flags)
+	}
+
+	if _, err := LoadSchedule(map[string]interface{}{"SEASON_SCHEDULE": "0 0 1"}); err == nil {
+		t.Error("잘못된 SEASON_SCHEDULE은 에러가 나야 함")
+	}
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"math"
+	"sort"
+)
+
+// SpearmanCorrelation 두 값 목록의 스피어만 순위 상관계수 (-1 ~ 1)
+// 동순위는 평균 순위를 사용합니다. 값이 2개 미만이거나 한쪽 순위가 모두 같으면 NaN을 반환합니다.
+func SpearmanCorrelation(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return math.NaN()
+	}
+	return pearson(ranks(x), ranks(y))
+}
+
+// 평균 순위 (1부터 시작)
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+
+	result := make([]float64, len(values))
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && values[order[end]] == values[order[start]] {
+			end++
+		}
+		rank := float64(start+end+1) / 2 // start+1 ~ end 순위의 평균
+		for _, idx := range order[start:end] {
+			result[idx] = rank
+		}
+		start = end
+	}
+	return result
+}
+
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varX*varY)
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpearmanCorrelation(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64 // NaN이면 NaN 기대
+	}{
+		{"같은 순서", []float64{1, 2, 3, 4}, []float64{10, 20, 30, 40}, 1},
+		{"반대 순서", []float64{1, 2, 3, 4}, []float64{4, 3, 2, 1}, -1},
+		{"순위만 비교", []float64{1, 2, 3}, []float64{1, 100, 1000}, 1},
+		{"한쪽 동순위", []float64{1, 2, 2, 3}, []float64{1, 2, 3, 4}, 4.5 / math.Sqrt(22.5)},
+		{"양쪽 동순위", []float64{1, 1, 2, 2}, []float64{5, 5, 6, 6}, 1},
+		{"한쪽 값이 모두 같음", []float64{50, 50, 50}, []float64{1, 2, 3}, math.NaN()},
+		{"다른쪽 값이 모두 같음", []float64{1, 2, 3}, []float64{0, 0, 0}, math.NaN()},
+		{"값이 하나", []float64{1}, []float64{1}, math.NaN()},
+		{"길이가 다름", []float64{1, 2, 3}, []float64{1, 2}, math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SpearmanCorrelation(tt.x, tt.y)
+			if math.IsNaN(tt.want) {
+				if !math.IsNaN(got) {
+					t.Errorf("SpearmanCorrelation = %v, want NaN", got)
+				}
+				return
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("SpearmanCorrelation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRanks(t *testing.T) {
+	got := ranks([]float64{30, 10, 20, 10, 30, 30})
+	want := []float64{5, 1.5, 3, 1.5, 5, 5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ranks = %v, want %v", got, want)
+			break
+		}
+	}
+}